@@ -4,10 +4,14 @@ Build embeddings database for game recommendations.
 Processes enriched game metadata and creates vector embeddings for semantic search.
 """
 
+import argparse
 import json
 from pathlib import Path
 from typing import List, Dict
 
+# Sentence-Transformers model used for the launcher's vectors by default
+DEFAULT_MODEL = "all-MiniLM-L6-v2"
+
 def load_enriched_games(metadata_dir: Path) -> List[Dict]:
     """Load all enriched game data from metadata directory."""
     all_games = []
@@ -68,7 +72,7 @@ def build_embedding_dataset(output_file: Path):
     if not metadata_dir.exists():
         print(f"ERROR: {metadata_dir} not found")
         print("Please run scrape_game_metadata.py first")
-        return
+        return []
 
     print("Loading enriched game data...")
     games = load_enriched_games(metadata_dir)
@@ -77,7 +81,7 @@ def build_embedding_dataset(output_file: Path):
 
     if not games:
         print("No games found. Please run scrape_game_metadata.py first.")
-        return
+        return []
 
     # Build embedding dataset
     print("\nBuilding embedding dataset...")
@@ -137,11 +141,39 @@ def build_embedding_dataset(output_file: Path):
     avg_length = sum(len(g['embedding_text']) for g in embedding_dataset) / len(embedding_dataset)
     print(f"\nAverage embedding text length: {avg_length:.0f} characters")
 
-    print("\nNext steps:")
-    print("  1. Use an embedding model (e.g., OpenAI, Sentence-Transformers)")
-    print("  2. Generate embeddings for each game's embedding_text")
-    print("  3. Store embeddings in a vector database (ChromaDB, FAISS, Pinecone)")
-    print("  4. Build semantic search for game recommendations")
+    return embedding_dataset
+
+
+def build_game_embeddings(dataset: List[Dict], output_file: Path, model_name: str = DEFAULT_MODEL):
+    """
+    Write the vector file the launcher's "More like this" list reads.
+    Each dataset entry gets an "embedding" list; the launcher matches
+    entries to games by metadata.rawg_id.
+    """
+    try:
+        from sentence_transformers import SentenceTransformer
+    except ImportError:
+        print("\nsentence-transformers is not installed, skipping game_embeddings.json")
+        print("  pip install sentence-transformers")
+        print("Without it, the launcher compares games by genres, tags and developers.")
+        return
+
+    print(f"\nGenerating embeddings with {model_name}...")
+    model = SentenceTransformer(model_name)
+    texts = [game['embedding_text'] for game in dataset]
+    # Normalised, so every vector has the same length and scale
+    embeddings = model.encode(texts, show_progress_bar=True, normalize_embeddings=True)
+
+    entries = [dict(game, embedding=[round(float(x), 6) for x in vector])
+               for game, vector in zip(dataset, embeddings)]
+
+    # Write a temp file first so a failed run doesn't leave a truncated file
+    tmp_file = output_file.with_name(output_file.name + ".tmp")
+    with open(tmp_file, 'w', encoding='utf-8') as f:
+        json.dump(entries, f, ensure_ascii=False)
+    tmp_file.replace(output_file)
+
+    print(f"✓ Saved {len(entries)} embeddings to: {output_file}")
 
 
 def show_example_games(n=5):
@@ -170,10 +202,19 @@ def show_example_games(n=5):
 
 
 if __name__ == "__main__":
+    parser = argparse.ArgumentParser(description=__doc__)
+    parser.add_argument("--model", default=DEFAULT_MODEL,
+                        help=f"Sentence-Transformers model (default: {DEFAULT_MODEL})")
+    parser.add_argument("--no-vectors", action="store_true",
+                        help="only write embedding_dataset.json")
+    args = parser.parse_args()
+
     project_root = Path(__file__).parent
     output_file = project_root / "game_metadata" / "embedding_dataset.json"
 
-    build_embedding_dataset(output_file)
+    dataset = build_embedding_dataset(output_file)
+    if dataset and not args.no_vectors:
+        build_game_embeddings(dataset, project_root / "game_metadata" / "game_embeddings.json", args.model)
 
     # Show some examples
     show_example_games(3)
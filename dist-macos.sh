@@ -45,13 +45,17 @@ echo ""
 echo "Creating macOS distribution..."
 MAC_DIR="dist/EmuBuddy-macOS-v${VERSION}"
 rm -rf "$MAC_DIR"
-mkdir -p "$MAC_DIR/1g1rsets"
+mkdir -p "$MAC_DIR/1g1rsets" "$MAC_DIR/game_metadata"
 
 cp EmuBuddyLauncher-macos "$MAC_DIR/"
 cp EmuBuddySetup-macos "$MAC_DIR/"
 cp systems.json "$MAC_DIR/"
 cp README.md "$MAC_DIR/"
 cp 1g1rsets/*.json "$MAC_DIR/1g1rsets/"
+cp game_metadata/*_enriched.json "$MAC_DIR/game_metadata/"
+if [ -f game_metadata/game_embeddings.json ]; then
+    cp game_metadata/game_embeddings.json "$MAC_DIR/game_metadata/"
+fi
 
 # Create .command files for double-click
 cat > "$MAC_DIR/Start EmuBuddy.command" << 'EOF'
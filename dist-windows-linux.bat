@@ -90,12 +90,15 @@ set WIN_DIR=dist\EmuBuddy-Windows-v%VERSION%
 if exist "%WIN_DIR%" rmdir /s /q "%WIN_DIR%"
 mkdir "%WIN_DIR%"
 mkdir "%WIN_DIR%\1g1rsets"
+mkdir "%WIN_DIR%\game_metadata"
 
 copy EmuBuddyLauncher.exe "%WIN_DIR%\" >nul
 copy EmuBuddySetup.exe "%WIN_DIR%\" >nul
 copy systems.json "%WIN_DIR%\" >nul
 copy README.md "%WIN_DIR%\" >nul
 xcopy 1g1rsets\*.json "%WIN_DIR%\1g1rsets\" /q >nul
+xcopy game_metadata\*_enriched.json "%WIN_DIR%\game_metadata\" /q >nul
+if exist game_metadata\game_embeddings.json copy game_metadata\game_embeddings.json "%WIN_DIR%\game_metadata\" >nul
 
 cd dist
 :: Use 7-Zip for better cross-platform ZIP compatibility
@@ -115,12 +118,15 @@ set LIN_DIR=dist\EmuBuddy-Linux-v%VERSION%
 if exist "%LIN_DIR%" rmdir /s /q "%LIN_DIR%"
 mkdir "%LIN_DIR%"
 mkdir "%LIN_DIR%\1g1rsets"
+mkdir "%LIN_DIR%\game_metadata"
 
 copy EmuBuddyLauncher-linux "%LIN_DIR%\" >nul
 copy EmuBuddySetup-linux "%LIN_DIR%\" >nul
 copy systems.json "%LIN_DIR%\" >nul
 copy README.md "%LIN_DIR%\" >nul
 xcopy 1g1rsets\*.json "%LIN_DIR%\1g1rsets\" /q >nul
+xcopy game_metadata\*_enriched.json "%LIN_DIR%\game_metadata\" /q >nul
+if exist game_metadata\game_embeddings.json copy game_metadata\game_embeddings.json "%LIN_DIR%\game_metadata\" >nul
 
 :: Create run scripts
 echo #!/bin/bash > "%LIN_DIR%\start-emubuddy.sh"
@@ -0,0 +1,508 @@
+// Package extract unpacks zip, 7z, RAR and tar archives for the launcher and
+// the installer, without external tools.
+//
+// Archives come from the internet, so entries are checked before anything is
+// written: names that leave the destination ("../", absolute paths, drive
+// letters) and links that point out of it stop the extraction, as does
+// writing more than the size limit. With Options.SkipUnsafe such names are
+// left out instead. The archive is first extracted to a
+// staging folder inside the destination, then each file is renamed into
+// place. If that fails partway the renames are undone, so a failed
+// extraction leaves the destination as it found it.
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxSize is the size limit when Options.MaxSize is 0. The largest
+// games (dual-layer DVDs, Wii U titles) are well under it.
+const DefaultMaxSize = 32 << 30
+
+var (
+	// ErrUnsafePath means an entry's name leaves the destination folder
+	ErrUnsafePath = errors.New("path leaves the destination folder")
+	// ErrUnsafeLink means a link points outside the destination folder or
+	// to nothing
+	ErrUnsafeLink = errors.New("link points outside the destination folder")
+	// ErrTooLarge means the archive holds more than the size limit
+	ErrTooLarge = errors.New("archive is larger than the extraction limit")
+)
+
+// Error is a failed extraction. Err is ErrUnsafePath, ErrUnsafeLink,
+// ErrTooLarge or the error that stopped it, such as a full disk.
+type Error struct {
+	Archive string // File name of the archive
+	Entry   string // Entry being extracted, if any
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Entry != "" {
+		return fmt.Sprintf("extracting %s: %s: %v", e.Archive, e.Entry, e.Err)
+	}
+	return fmt.Sprintf("extracting %s: %v", e.Archive, e.Err)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Options changes how an archive is extracted
+type Options struct {
+	MaxSize   int64 // Most bytes written, DefaultMaxSize if 0
+	StripRoot bool  // Leave out a folder that holds every entry
+	Flatten   bool  // Extract files by name only, without their folders
+
+	// SkipUnsafe leaves out entries whose names leave the destination
+	// instead of failing. Links that point out of it still fail.
+	SkipUnsafe bool
+}
+
+// archiveExts are the archive formats Archive extracts
+var archiveExts = []string{".zip", ".7z", ".rar"}
+
+// IsArchive reports whether a file is an archive Archive can extract
+func IsArchive(name string) bool {
+	return TrimExt(name) != name
+}
+
+// TrimExt returns a file name without its archive extension
+func TrimExt(name string) string {
+	for _, ext := range archiveExts {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
+
+// Archive extracts a zip, 7z or RAR file, going by its extension, into
+// destDir and returns the paths of the files it extracted. Errors are *Error.
+func Archive(archivePath, destDir string, opts Options) ([]string, error) {
+	switch strings.ToLower(filepath.Ext(archivePath)) {
+	case ".zip":
+		return Zip(archivePath, destDir, opts)
+	case ".7z":
+		return SevenZip(archivePath, destDir, opts)
+	case ".rar":
+		return Rar(archivePath, destDir, opts)
+	}
+	return nil, &Error{Archive: filepath.Base(archivePath), Err: fmt.Errorf("unsupported archive format")}
+}
+
+// entry is one file, folder or link of an archive
+type entry struct {
+	name   string // Slash-separated, as in the archive
+	dir    bool
+	link   string // Link target, for symbolic links
+	mode   os.FileMode
+	size   int64 // Declared size, may be wrong
+	open   func() (io.ReadCloser, error)
+	isHard bool // link is a hard link, relative to the archive root
+
+	// spooled is the file already holding the content of a streamed entry,
+	// size bytes long. It's renamed into place instead of being copied.
+	spooled string
+}
+
+// extractor writes the entries of one archive into a staging folder
+type extractor struct {
+	archive string
+	destDir string
+	staging string
+	opts    Options
+	written int64
+	files   []string // Staged files and links, relative to staging
+	links   []string // Staged symbolic links, relative to staging
+}
+
+func newExtractor(archive, destDir string, opts Options) (*extractor, error) {
+	if opts.MaxSize == 0 {
+		opts.MaxSize = DefaultMaxSize
+	}
+	x := &extractor{archive: filepath.Base(archive), destDir: filepath.Clean(destDir), opts: opts}
+	if err := os.MkdirAll(x.destDir, 0755); err != nil {
+		return nil, x.fail("", err)
+	}
+	staging, err := os.MkdirTemp(x.destDir, ".extracting-")
+	if err != nil {
+		return nil, x.fail("", err)
+	}
+	x.staging = staging
+	return x, nil
+}
+
+func (x *extractor) fail(entry string, err error) error {
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	return &Error{Archive: x.archive, Entry: entry, Err: err}
+}
+
+// cleanup removes the staging folder
+func (x *extractor) cleanup() {
+	os.RemoveAll(x.staging)
+}
+
+// relPath returns where an entry goes, relative to the destination, or ""
+// for entries that are left out, like the stripped root folder itself
+func (x *extractor) relPath(name, root string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(name, "/") || (len(name) > 1 && name[1] == ':') {
+		return "", ErrUnsafePath
+	}
+	clean := path.Clean(name)
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", ErrUnsafePath
+	}
+	if root != "" {
+		clean = strings.TrimPrefix(strings.TrimPrefix(clean, root), "/")
+	}
+	if clean == "." || clean == "" {
+		return "", nil
+	}
+	if x.opts.Flatten {
+		clean = path.Base(clean)
+	}
+	return filepath.FromSlash(clean), nil
+}
+
+// commonRoot returns the folder holding every entry, "" if there isn't one
+func commonRoot(entries []entry) string {
+	root := ""
+	for _, e := range entries {
+		name := path.Clean(strings.ReplaceAll(e.name, "\\", "/"))
+		first, _, nested := strings.Cut(name, "/")
+		if !nested && !e.dir {
+			return ""
+		}
+		if root == "" {
+			root = first
+		} else if first != root {
+			return ""
+		}
+	}
+	return root
+}
+
+// extract stages all entries, checks the links and moves everything into
+// place. It returns the extracted files and links.
+func (x *extractor) extract(entries []entry) ([]string, error) {
+	defer x.cleanup()
+
+	var declared int64
+	for _, e := range entries {
+		declared += e.size
+	}
+	if declared > x.opts.MaxSize {
+		return nil, x.fail("", ErrTooLarge)
+	}
+
+	root := ""
+	if x.opts.StripRoot {
+		root = commonRoot(entries)
+	}
+
+	// Links are made last, so no file is ever written through one
+	var links []entry
+	for _, e := range entries {
+		rel, err := x.relPath(e.name, root)
+		if errors.Is(err, ErrUnsafePath) && x.opts.SkipUnsafe {
+			continue
+		}
+		if err != nil {
+			return nil, x.fail(e.name, err)
+		}
+		if rel == "" || (e.dir && x.opts.Flatten) {
+			continue
+		}
+		if e.link != "" {
+			e.name = rel
+			links = append(links, e)
+			continue
+		}
+		if err := x.write(e, rel); err != nil {
+			return nil, x.fail(e.name, err)
+		}
+	}
+	for _, e := range links {
+		if err := x.makeLink(e, root); err != nil {
+			return nil, x.fail(e.name, err)
+		}
+	}
+	if err := x.checkLinks(); err != nil {
+		return nil, err
+	}
+	return x.place()
+}
+
+// write stages a file or folder
+func (x *extractor) write(e entry, rel string) error {
+	target := filepath.Join(x.staging, rel)
+	if e.dir {
+		return os.MkdirAll(target, 0755)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if e.spooled != "" {
+		return x.moveSpooled(e, rel, target)
+	}
+
+	rc, err := e.open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// Archives can have the same file twice; the last one wins
+	os.Remove(target)
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fileMode(e.mode))
+	if err != nil {
+		return err
+	}
+
+	// Declared sizes can lie, so the limit is enforced on what's written
+	remaining := x.opts.MaxSize - x.written
+	n, err := io.Copy(out, io.LimitReader(rc, remaining+1))
+	x.written += n
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if n > remaining {
+		return ErrTooLarge
+	}
+	if !containsPath(x.files, rel) {
+		x.files = append(x.files, rel)
+	}
+	return nil
+}
+
+// moveSpooled stages a file that was spooled to disk while its archive was
+// read, by renaming it. Its size counts towards the limit like a written
+// file's.
+func (x *extractor) moveSpooled(e entry, rel, target string) error {
+	remaining := x.opts.MaxSize - x.written
+	x.written += e.size
+	if e.size > remaining {
+		return ErrTooLarge
+	}
+	// Archives can have the same file twice; the last one wins
+	os.Remove(target)
+	if err := os.Rename(e.spooled, target); err != nil {
+		return err
+	}
+	if err := os.Chmod(target, fileMode(e.mode)); err != nil {
+		return err
+	}
+	if !containsPath(x.files, rel) {
+		x.files = append(x.files, rel)
+	}
+	return nil
+}
+
+// makeLink stages a symbolic or hard link whose target stays inside the
+// destination
+func (x *extractor) makeLink(e entry, root string) error {
+	target := filepath.Join(x.staging, e.name)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	os.Remove(target)
+
+	if e.isHard {
+		rel, err := x.relPath(e.link, root)
+		if err != nil || rel == "" {
+			return ErrUnsafeLink
+		}
+		if err := os.Link(filepath.Join(x.staging, rel), target); err != nil {
+			return err
+		}
+		x.files = append(x.files, e.name)
+		return nil
+	}
+
+	link := strings.ReplaceAll(e.link, "\\", "/")
+	if path.IsAbs(link) || (len(link) > 1 && link[1] == ':') {
+		return ErrUnsafeLink
+	}
+	resolved := path.Join(path.Dir(filepath.ToSlash(e.name)), link)
+	if resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return ErrUnsafeLink
+	}
+	if err := os.Symlink(filepath.FromSlash(link), target); err != nil {
+		return err
+	}
+	x.files = append(x.files, e.name)
+	x.links = append(x.links, e.name)
+	return nil
+}
+
+// checkLinks resolves every staged link. Links through other links can leave
+// the folder even when each looks harmless on its own, so the real target is
+// what's checked.
+func (x *extractor) checkLinks() error {
+	if len(x.links) == 0 {
+		return nil
+	}
+	stagingReal, err := filepath.EvalSymlinks(x.staging)
+	if err != nil {
+		return x.fail("", err)
+	}
+	for _, rel := range x.links {
+		real, err := filepath.EvalSymlinks(filepath.Join(x.staging, rel))
+		if err != nil || !within(stagingReal, real) {
+			return x.fail(filepath.ToSlash(rel), ErrUnsafeLink)
+		}
+	}
+	return nil
+}
+
+// place moves the staged files into the destination and returns their
+// paths. If a move fails the ones already made are undone, so the
+// destination is left as it was.
+func (x *extractor) place() ([]string, error) {
+	p := &placer{destDir: x.destDir}
+	defer p.removeBackups()
+	if err := p.moveInto(x.staging, x.destDir); err != nil {
+		p.undo()
+		return nil, x.fail("", err)
+	}
+	paths := make([]string, len(x.files))
+	for i, rel := range x.files {
+		paths[i] = filepath.Join(x.destDir, rel)
+	}
+	return paths, nil
+}
+
+// placer moves staged entries into the destination, remembering each move
+// and the file it replaced so they can be undone
+type placer struct {
+	destDir string
+	backups string // Folder for replaced files, made on the first one
+	moves   []move
+}
+
+// move is an entry renamed out of staging. backup is where the file it
+// replaced was put, if there was one.
+type move struct {
+	from, to, backup string
+}
+
+// moveInto renames the contents of src into dst. Folders that don't exist in
+// dst yet are renamed whole; existing ones are merged.
+func (p *placer) moveInto(src, dst string) error {
+	names, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range names {
+		m := move{from: filepath.Join(src, entry.Name()), to: filepath.Join(dst, entry.Name())}
+		info, err := os.Lstat(m.to)
+		exists := err == nil
+		if exists && entry.IsDir() && info.IsDir() {
+			if err := p.moveInto(m.from, m.to); err != nil {
+				return err
+			}
+			continue
+		}
+		if exists && info.IsDir() != entry.IsDir() {
+			return fmt.Errorf("%s is in the way", m.to)
+		}
+		if exists {
+			if m.backup, err = p.backup(m.to); err != nil {
+				return err
+			}
+		}
+		if err := os.Rename(m.from, m.to); err != nil {
+			if m.backup != "" {
+				os.Rename(m.backup, m.to)
+			}
+			return err
+		}
+		p.moves = append(p.moves, m)
+	}
+	return nil
+}
+
+// backup moves a file that's about to be replaced out of the way
+func (p *placer) backup(path string) (string, error) {
+	if p.backups == "" {
+		dir, err := os.MkdirTemp(p.destDir, ".replaced-")
+		if err != nil {
+			return "", err
+		}
+		p.backups = dir
+	}
+	backup := filepath.Join(p.backups, strconv.Itoa(len(p.moves)))
+	return backup, os.Rename(path, backup)
+}
+
+// undo moves the placed entries back into staging and puts back the files
+// they replaced, newest first
+func (p *placer) undo() {
+	for i := len(p.moves) - 1; i >= 0; i-- {
+		m := p.moves[i]
+		os.Rename(m.to, m.from)
+		if m.backup != "" {
+			os.Rename(m.backup, m.to)
+		}
+	}
+	p.moves = nil
+}
+
+// removeBackups deletes the replaced files that weren't put back
+func (p *placer) removeBackups() {
+	if p.backups != "" {
+		os.RemoveAll(p.backups)
+	}
+}
+
+// within reports whether path is dir or inside it
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// fileMode keeps an entry's permission bits, so executables stay executable,
+// but always lets the owner read and write
+func fileMode(mode os.FileMode) os.FileMode {
+	perm := mode.Perm()
+	if perm == 0 {
+		return 0644
+	}
+	return perm | 0600
+}
+
+func containsPath(paths []string, p string) bool {
+	for _, existing := range paths {
+		if existing == p {
+			return true
+		}
+	}
+	return false
+}
+
+// readAll returns the content of a small entry, such as a link's target
+func readAll(open func() (io.ReadCloser, error), limit int64) (string, error) {
+	rc, err := open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, limit))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
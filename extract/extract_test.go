@@ -0,0 +1,526 @@
+package extract
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// testEntry is a file, folder or symbolic link of a test archive
+type testEntry struct {
+	name string
+	body string
+	dir  bool
+	link string // Target, for links
+}
+
+func file(name, body string) testEntry { return testEntry{name: name, body: body} }
+func dir(name string) testEntry        { return testEntry{name: name, dir: true} }
+func link(name, target string) testEntry {
+	return testEntry{name: name, link: target}
+}
+
+// testFormats are the formats archives are extracted from, each with a
+// writer that crafts an archive holding exactly the entries given, names
+// unchecked
+var testFormats = []struct {
+	ext   string
+	write func(t *testing.T, path string, entries []testEntry)
+}{
+	{".zip", writeZip},
+	{".tar", writeTar},
+	{".7z", write7z},
+	{".rar", writeRar},
+}
+
+// extractTest writes entries as an archive of the format with ext and
+// extracts it into dest
+func extractTest(t *testing.T, ext string, entries []testEntry, dest string, opts Options) ([]string, error) {
+	t.Helper()
+	archive := filepath.Join(t.TempDir(), "game"+ext)
+	for _, format := range testFormats {
+		if format.ext == ext {
+			format.write(t, archive, entries)
+		}
+	}
+	if ext == ".tar" {
+		f, err := os.Open(archive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		return Tar(f, filepath.Base(archive), dest, opts)
+	}
+	return Archive(archive, dest, opts)
+}
+
+func writeZip(t *testing.T, path string, entries []testEntry) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.name, Method: zip.Store}
+		body := e.body
+		switch {
+		case e.dir:
+			header.Name = strings.TrimSuffix(e.name, "/") + "/"
+			header.SetMode(fs.ModeDir | 0755)
+		case e.link != "":
+			header.SetMode(fs.ModeSymlink | 0777)
+			body = e.link
+		default:
+			header.SetMode(0644)
+		}
+		out, err := w.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out.Write([]byte(body))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, path, buf.Bytes())
+}
+
+func writeTar(t *testing.T, path string, entries []testEntry) {
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(e.body))}
+		switch {
+		case e.dir:
+			header = &tar.Header{Name: e.name, Typeflag: tar.TypeDir, Mode: 0755}
+		case e.link != "":
+			header = &tar.Header{Name: e.name, Typeflag: tar.TypeSymlink, Linkname: e.link, Mode: 0777}
+		}
+		if err := w.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(e.body))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, path, buf.Bytes())
+}
+
+// write7z writes a 7z archive whose files are stored in a single folder with
+// the copy method, with Unix modes in their attributes
+func write7z(t *testing.T, path string, entries []testEntry) {
+	var packed bytes.Buffer
+	var sizes []uint64
+	var crcs []uint32
+	emptyStreams := make([]byte, (len(entries)+7)/8)
+	for i, e := range entries {
+		if e.dir {
+			emptyStreams[i/8] |= 0x80 >> (i % 8)
+			continue
+		}
+		data := []byte(e.body)
+		if e.link != "" {
+			data = []byte(e.link)
+		}
+		packed.Write(data)
+		sizes = append(sizes, uint64(len(data)))
+		crcs = append(crcs, crc32.ChecksumIEEE(data))
+	}
+
+	var h bytes.Buffer
+	h.WriteByte(0x01) // Header
+	if len(sizes) > 0 {
+		h.WriteByte(0x04) // MainStreamsInfo
+		h.WriteByte(0x06) // PackInfo: at 0, one stream
+		put7zNumber(&h, 0)
+		put7zNumber(&h, 1)
+		h.WriteByte(0x09)
+		put7zNumber(&h, uint64(packed.Len()))
+		h.WriteByte(0x00)
+		h.WriteByte(0x07) // UnpackInfo: one folder, one coder, copy
+		h.WriteByte(0x0B)
+		put7zNumber(&h, 1)
+		h.WriteByte(0x00)
+		put7zNumber(&h, 1)
+		h.Write([]byte{0x01, 0x00})
+		h.WriteByte(0x0C)
+		put7zNumber(&h, uint64(packed.Len()))
+		h.WriteByte(0x00)
+		h.WriteByte(0x08) // SubStreamsInfo: a stream per file
+		h.WriteByte(0x0D)
+		put7zNumber(&h, uint64(len(sizes)))
+		h.WriteByte(0x09)
+		for _, size := range sizes[:len(sizes)-1] {
+			put7zNumber(&h, size)
+		}
+		h.Write([]byte{0x0A, 0x01})
+		for _, crc := range crcs {
+			binary.Write(&h, binary.LittleEndian, crc)
+		}
+		h.WriteByte(0x00)
+		h.WriteByte(0x00)
+	}
+
+	h.WriteByte(0x05) // FilesInfo
+	put7zNumber(&h, uint64(len(entries)))
+	h.WriteByte(0x0E) // EmptyStream
+	put7zNumber(&h, uint64(len(emptyStreams)))
+	h.Write(emptyStreams)
+	var names bytes.Buffer
+	for _, e := range entries {
+		binary.Write(&names, binary.LittleEndian, utf16.Encode([]rune(e.name+"\x00")))
+	}
+	h.WriteByte(0x11) // Name
+	put7zNumber(&h, uint64(1+names.Len()))
+	h.WriteByte(0x00)
+	h.Write(names.Bytes())
+	h.WriteByte(0x15) // WinAttributes, with the Unix mode in the high bits
+	put7zNumber(&h, uint64(2+4*len(entries)))
+	h.Write([]byte{0x01, 0x00})
+	for _, e := range entries {
+		attr := uint32(0x8000 | 0100644<<16)
+		switch {
+		case e.dir:
+			attr = 0x8010 | 040755<<16
+		case e.link != "":
+			attr = 0x8000 | 0120777<<16
+		}
+		binary.Write(&h, binary.LittleEndian, attr)
+	}
+	h.WriteByte(0x00)
+	h.WriteByte(0x00)
+
+	var start bytes.Buffer
+	binary.Write(&start, binary.LittleEndian, uint64(packed.Len()))
+	binary.Write(&start, binary.LittleEndian, uint64(h.Len()))
+	binary.Write(&start, binary.LittleEndian, crc32.ChecksumIEEE(h.Bytes()))
+
+	var buf bytes.Buffer
+	buf.Write([]byte{'7', 'z', 0xBC, 0xAF, 0x27, 0x1C, 0, 4})
+	binary.Write(&buf, binary.LittleEndian, crc32.ChecksumIEEE(start.Bytes()))
+	buf.Write(start.Bytes())
+	buf.Write(packed.Bytes())
+	buf.Write(h.Bytes())
+	writeFile(t, path, buf.Bytes())
+}
+
+// put7zNumber writes a 7z number: the leading one bits of the first byte
+// count the bytes that follow, lowest first
+func put7zNumber(b *bytes.Buffer, v uint64) {
+	for n := 0; n < 8; n++ {
+		if v < 1<<(7*(n+1)) {
+			b.WriteByte(byte(uint16(0xFF00)>>n) | byte(v>>(8*n)))
+			for i := 0; i < n; i++ {
+				b.WriteByte(byte(v >> (8 * i)))
+			}
+			return
+		}
+	}
+	b.WriteByte(0xFF)
+	binary.Write(b, binary.LittleEndian, v)
+}
+
+// writeRar writes a RAR 1.5 archive with the files stored, made on Unix so
+// links are kept
+func writeRar(t *testing.T, path string, entries []testEntry) {
+	var buf bytes.Buffer
+	buf.WriteString("Rar!\x1A\x07\x00")
+	rarBlock(&buf, 0x73, 0, make([]byte, 6)) // Archive header
+	for _, e := range entries {
+		data := []byte(e.body)
+		flags := uint16(0x8000) // Followed by data
+		mode := uint32(0100644)
+		switch {
+		case e.dir:
+			data = nil
+			flags |= 0x00E0
+			mode = 040755
+		case e.link != "":
+			data = []byte(e.link)
+			mode = 0120777
+		}
+		var h bytes.Buffer
+		binary.Write(&h, binary.LittleEndian, uint32(len(data))) // Packed
+		binary.Write(&h, binary.LittleEndian, uint32(len(data))) // Unpacked
+		h.WriteByte(3)                                           // Unix
+		binary.Write(&h, binary.LittleEndian, crc32.ChecksumIEEE(data))
+		binary.Write(&h, binary.LittleEndian, uint32(0)) // Time
+		h.WriteByte(20)                                  // Version
+		h.WriteByte(0x30)                                // Stored
+		binary.Write(&h, binary.LittleEndian, uint16(len(e.name)))
+		binary.Write(&h, binary.LittleEndian, mode)
+		h.WriteString(e.name)
+		rarBlock(&buf, 0x74, flags, h.Bytes())
+		buf.Write(data)
+	}
+	rarBlock(&buf, 0x7B, 0, nil) // End
+	writeFile(t, path, buf.Bytes())
+}
+
+func rarBlock(buf *bytes.Buffer, kind byte, flags uint16, data []byte) {
+	var block bytes.Buffer
+	block.WriteByte(kind)
+	binary.Write(&block, binary.LittleEndian, flags)
+	binary.Write(&block, binary.LittleEndian, uint16(7+len(data)))
+	block.Write(data)
+	binary.Write(buf, binary.LittleEndian, uint16(crc32.ChecksumIEEE(block.Bytes())))
+	buf.Write(block.Bytes())
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tree lists everything under dir, hidden files included: files with their
+// content, folders with a trailing slash and links with their target
+func tree(t *testing.T, dir string) []string {
+	t.Helper()
+	var list []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		rel = filepath.ToSlash(rel)
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, _ := os.Readlink(path)
+			list = append(list, rel+" -> "+filepath.ToSlash(target))
+		case info.IsDir():
+			list = append(list, rel+"/")
+		default:
+			data, _ := os.ReadFile(path)
+			list = append(list, rel+": "+string(data))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func checkTree(t *testing.T, dir string, want ...string) {
+	t.Helper()
+	sort.Strings(want)
+	if got := tree(t, dir); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("%s holds\n\t%s\nwant\n\t%s", filepath.Base(dir), strings.Join(got, "\n\t"), strings.Join(want, "\n\t"))
+	}
+}
+
+func skipLinksOnWindows(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links need privileges on Windows")
+	}
+}
+
+func TestExtract(t *testing.T) {
+	skipLinksOnWindows(t)
+	entries := []testEntry{
+		dir("Game"),
+		file("Game/Game.cue", "FILE \"Game (Track 1).bin\" BINARY"),
+		file("Game/Game (Track 1).bin", "track 1"),
+		dir("Game/extras"),
+		file("Game/extras/manual.txt", "manual"),
+		link("Game/latest.cue", "Game.cue"),
+		link("Game/extras/cue", "../Game.cue"),
+	}
+	for _, format := range testFormats {
+		t.Run(format.ext, func(t *testing.T) {
+			dest := t.TempDir()
+			files, err := extractTest(t, format.ext, entries, dest, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkTree(t, dest,
+				"Game/",
+				"Game/Game.cue: FILE \"Game (Track 1).bin\" BINARY",
+				"Game/Game (Track 1).bin: track 1",
+				"Game/extras/",
+				"Game/extras/manual.txt: manual",
+				"Game/latest.cue -> Game.cue",
+				"Game/extras/cue -> ../Game.cue",
+			)
+			want := []string{"Game/Game.cue", "Game/Game (Track 1).bin", "Game/extras/manual.txt", "Game/latest.cue", "Game/extras/cue"}
+			for i := range want {
+				want[i] = filepath.Join(dest, filepath.FromSlash(want[i]))
+			}
+			sort.Strings(files)
+			sort.Strings(want)
+			if strings.Join(files, "\n") != strings.Join(want, "\n") {
+				t.Errorf("returned %q, want %q", files, want)
+			}
+		})
+	}
+}
+
+func TestExtractUnsafePath(t *testing.T) {
+	names := []string{
+		"../evil.txt",
+		"Game/../../evil.txt",
+		"..\\evil.txt",
+		"Game\\..\\..\\evil.txt",
+		"/tmp/evil.txt",
+		"\\tmp\\evil.txt",
+		"C:/evil.txt",
+		"C:\\evil.txt",
+		"c:evil.txt",
+		"\\\\server\\share\\evil.txt",
+		"//server/share/evil.txt",
+	}
+	for _, format := range testFormats {
+		for _, name := range names {
+			t.Run(format.ext+"/"+name, func(t *testing.T) {
+				root := t.TempDir()
+				dest := filepath.Join(root, "dest")
+				entries := []testEntry{file("Game/Game.iso", "game"), file(name, "evil")}
+				_, err := extractTest(t, format.ext, entries, dest, Options{})
+				if !errors.Is(err, ErrUnsafePath) {
+					t.Fatalf("got %v, want %v", err, ErrUnsafePath)
+				}
+				var extractErr *Error
+				if !errors.As(err, &extractErr) || extractErr.Entry == "" {
+					t.Errorf("error %v doesn't name the entry", err)
+				}
+				// Nothing written, in the destination or out of it
+				checkTree(t, root, "dest/")
+			})
+		}
+	}
+}
+
+func TestExtractSkipUnsafe(t *testing.T) {
+	for _, format := range testFormats {
+		t.Run(format.ext, func(t *testing.T) {
+			root := t.TempDir()
+			dest := filepath.Join(root, "dest")
+			entries := []testEntry{
+				file("Game/Game.iso", "game"),
+				file("../evil.txt", "evil"),
+				file("Game/../../evil.txt", "evil"),
+				file("/tmp/evil.txt", "evil"),
+				file("C:\\evil.txt", "evil"),
+				file("Game/manual.txt", "manual"),
+			}
+			files, err := extractTest(t, format.ext, entries, dest, Options{SkipUnsafe: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkTree(t, root, "dest/", "dest/Game/", "dest/Game/Game.iso: game", "dest/Game/manual.txt: manual")
+			if len(files) != 2 {
+				t.Errorf("returned %q, want the two safe files", files)
+			}
+		})
+	}
+}
+
+func TestExtractUnsafeLink(t *testing.T) {
+	skipLinksOnWindows(t)
+	tests := []struct {
+		name    string
+		entries []testEntry
+	}{
+		{"parent", []testEntry{link("up", "..")}},
+		{"nested parent", []testEntry{dir("Game"), link("Game/up", "../../outside")}},
+		{"absolute", []testEntry{link("passwd", "/etc/passwd")}},
+		{"drive letter", []testEntry{link("system", "C:\\Windows\\System32")}},
+		{"dangling", []testEntry{link("nowhere", "missing.bin")}},
+		// Each link stays inside on its own, together they lead out
+		{"chain", []testEntry{dir("a"), link("a/up", ".."), link("escape", "a/up/..")}},
+		{"chain of three", []testEntry{dir("a/b"), link("a/b/up", ".."), link("a/up", "b/up/.."), link("escape", "a/up/../outside")}},
+		{"loop", []testEntry{link("loop1", "loop2"), link("loop2", "loop1")}},
+		// A file under a link would be written out of the destination
+		{"file through link", []testEntry{link("dir", ".."), file("dir/evil.txt", "evil")}},
+	}
+	for _, format := range testFormats {
+		for _, tt := range tests {
+			t.Run(format.ext+"/"+tt.name, func(t *testing.T) {
+				root := t.TempDir()
+				dest := filepath.Join(root, "dest")
+				os.WriteFile(filepath.Join(root, "outside"), []byte("outside"), 0644)
+				entries := append([]testEntry{file("Game.iso", "game")}, tt.entries...)
+				_, err := extractTest(t, format.ext, entries, dest, Options{})
+				if !errors.Is(err, ErrUnsafeLink) {
+					t.Fatalf("got %v, want %v", err, ErrUnsafeLink)
+				}
+				checkTree(t, root, "dest/", "outside: outside")
+			})
+		}
+	}
+}
+
+func TestExtractSizeLimit(t *testing.T) {
+	entries := []testEntry{
+		file("Disc 1.bin", strings.Repeat("1", 1000)),
+		file("Disc 2.bin", strings.Repeat("2", 1000)),
+		file("Disc 3.bin", strings.Repeat("3", 1000)),
+	}
+	for _, format := range testFormats {
+		t.Run(format.ext, func(t *testing.T) {
+			dest := t.TempDir()
+			if _, err := extractTest(t, format.ext, entries, dest, Options{MaxSize: 2999}); !errors.Is(err, ErrTooLarge) {
+				t.Fatalf("over the limit: got %v, want %v", err, ErrTooLarge)
+			}
+			checkTree(t, dest)
+
+			files, err := extractTest(t, format.ext, entries, dest, Options{MaxSize: 3000})
+			if err != nil {
+				t.Fatalf("at the limit: %v", err)
+			}
+			if len(files) != 3 {
+				t.Errorf("at the limit: extracted %q", files)
+			}
+		})
+	}
+}
+
+func TestExtractUndoesPartialPlace(t *testing.T) {
+	// b.txt is a folder in the destination, so placing fails after a.txt
+	// replaced the one there
+	entries := []testEntry{
+		file("a.txt", "new a"),
+		file("b.txt", "new b"),
+		file("c.txt", "new c"),
+	}
+	for _, format := range testFormats {
+		t.Run(format.ext, func(t *testing.T) {
+			dest := t.TempDir()
+			writeFile(t, filepath.Join(dest, "a.txt"), []byte("old a"))
+			os.Mkdir(filepath.Join(dest, "b.txt"), 0755)
+
+			if _, err := extractTest(t, format.ext, entries, dest, Options{}); err == nil {
+				t.Fatal("extracted over a folder")
+			}
+			checkTree(t, dest, "a.txt: old a", "b.txt/")
+		})
+	}
+}
+
+func TestExtractReplaces(t *testing.T) {
+	entries := []testEntry{file("Game/Game.iso", "new"), file("Game/Game.txt", "notes")}
+	for _, format := range testFormats {
+		t.Run(format.ext, func(t *testing.T) {
+			dest := t.TempDir()
+			os.Mkdir(filepath.Join(dest, "Game"), 0755)
+			writeFile(t, filepath.Join(dest, "Game", "Game.iso"), []byte("old"))
+			writeFile(t, filepath.Join(dest, "Game", "save.sav"), []byte("save"))
+
+			if _, err := extractTest(t, format.ext, entries, dest, Options{}); err != nil {
+				t.Fatal(err)
+			}
+			checkTree(t, dest, "Game/", "Game/Game.iso: new", "Game/Game.txt: notes", "Game/save.sav: save")
+		})
+	}
+}
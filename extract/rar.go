@@ -0,0 +1,42 @@
+package extract
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/nwaples/rardecode"
+)
+
+// Rar extracts a RAR file (RAR 1.5 to 5) into destDir and returns the paths
+// of the files it extracted. The other volumes of a multi-volume archive are
+// found next to the first one. Encrypted archives aren't supported. Errors
+// are *Error.
+func Rar(archivePath, destDir string, opts Options) ([]string, error) {
+	r, err := rardecode.OpenReader(archivePath, "")
+	if err != nil {
+		return nil, &Error{Archive: filepath.Base(archivePath), Err: err}
+	}
+	defer r.Close()
+
+	return extractStream(archivePath, destDir, opts, func() (*entry, io.Reader, error) {
+		header, err := r.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		e := &entry{name: header.Name, dir: header.IsDir, mode: header.Mode()}
+		if e.dir {
+			return e, nil, nil
+		}
+		if e.mode&os.ModeSymlink != 0 {
+			// A link's content is its target
+			target, err := readAll(func() (io.ReadCloser, error) { return io.NopCloser(r), nil }, 4096)
+			if err != nil {
+				return nil, nil, err
+			}
+			e.link = target
+			return e, nil, nil
+		}
+		return e, r, nil
+	})
+}
@@ -0,0 +1,49 @@
+package extract
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bodgit/sevenzip"
+)
+
+// SevenZip extracts a 7z file into destDir and returns the paths of the files
+// it extracted. LZMA, LZMA2 and the other common methods are supported;
+// encrypted archives aren't. Errors are *Error.
+func SevenZip(archivePath, destDir string, opts Options) ([]string, error) {
+	x, err := newExtractor(archivePath, destDir, opts)
+	if err != nil {
+		return nil, err
+	}
+	r, err := sevenzip.OpenReader(archivePath)
+	if err != nil {
+		x.cleanup()
+		return nil, x.fail("", err)
+	}
+	defer r.Close()
+
+	// Files are in archive order, so solid blocks are read front to back
+	entries := make([]entry, 0, len(r.File))
+	for _, f := range r.File {
+		f := f
+		info := f.FileInfo()
+		e := entry{
+			name: strings.ReplaceAll(f.Name, "\\", "/"),
+			dir:  info.IsDir(),
+			mode: info.Mode(),
+			size: int64(f.UncompressedSize),
+			open: func() (io.ReadCloser, error) { return f.Open() },
+		}
+		if !e.dir && info.Mode()&os.ModeSymlink != 0 {
+			target, err := readAll(f.Open, 4096)
+			if err != nil {
+				x.cleanup()
+				return nil, x.fail(e.name, err)
+			}
+			e.link = target
+		}
+		entries = append(entries, e)
+	}
+	return x.extract(entries)
+}
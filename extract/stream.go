@@ -0,0 +1,75 @@
+package extract
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// extractStream extracts an archive that can only be read in order, like a
+// tar or a RAR. Its files are spooled to disk as they're read, so the entries
+// go through the same checks as a zip's before anything is moved into place.
+// The spool folder is in destDir, next to the staging folder, so spooled
+// files are renamed into staging rather than copied.
+//
+// next returns the next entry and, for files, its content. It returns a nil
+// entry for entries that are left out, and io.EOF at the end.
+func extractStream(name, destDir string, opts Options, next func() (*entry, io.Reader, error)) ([]string, error) {
+	x, err := newExtractor(name, destDir, opts)
+	if err != nil {
+		return nil, err
+	}
+	spool, err := os.MkdirTemp(x.destDir, ".spool-")
+	if err != nil {
+		x.cleanup()
+		return nil, x.fail("", err)
+	}
+	defer os.RemoveAll(spool)
+
+	var entries []entry
+	var total int64
+	for i := 0; ; i++ {
+		e, content, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			x.cleanup()
+			return nil, x.fail("", err)
+		}
+		if e == nil {
+			continue
+		}
+		if content != nil {
+			spoolPath := filepath.Join(spool, "entry"+strconv.Itoa(i))
+			n, err := spoolFile(content, spoolPath, x.opts.MaxSize-total)
+			if total += n; err == nil && total > x.opts.MaxSize {
+				err = ErrTooLarge
+			}
+			if err != nil {
+				x.cleanup()
+				return nil, x.fail(e.name, err)
+			}
+			e.size = n
+			e.spooled = spoolPath
+		}
+		entries = append(entries, *e)
+	}
+	return x.extract(entries)
+}
+
+// spoolFile copies at most limit+1 bytes of r to path, enough to tell that
+// the limit was passed
+func spoolFile(r io.Reader, path string, limit int64) (int64, error) {
+	out, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(out, io.LimitReader(r, limit+1))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return n, err
+}
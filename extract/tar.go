@@ -0,0 +1,35 @@
+package extract
+
+import (
+	"archive/tar"
+	"io"
+)
+
+// Tar extracts a tar stream, already decompressed, into destDir and returns
+// the paths of the files it extracted. name is the archive's file name, for
+// errors. Errors are *Error.
+func Tar(r io.Reader, name, destDir string, opts Options) ([]string, error) {
+	tr := tar.NewReader(r)
+	return extractStream(name, destDir, opts, func() (*entry, io.Reader, error) {
+		header, err := tr.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		e := &entry{name: header.Name, mode: header.FileInfo().Mode(), size: header.Size}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			e.dir = true
+		case tar.TypeSymlink:
+			e.link = header.Linkname
+		case tar.TypeLink:
+			e.link = header.Linkname
+			e.isHard = true
+		case tar.TypeReg: // Also old-style regular files, the reader converts them
+			return e, tr, nil
+		default:
+			// Devices, FIFOs and the like have no place in a download
+			return nil, nil, nil
+		}
+		return e, nil, nil
+	})
+}
@@ -0,0 +1,69 @@
+package extract
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// Zip extracts a zip file into destDir and returns the paths of the files it
+// extracted. Errors are *Error.
+func Zip(zipPath, destDir string, opts Options) ([]string, error) {
+	x, err := newExtractor(zipPath, destDir, opts)
+	if err != nil {
+		return nil, err
+	}
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		x.cleanup()
+		return nil, x.fail("", err)
+	}
+	defer r.Close()
+
+	entries, err := zipEntries(r.File)
+	if err != nil {
+		x.cleanup()
+		return nil, x.fail("", err)
+	}
+	return x.extract(entries)
+}
+
+func zipEntries(files []*zip.File) ([]entry, error) {
+	// Some zip tools store folders as empty entries without a trailing slash;
+	// an empty entry with something under it is a folder
+	parents := make(map[string]bool)
+	for _, f := range files {
+		name := strings.ReplaceAll(f.Name, "\\", "/")
+		for dir := path.Dir(strings.TrimSuffix(name, "/")); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			parents[dir] = true
+		}
+	}
+
+	entries := make([]entry, 0, len(files))
+	for _, f := range files {
+		f := f
+		name := strings.ReplaceAll(f.Name, "\\", "/")
+		e := entry{
+			name: name,
+			mode: f.Mode(),
+			size: int64(f.UncompressedSize64),
+			open: func() (io.ReadCloser, error) { return f.Open() },
+		}
+		switch {
+		case f.FileInfo().IsDir() || strings.HasSuffix(name, "/"):
+			e.dir = true
+		case f.UncompressedSize64 == 0 && parents[path.Clean(name)]:
+			e.dir = true
+		case f.Mode()&os.ModeSymlink != 0:
+			target, err := readAll(f.Open, 4096)
+			if err != nil {
+				return nil, err
+			}
+			e.link = target
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
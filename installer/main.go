@@ -1,8 +1,6 @@
 package main
 
 import (
-	"archive/tar"
-	"archive/zip"
 	"compress/gzip"
 	"fmt"
 	"io"
@@ -14,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/emubuddy/extract"
 	"github.com/ulikunitz/xz"
 )
 
@@ -924,207 +923,26 @@ func extract7z(sevenZipPath, archivePath, destDir string) error {
 
 // extractZipToDir extracts a zip file directly to destDir without stripping root folders
 // Used for simple core zip files that contain just the dll/so file
+// Entries whose names leave destDir are skipped, as they always were
 func extractZipToDir(zipPath, destDir string) error {
-	r, err := zip.OpenReader(zipPath)
-	if err != nil {
-		return err
-	}
-	defer r.Close()
-
-	os.MkdirAll(destDir, 0755)
-
-	for _, f := range r.File {
-		if f.FileInfo().IsDir() {
-			continue
-		}
-		
-		// Just use the base filename, ignore any folder structure in the zip
-		destPath := filepath.Join(destDir, filepath.Base(f.Name))
-		
-		rc, err := f.Open()
-		if err != nil {
-			return err
-		}
-		
-		outFile, err := os.Create(destPath)
-		if err != nil {
-			rc.Close()
-			return err
-		}
-		
-		_, err = io.Copy(outFile, rc)
-		outFile.Close()
-		rc.Close()
-		
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	_, err := extract.Zip(zipPath, destDir, extract.Options{Flatten: true, SkipUnsafe: true})
+	return err
 }
 
+// extractZip extracts a zip file to destDir, leaving out a root folder that
+// holds everything. Entries whose names leave destDir are skipped.
 func extractZip(zipPath, destDir string) error {
-	r, err := zip.OpenReader(zipPath)
+	files, err := extract.Zip(zipPath, destDir, extract.Options{StripRoot: true, SkipUnsafe: true})
 	if err != nil {
 		return err
 	}
-	defer r.Close()
-
-	// Clean and normalize destDir for consistent path handling on Windows
-	destDir = filepath.Clean(destDir)
 
-	// Ensure destination directory exists first
-	if err := os.MkdirAll(destDir, 0755); err != nil {
-		return fmt.Errorf("failed to create destination directory %s: %v", destDir, err)
-	}
-
-	// Find if there's a common root folder
-	var rootFolder string
-	if len(r.File) > 0 {
-		// Check first file's path
-		firstPath := r.File[0].Name
-		parts := strings.Split(filepath.ToSlash(firstPath), "/")
-		if len(parts) > 1 {
-			// Potential root folder
-			potentialRoot := parts[0] + "/"
-			hasRoot := true
-			for _, f := range r.File {
-				if !strings.HasPrefix(filepath.ToSlash(f.Name), potentialRoot) {
-					hasRoot = false
-					break
-				}
-			}
-			if hasRoot {
-				rootFolder = potentialRoot
-			}
-		}
-	}
-
-	// Helper function to check if a ZIP entry is a directory
-	isDir := func(f *zip.File) bool {
-		// Check the mode flag
-		if f.FileInfo().IsDir() {
-			return true
-		}
-		// Also check for trailing slash (some ZIPs mark dirs this way)
-		if strings.HasSuffix(f.Name, "/") || strings.HasSuffix(f.Name, "\\") {
-			return true
-		}
-		// Check if uncompressed size is 0 and name looks like a directory
-		if f.UncompressedSize64 == 0 && !strings.Contains(filepath.Base(f.Name), ".") {
-			return true
-		}
-		return false
-	}
-
-	// First pass: collect all directories that need to be created
-	dirsToCreate := make(map[string]bool)
-	for _, f := range r.File {
-		name := filepath.ToSlash(f.Name)
-		if rootFolder != "" {
-			name = strings.TrimPrefix(name, rootFolder)
-		}
-		if name == "" {
-			continue
-		}
-
-		// Remove trailing slashes before processing
-		name = strings.TrimSuffix(name, "/")
-		if name == "" {
-			continue
-		}
-
-		// Security check
-		if strings.Contains(name, "..") {
-			continue
-		}
-
-		// Convert to OS-specific path and clean it
-		name = filepath.Clean(filepath.FromSlash(name))
-		fpath := filepath.Join(destDir, name)
-
-		if isDir(f) {
-			dirsToCreate[fpath] = true
-		} else {
-			// Add parent directory
-			parentDir := filepath.Dir(fpath)
-			if parentDir != destDir {
-				dirsToCreate[parentDir] = true
-			}
-		}
-	}
-
-	// Create all directories upfront, sorted by depth (shortest paths first)
-	var sortedDirs []string
-	for dir := range dirsToCreate {
-		sortedDirs = append(sortedDirs, dir)
-	}
-	// Sort by path length to ensure parent dirs are created first
-	for i := 0; i < len(sortedDirs); i++ {
-		for j := i + 1; j < len(sortedDirs); j++ {
-			if len(sortedDirs[i]) > len(sortedDirs[j]) {
-				sortedDirs[i], sortedDirs[j] = sortedDirs[j], sortedDirs[i]
-			}
+	// Make AppImage files executable
+	for _, path := range files {
+		if strings.HasSuffix(strings.ToLower(path), ".appimage") {
+			os.Chmod(path, 0755)
 		}
 	}
-
-	for _, dir := range sortedDirs {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("mkdir %s: %v", dir, err)
-		}
-	}
-
-	// Second pass: extract files
-	for _, f := range r.File {
-		// Skip directories (already created)
-		if isDir(f) {
-			continue
-		}
-
-		name := filepath.ToSlash(f.Name)
-		if rootFolder != "" {
-			name = strings.TrimPrefix(name, rootFolder)
-		}
-
-		if name == "" {
-			continue
-		}
-
-		// Security check
-		if strings.Contains(name, "..") {
-			continue
-		}
-
-		// Convert to OS-specific path and clean it
-		name = filepath.Clean(filepath.FromSlash(name))
-		fpath := filepath.Join(destDir, name)
-
-		// Create file
-		outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
-		if err != nil {
-			return fmt.Errorf("create file %s: %v", fpath, err)
-		}
-
-		rc, err := f.Open()
-		if err != nil {
-			outFile.Close()
-			return err
-		}
-
-		_, copyErr := io.Copy(outFile, rc)
-		outFile.Close()
-		rc.Close()
-
-		if copyErr != nil {
-			return fmt.Errorf("write file %s: %v", fpath, copyErr)
-		}
-		
-		// Make AppImage files executable
-		if strings.HasSuffix(strings.ToLower(fpath), ".appimage") {
-			os.Chmod(fpath, 0755)
-		}
-	}
-
 	return nil
 }
 
@@ -1188,7 +1006,7 @@ func extractTarXz(tarXzPath, destDir string) error {
 		return err
 	}
 
-	return extractTar(xzReader, destDir)
+	return extractTar(xzReader, filepath.Base(tarXzPath), destDir)
 }
 
 func extractTarGz(tarGzPath, destDir string) error {
@@ -1204,45 +1022,14 @@ func extractTarGz(tarGzPath, destDir string) error {
 	}
 	defer gzReader.Close()
 
-	return extractTar(gzReader, destDir)
+	return extractTar(gzReader, filepath.Base(tarGzPath), destDir)
 }
 
-func extractTar(reader io.Reader, destDir string) error {
-	tarReader := tar.NewReader(reader)
-
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-
-		target := filepath.Join(destDir, header.Name)
-
-		switch header.Typeflag {
-		case tar.TypeDir:
-			if err := os.MkdirAll(target, 0755); err != nil {
-				return err
-			}
-		case tar.TypeReg:
-			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
-				return err
-			}
-			outFile, err := os.Create(target)
-			if err != nil {
-				return err
-			}
-			if _, err := io.Copy(outFile, tarReader); err != nil {
-				outFile.Close()
-				return err
-			}
-			outFile.Close()
-		}
-	}
-
-	return nil
+// extractTar extracts a tar stream to destDir. Entries whose names leave
+// destDir are skipped.
+func extractTar(reader io.Reader, name, destDir string) error {
+	_, err := extract.Tar(reader, name, destDir, extract.Options{SkipUnsafe: true})
+	return err
 }
 
 // extractDMG mounts a DMG file, copies the .app bundle to destDir, and unmounts
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Collections
+//
+// Collections are named lists of games from any system, in the order the user
+// puts them, kept in collections.json. Each one shows as a virtual system in
+// the system list. A collection can be exported to a file and imported on
+// another machine; the file holds one collection or a list of them:
+//
+//	{"name": "Couch co-op", "games": [{"system": "snes", "game": "Super Bomberman (USA).zip"}]}
+//
+// Importing a collection whose name is taken adds the games it doesn't have yet.
+
+// collectionPrefix starts the system IDs of collections in the system list
+const collectionPrefix = "collection:"
+
+// CollectionGame is a game in a collection
+type CollectionGame struct {
+	SystemID string `json:"system"`
+	Game     string `json:"game"` // Catalog name
+}
+
+// Collection is a named, ordered list of games
+type Collection struct {
+	Name  string           `json:"name"`
+	Games []CollectionGame `json:"games"`
+}
+
+var collectionsPath string
+var collections []Collection
+
+func loadCollections() {
+	collections = nil
+	data, err := os.ReadFile(collectionsPath)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &collections); err != nil {
+		logDebug("collections.json ignored: %v", err)
+	}
+}
+
+func saveCollections() error {
+	data, err := json.MarshalIndent(collections, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := collectionsPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, collectionsPath)
+}
+
+// collectionSystemID returns the system list ID of a collection
+func collectionSystemID(name string) string {
+	return collectionPrefix + name
+}
+
+// isVirtualSystem reports whether a system list entry lists games from
+// several systems
+func isVirtualSystem(sysID string) bool {
+	return sysID == recentSystemID || strings.HasPrefix(sysID, collectionPrefix)
+}
+
+// findCollection returns the index of the collection with a name, or -1
+func findCollection(name string) int {
+	for i, c := range collections {
+		if strings.EqualFold(c.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// collectionForSystem returns the collection shown as a system list entry
+func collectionForSystem(sysID string) (*Collection, bool) {
+	if !strings.HasPrefix(sysID, collectionPrefix) {
+		return nil, false
+	}
+	i := findCollection(strings.TrimPrefix(sysID, collectionPrefix))
+	if i < 0 {
+		return nil, false
+	}
+	return &collections[i], true
+}
+
+// validCollectionName checks a new name for a collection
+func validCollectionName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("collection name is empty")
+	}
+	if findCollection(name) >= 0 {
+		return fmt.Errorf("there is already a collection called '%s'", name)
+	}
+	return nil
+}
+
+func createCollection(name string) error {
+	name = strings.TrimSpace(name)
+	if err := validCollectionName(name); err != nil {
+		return err
+	}
+	collections = append(collections, Collection{Name: name})
+	return saveCollections()
+}
+
+func renameCollection(oldName, newName string) error {
+	newName = strings.TrimSpace(newName)
+	i := findCollection(oldName)
+	if i < 0 {
+		return fmt.Errorf("no collection called '%s'", oldName)
+	}
+	if !strings.EqualFold(oldName, newName) {
+		if err := validCollectionName(newName); err != nil {
+			return err
+		}
+	}
+	collections[i].Name = newName
+	return saveCollections()
+}
+
+func deleteCollection(name string) error {
+	i := findCollection(name)
+	if i < 0 {
+		return fmt.Errorf("no collection called '%s'", name)
+	}
+	collections = append(collections[:i], collections[i+1:]...)
+	return saveCollections()
+}
+
+// indexOf returns the position of a game in the collection, or -1
+func (c *Collection) indexOf(systemID, game string) int {
+	for i, g := range c.Games {
+		if g.SystemID == systemID && g.Game == game {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *Collection) contains(systemID, game string) bool {
+	return c.indexOf(systemID, game) >= 0
+}
+
+// add appends a game if it isn't in the collection yet
+func (c *Collection) add(systemID, game string) {
+	if !c.contains(systemID, game) {
+		c.Games = append(c.Games, CollectionGame{SystemID: systemID, Game: game})
+	}
+}
+
+func (c *Collection) remove(systemID, game string) {
+	if i := c.indexOf(systemID, game); i >= 0 {
+		c.Games = append(c.Games[:i], c.Games[i+1:]...)
+	}
+}
+
+// move moves a game delta places up or down the list. It returns the new
+// position, or -1 if the game can't move that way.
+func (c *Collection) move(systemID, game string, delta int) int {
+	i := c.indexOf(systemID, game)
+	j := i + delta
+	if i < 0 || j < 0 || j >= len(c.Games) {
+		return -1
+	}
+	c.Games[i], c.Games[j] = c.Games[j], c.Games[i]
+	return j
+}
+
+// collectionGames returns the games of a collection in its order, with the
+// catalog entry of each game where it can be found. Games of systems that no
+// longer exist are left out.
+func collectionGames(c *Collection) []ROM {
+	catalogs := make(catalogLookup)
+	var games []ROM
+	for _, g := range c.Games {
+		if _, ok := systems[g.SystemID]; !ok {
+			continue
+		}
+		games = append(games, catalogs.find(g.SystemID, g.Game))
+	}
+	return games
+}
+
+// exportCollection writes a collection to a file that can be imported elsewhere
+func exportCollection(name, path string) error {
+	i := findCollection(name)
+	if i < 0 {
+		return fmt.Errorf("no collection called '%s'", name)
+	}
+	data, err := json.MarshalIndent(collections[i], "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// importCollections reads a file holding one collection or a list of them and
+// merges them into the user's collections. It returns the names of the
+// collections imported and how many games were left out because their system
+// is unknown here.
+func importCollections(path string) ([]string, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	var imported []Collection
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		err = json.Unmarshal(data, &imported)
+	} else {
+		var single Collection
+		err = json.Unmarshal(data, &single)
+		imported = []Collection{single}
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var names []string
+	skipped := 0
+	for _, c := range imported {
+		name := strings.TrimSpace(c.Name)
+		if name == "" {
+			return nil, 0, fmt.Errorf("%s: collection without a name", path)
+		}
+		i := findCollection(name)
+		if i < 0 {
+			collections = append(collections, Collection{Name: name})
+			i = len(collections) - 1
+		}
+		for _, g := range c.Games {
+			if _, ok := systems[g.SystemID]; !ok || g.Game == "" {
+				skipped++
+				continue
+			}
+			collections[i].add(g.SystemID, g.Game)
+		}
+		names = append(names, collections[i].Name)
+	}
+	return names, skipped, saveCollections()
+}
+
+// exportCollectionHeadless exports a collection from the command line:
+// --export-collection <name> <file>
+func exportCollectionHeadless(args []string) {
+	if len(args) < 2 {
+		fmt.Printf("Usage: %s --export-collection <name> <file>\n", os.Args[0])
+		os.Exit(1)
+	}
+	if err := exportCollection(args[0], args[1]); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Exported %s to %s\n", args[0], args[1])
+}
+
+// importCollectionsHeadless imports collections from the command line:
+// --import-collections <file>
+func importCollectionsHeadless(args []string) {
+	if len(args) < 1 {
+		fmt.Printf("Usage: %s --import-collections <file>\n", os.Args[0])
+		os.Exit(1)
+	}
+	names, skipped, err := importCollections(args[0])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Imported %s\n", strings.Join(names, ", "))
+	if skipped > 0 {
+		fmt.Printf("%d game(s) of unknown systems left out\n", skipped)
+	}
+}
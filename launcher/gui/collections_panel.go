@@ -0,0 +1,384 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// buildCollectionsPanel creates the panel for putting the selected game in
+// collections and managing them. It's swapped into the right panel like the
+// download queue.
+func (a *App) buildCollectionsPanel() {
+	a.collectionsList = widget.NewList(
+		func() int { return len(collections) },
+		func() fyne.CanvasObject {
+			return NewTappableListItem(widget.NewLabel("[x] Collection Name (99 games)"))
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			if id >= len(collections) {
+				return
+			}
+			tappable := item.(*TappableListItem)
+			tappable.SetListInfo(a.collectionsList, id, func(itemID widget.ListItemID) {
+				a.toggleInCollection()
+			})
+			c := &collections[id]
+			mark := "[ ]"
+			if a.collectionsGame != nil && c.contains(a.collectionsGame.systemID, a.collectionsGame.Name) {
+				mark = "[x]"
+			}
+			text := fmt.Sprintf("%s %s (%d games)", mark, c.Name, len(c.Games))
+			if id == a.selectedCollectionIdx {
+				text = "> " + text
+			}
+			tappable.Content.(*widget.Label).SetText(text)
+		},
+	)
+	a.collectionsList.OnSelected = func(id widget.ListItemID) {
+		a.selectedCollectionIdx = id
+		a.collectionsList.Refresh()
+	}
+
+	a.collectionsHeader = widget.NewLabel("COLLECTIONS")
+	a.collectionsHeader.TextStyle = fyne.TextStyle{Bold: true}
+	buttons := container.NewHBox(
+		widget.NewButton("New", a.newCollection),
+		widget.NewButton("Rename", a.renameSelectedCollection),
+		widget.NewButton("Delete", a.deleteSelectedCollection),
+		widget.NewButton("Import", a.importCollectionsFile),
+		widget.NewButton("Export", a.exportSelectedCollection),
+		widget.NewButton("Back", a.hideCollections),
+	)
+	headerRow := container.NewBorder(nil, nil, a.collectionsHeader, buttons)
+
+	a.collectionsPanel = container.NewBorder(
+		headerRow, nil, nil, nil,
+		a.collectionsList,
+	)
+}
+
+// showCollections opens the collections panel for the selected game, if any
+func (a *App) showCollections() {
+	if a.choosingEmulator || a.showingDownloads || a.showingFilters {
+		return
+	}
+	if a.focusOnDetails {
+		a.unfocusDetails()
+	}
+	a.collectionsGame = nil
+	a.collectionsHeader.SetText("COLLECTIONS")
+	if a.focusOnGames && a.selectedGameIdx >= 0 && a.selectedGameIdx < len(a.filteredGames) {
+		game := a.filteredGames[a.selectedGameIdx]
+		game.systemID = a.gameSystem(game)
+		a.collectionsGame = &game
+		a.collectionsHeader.SetText("COLLECTIONS - " + catalogBaseName(game.Name))
+	}
+	if a.selectedCollectionIdx >= len(collections) {
+		a.selectedCollectionIdx = 0
+	}
+
+	a.showingCollections = true
+	a.rightPanel.Objects = []fyne.CanvasObject{a.collectionsPanel}
+	a.rightPanel.Refresh()
+	if len(collections) > 0 {
+		a.collectionsList.Select(a.selectedCollectionIdx)
+	}
+	a.collectionsList.Refresh()
+	a.updateCollectionsStatus()
+}
+
+func (a *App) hideCollections() {
+	a.showingCollections = false
+	a.rightPanel.Objects = []fyne.CanvasObject{a.gamePanel}
+	a.rightPanel.Refresh()
+	a.updateStatus()
+}
+
+func (a *App) updateCollectionsStatus() {
+	switch {
+	case len(collections) == 0:
+		a.statusBar.SetText("No collections yet: N to create one, I to import")
+	case a.collectionsGame != nil:
+		a.statusBar.SetText("Enter/A: add or remove the game, N: new, R: rename, Del: delete, I/E: import/export")
+	default:
+		a.statusBar.SetText("N: new, R: rename, Del: delete, I/E: import/export")
+	}
+}
+
+func (a *App) navigateCollections(delta int) {
+	newIdx := a.selectedCollectionIdx + delta
+	if newIdx >= 0 && newIdx < len(collections) {
+		a.selectedCollectionIdx = newIdx
+		a.collectionsList.Select(newIdx)
+		a.collectionsList.Refresh()
+	}
+}
+
+// selectedCollection returns the highlighted collection, nil if there are none
+func (a *App) selectedCollection() *Collection {
+	if a.selectedCollectionIdx < 0 || a.selectedCollectionIdx >= len(collections) {
+		return nil
+	}
+	return &collections[a.selectedCollectionIdx]
+}
+
+// toggleInCollection adds the game the panel was opened for to the
+// highlighted collection, or removes it
+func (a *App) toggleInCollection() {
+	c := a.selectedCollection()
+	game := a.collectionsGame
+	if c == nil || game == nil {
+		return
+	}
+	if c.contains(game.systemID, game.Name) {
+		c.remove(game.systemID, game.Name)
+		a.statusBar.SetText("Removed from " + c.Name)
+	} else {
+		c.add(game.systemID, game.Name)
+		a.statusBar.SetText("Added to " + c.Name)
+	}
+	a.collectionsChanged()
+}
+
+// collectionsChanged saves the collections and updates the lists showing them
+func (a *App) collectionsChanged() {
+	if err := saveCollections(); err != nil {
+		a.statusBar.SetText(fmt.Sprintf("Could not save collections: %v", err))
+	}
+	a.collectionsList.Refresh()
+	a.updateSystemIDs()
+	if _, ok := collectionForSystem(a.currentSystem); ok {
+		a.reloadCollection()
+	}
+}
+
+// reloadCollection shows the games of the collection on screen again,
+// keeping the selection where it was
+func (a *App) reloadCollection() {
+	selected := a.selectedGameIdx
+	a.selectSystem(a.currentSystem)
+	if selected >= 0 && selected < len(a.filteredGames) {
+		a.selectedGameIdx = selected
+		a.gameList.Select(selected)
+	}
+}
+
+// updateSystemIDs rebuilds the system list after collections are added,
+// renamed or removed. The system on screen stays selected; if it was a
+// deleted collection, Recently Played is shown instead.
+func (a *App) updateSystemIDs() {
+	a.systemIDs = systemListIDs()
+
+	idx := -1
+	for i, id := range a.systemIDs {
+		if id == a.currentSystem {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		a.systemList.Select(0)
+		return
+	}
+	a.relistingSystems = true
+	a.systemList.Select(idx)
+	a.relistingSystems = false
+	a.selectedSysIdx = idx
+	a.systemList.Refresh()
+}
+
+// systemListIDs returns the entries of the system list: Recently Played, the
+// collections, then the systems
+func systemListIDs() []string {
+	ids := []string{recentSystemID}
+	for _, c := range collections {
+		ids = append(ids, collectionSystemID(c.Name))
+	}
+	return append(ids, systemsList...)
+}
+
+// askCollectionName asks for the name of a collection
+func (a *App) askCollectionName(title, initial string, onName func(name string)) {
+	entry := widget.NewEntry()
+	entry.SetText(initial)
+	entry.SetPlaceHolder("e.g. Couch co-op")
+	a.dialogOpen = true
+	form := dialog.NewForm(title, "OK", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Name", entry),
+	}, func(ok bool) {
+		a.dialogOpen = false
+		if ok {
+			onName(entry.Text)
+		}
+	}, a.window)
+	form.Resize(fyne.NewSize(400, 150))
+	form.Show()
+	a.window.Canvas().Focus(entry)
+}
+
+func (a *App) newCollection() {
+	a.askCollectionName("New Collection", "", func(name string) {
+		if err := createCollection(name); err != nil {
+			a.statusBar.SetText(err.Error())
+			return
+		}
+		a.selectedCollectionIdx = len(collections) - 1
+		c := &collections[a.selectedCollectionIdx]
+		if a.collectionsGame != nil {
+			c.add(a.collectionsGame.systemID, a.collectionsGame.Name)
+		}
+		a.collectionsChanged()
+		a.collectionsList.Select(a.selectedCollectionIdx)
+		a.statusBar.SetText("Created " + c.Name)
+	})
+}
+
+func (a *App) renameSelectedCollection() {
+	c := a.selectedCollection()
+	if c == nil {
+		return
+	}
+	oldName := c.Name
+	a.askCollectionName("Rename Collection", oldName, func(name string) {
+		if err := renameCollection(oldName, name); err != nil {
+			a.statusBar.SetText(err.Error())
+			return
+		}
+		if a.currentSystem == collectionSystemID(oldName) {
+			a.currentSystem = collectionSystemID(strings.TrimSpace(name))
+		}
+		a.collectionsChanged()
+	})
+}
+
+func (a *App) deleteSelectedCollection() {
+	c := a.selectedCollection()
+	if c == nil {
+		return
+	}
+	name := c.Name
+	a.dialogOpen = true
+	dialog.ShowConfirm("Delete Collection", fmt.Sprintf("Delete the collection '%s'? The games stay downloaded.", name), func(ok bool) {
+		a.dialogOpen = false
+		if !ok {
+			return
+		}
+		if err := deleteCollection(name); err != nil {
+			a.statusBar.SetText(err.Error())
+			return
+		}
+		if a.selectedCollectionIdx >= len(collections) && a.selectedCollectionIdx > 0 {
+			a.selectedCollectionIdx--
+		}
+		a.collectionsChanged()
+		a.statusBar.SetText("Deleted " + name)
+	}, a.window)
+}
+
+func (a *App) exportSelectedCollection() {
+	c := a.selectedCollection()
+	if c == nil {
+		return
+	}
+	name := c.Name
+	a.dialogOpen = true
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		a.dialogOpen = false
+		if err != nil || writer == nil {
+			return
+		}
+		path := writer.URI().Path()
+		writer.Close()
+		if err := exportCollection(name, path); err != nil {
+			a.statusBar.SetText(fmt.Sprintf("Export failed: %v", err))
+			return
+		}
+		a.statusBar.SetText("Exported " + name + " to " + path)
+	}, a.window)
+	save.SetFileName(name + ".json")
+	save.Show()
+}
+
+func (a *App) importCollectionsFile() {
+	a.dialogOpen = true
+	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		a.dialogOpen = false
+		if err != nil || reader == nil {
+			return
+		}
+		path := reader.URI().Path()
+		reader.Close()
+		names, skipped, err := importCollections(path)
+		if err != nil {
+			a.statusBar.SetText(fmt.Sprintf("Import failed: %v", err))
+			return
+		}
+		a.collectionsChanged()
+		msg := "Imported " + strings.Join(names, ", ")
+		if skipped > 0 {
+			msg += fmt.Sprintf(" (%d games of unknown systems left out)", skipped)
+		}
+		a.statusBar.SetText(msg)
+	}, a.window)
+}
+
+// moveInCollection moves the selected game up or down the collection on screen
+func (a *App) moveInCollection(delta int) {
+	c, ok := collectionForSystem(a.currentSystem)
+	if !ok || a.selectedGameIdx < 0 || a.selectedGameIdx >= len(a.filteredGames) {
+		return
+	}
+	if a.currentFilter().Sort != SortCatalog {
+		a.statusBar.SetText("Set the sort order to Catalog to reorder a collection")
+		return
+	}
+	game := a.filteredGames[a.selectedGameIdx]
+	if c.move(game.systemID, game.Name, delta) < 0 {
+		return
+	}
+	if err := saveCollections(); err != nil {
+		a.statusBar.SetText(fmt.Sprintf("Could not save collections: %v", err))
+	}
+	a.selectedGameIdx += delta
+	a.reloadCollection()
+}
+
+// removeFromCollection takes the selected game out of the collection on screen
+func (a *App) removeFromCollection() {
+	c, ok := collectionForSystem(a.currentSystem)
+	if !ok || a.selectedGameIdx < 0 || a.selectedGameIdx >= len(a.filteredGames) {
+		return
+	}
+	game := a.filteredGames[a.selectedGameIdx]
+	c.remove(game.systemID, game.Name)
+	a.collectionsChanged()
+	a.statusBar.SetText("Removed from " + c.Name)
+}
+
+// handleCollectionsKey handles keyboard input while the collections panel is shown
+func (a *App) handleCollectionsKey(ke *fyne.KeyEvent) {
+	switch ke.Name {
+	case fyne.KeyUp:
+		a.navigateCollections(-1)
+	case fyne.KeyDown:
+		a.navigateCollections(1)
+	case fyne.KeyReturn, fyne.KeyEnter, fyne.KeySpace:
+		a.toggleInCollection()
+	case fyne.KeyN:
+		a.newCollection()
+	case fyne.KeyR:
+		a.renameSelectedCollection()
+	case fyne.KeyDelete:
+		a.deleteSelectedCollection()
+	case fyne.KeyI:
+		a.importCollectionsFile()
+	case fyne.KeyE:
+		a.exportSelectedCollection()
+	case fyne.KeyEscape, fyne.KeyBackspace, fyne.KeyC:
+		a.hideCollections()
+	}
+}
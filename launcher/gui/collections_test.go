@@ -0,0 +1,199 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// useCollections starts a test with no collections, kept in a temporary
+// collections.json, and with the systems given
+func useCollections(t *testing.T, systemIDs ...string) {
+	t.Helper()
+	savedPath, savedCollections, savedSystems := collectionsPath, collections, systems
+	t.Cleanup(func() {
+		collectionsPath, collections, systems = savedPath, savedCollections, savedSystems
+	})
+
+	collectionsPath = filepath.Join(t.TempDir(), "collections.json")
+	collections = nil
+	systems = make(map[string]SystemConfig)
+	for _, id := range systemIDs {
+		systems[id] = SystemConfig{Name: strings.ToUpper(id), Dir: id}
+	}
+}
+
+// collectionList shows collections as "name: system/game, ..." lines
+func collectionList(list []Collection) string {
+	var lines []string
+	for _, c := range list {
+		var games []string
+		for _, g := range c.Games {
+			games = append(games, g.SystemID+"/"+g.Game)
+		}
+		lines = append(lines, c.Name+": "+strings.Join(games, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestCollectionEdits(t *testing.T) {
+	useCollections(t, "snes", "genesis")
+
+	if err := createCollection("  Couch co-op "); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"", "   ", "couch CO-OP"} {
+		if err := createCollection(name); err == nil {
+			t.Errorf("created a collection called %q", name)
+		}
+	}
+	if err := createCollection("RPGs"); err != nil {
+		t.Fatal(err)
+	}
+
+	c, ok := collectionForSystem(collectionSystemID("couch co-op"))
+	if !ok {
+		t.Fatal("collection not found by its system ID")
+	}
+	c.add("snes", "Super Bomberman (USA).zip")
+	c.add("genesis", "Gunstar Heroes (USA).zip")
+	c.add("snes", "Secret of Mana (USA).zip")
+	c.add("snes", "Super Bomberman (USA).zip") // Already in it
+	if got := c.move("snes", "Secret of Mana (USA).zip", -2); got != 0 {
+		t.Errorf("moved to %d, want 0", got)
+	}
+	if got := c.move("snes", "Secret of Mana (USA).zip", -1); got != -1 {
+		t.Errorf("moved past the top to %d", got)
+	}
+	c.remove("genesis", "Gunstar Heroes (USA).zip")
+	c.remove("genesis", "Not In It (USA).zip")
+	if !c.contains("snes", "Super Bomberman (USA).zip") || c.contains("genesis", "Super Bomberman (USA).zip") {
+		t.Error("contains doesn't tell systems apart")
+	}
+
+	if err := renameCollection("couch co-op", "Couch Co-op"); err != nil {
+		t.Errorf("changing the case of a name: %v", err)
+	}
+	if err := renameCollection("Couch Co-op", "rpgs"); err == nil {
+		t.Error("renamed a collection to a name that's taken")
+	}
+	if err := renameCollection("Missing", "Other"); err == nil {
+		t.Error("renamed a collection that doesn't exist")
+	}
+	if err := deleteCollection("rpgs"); err != nil {
+		t.Fatal(err)
+	}
+	if err := deleteCollection("rpgs"); err == nil {
+		t.Error("deleted a collection twice")
+	}
+	if err := saveCollections(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Couch Co-op: snes/Secret of Mana (USA).zip, snes/Super Bomberman (USA).zip"
+	if got := collectionList(collections); got != want {
+		t.Errorf("collections are\n%s\nwant\n%s", got, want)
+	}
+	loadCollections()
+	if got := collectionList(collections); got != want {
+		t.Errorf("collections.json holds\n%s\nwant\n%s", got, want)
+	}
+	if _, ok := collectionForSystem("snes"); ok {
+		t.Error("a real system was taken for a collection")
+	}
+	if !isVirtualSystem(collectionSystemID("Couch Co-op")) || isVirtualSystem("snes") {
+		t.Error("isVirtualSystem is wrong")
+	}
+}
+
+func TestExportImportCollections(t *testing.T) {
+	useCollections(t, "snes", "genesis")
+	dir := t.TempDir()
+
+	collections = []Collection{{Name: "Couch co-op", Games: []CollectionGame{
+		{SystemID: "snes", Game: "Super Bomberman (USA).zip"},
+		{SystemID: "genesis", Game: "Gunstar Heroes (USA).zip"},
+	}}}
+	exported := filepath.Join(dir, "couch.json")
+	if err := exportCollection("couch CO-OP", exported); err != nil {
+		t.Fatal(err)
+	}
+	if err := exportCollection("Missing", filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("exported a collection that doesn't exist")
+	}
+	exportedCollection := collections[0]
+
+	t.Run("into an empty list", func(t *testing.T) {
+		collections = nil
+		names, skipped, err := importCollections(exported)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(names, []string{"Couch co-op"}) || skipped != 0 {
+			t.Errorf("imported %q, skipped %d", names, skipped)
+		}
+		if !reflect.DeepEqual(collections, []Collection{exportedCollection}) {
+			t.Errorf("got %+v, want the exported collection", collections)
+		}
+	})
+
+	t.Run("merged by name", func(t *testing.T) {
+		collections = []Collection{{Name: "COUCH CO-OP", Games: []CollectionGame{
+			{SystemID: "genesis", Game: "Gunstar Heroes (USA).zip"},
+			{SystemID: "genesis", Game: "Streets of Rage 2 (USA).zip"},
+		}}}
+		names, _, err := importCollections(exported)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(names, []string{"COUCH CO-OP"}) {
+			t.Errorf("imported %q", names)
+		}
+		want := "COUCH CO-OP: genesis/Gunstar Heroes (USA).zip, genesis/Streets of Rage 2 (USA).zip, snes/Super Bomberman (USA).zip"
+		if got := collectionList(collections); got != want {
+			t.Errorf("collections are\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("a list, with unknown systems", func(t *testing.T) {
+		collections = nil
+		path := filepath.Join(dir, "list.json")
+		writeTestFile(t, path, `[
+			{"name": "RPGs", "games": [{"system": "snes", "game": "Chrono Trigger (USA).zip"}, {"system": "psx", "game": "Final Fantasy VII (USA) (Disc 1).chd"}]},
+			{"name": " Shmups ", "games": [{"system": "genesis", "game": ""}, {"system": "genesis", "game": "Thunder Force IV (USA).zip"}]}
+		]`)
+		names, skipped, err := importCollections(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(names, []string{"RPGs", "Shmups"}) || skipped != 2 {
+			t.Errorf("imported %q, skipped %d; want 2 skipped", names, skipped)
+		}
+		want := "RPGs: snes/Chrono Trigger (USA).zip\nShmups: genesis/Thunder Force IV (USA).zip"
+		if got := collectionList(collections); got != want {
+			t.Errorf("collections are\n%s\nwant\n%s", got, want)
+		}
+		loadCollections()
+		if got := collectionList(collections); got != want {
+			t.Errorf("collections.json holds\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("bad files", func(t *testing.T) {
+		for name, data := range map[string]string{
+			"unnamed.json": `{"games": [{"system": "snes", "game": "Chrono Trigger (USA).zip"}]}`,
+			"broken.json":  `{"name": "Broken", "games": [`,
+		} {
+			path := filepath.Join(dir, name)
+			writeTestFile(t, path, data)
+			if _, _, err := importCollections(path); err == nil {
+				t.Errorf("%s was imported", name)
+			}
+		}
+		if _, _, err := importCollections(filepath.Join(dir, "missing.json")); !os.IsNotExist(err) {
+			t.Errorf("missing file: got %v", err)
+		}
+	})
+}
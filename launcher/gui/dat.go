@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Logiqx XML DAT files (the format used by No-Intro and Redump) list each
+// game with the name, size and checksums of the files that make it up:
+//
+//	<datafile>
+//	  <game name="Super Mario Bros. (World)">
+//	    <rom name="Super Mario Bros. (World).nes" size="40976" crc="3337ec46" md5="..." sha1="..."/>
+//	  </game>
+//	</datafile>
+//
+// importDAT stores those checksums in checksums.json, by system and catalog
+// entry. The shipped 1g1rsets catalogs are left alone, so a catalog update
+// doesn't lose them; they're merged into the "files" field of the entries
+// when a catalog is loaded, and used to verify downloads.
+
+var checksumsPath string
+var importedChecksums map[string]map[string][]ROMFile // By system, then catalog name
+var checksumsMu sync.Mutex
+
+func loadChecksums() {
+	importedChecksums = make(map[string]map[string][]ROMFile)
+	data, err := os.ReadFile(checksumsPath)
+	if err != nil {
+		return
+	}
+	json.Unmarshal(data, &importedChecksums)
+}
+
+// saveChecksums must be called with checksumsMu held
+func saveChecksums() error {
+	data, err := json.Marshal(importedChecksums)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(checksumsPath, data, 0644)
+}
+
+// applyChecksums fills in the files of catalog entries from the imported
+// DATs. Imported checksums win over any the catalog ships with.
+func applyChecksums(systemID string, roms []ROM) {
+	checksumsMu.Lock()
+	defer checksumsMu.Unlock()
+	imported := importedChecksums[systemID]
+	if len(imported) == 0 {
+		return
+	}
+	for i := range roms {
+		if files, ok := imported[roms[i].Name]; ok {
+			roms[i].Files = files
+		}
+	}
+}
+
+type datFile struct {
+	Games    []datGame `xml:"game"`
+	Machines []datGame `xml:"machine"` // MAME-style DATs use <machine> instead of <game>
+}
+
+type datGame struct {
+	Name string   `xml:"name,attr"`
+	ROMs []datROM `xml:"rom"`
+}
+
+type datROM struct {
+	Name string `xml:"name,attr"`
+	Size string `xml:"size,attr"`
+	CRC  string `xml:"crc,attr"`
+	MD5  string `xml:"md5,attr"`
+	SHA1 string `xml:"sha1,attr"`
+}
+
+// parseDAT reads a Logiqx XML DAT and returns the files of each game keyed by
+// lowercase game name
+func parseDAT(path string) (map[string][]ROMFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var dat datFile
+	if err := xml.Unmarshal(data, &dat); err != nil {
+		return nil, fmt.Errorf("failed to parse DAT %s: %w", filepath.Base(path), err)
+	}
+
+	games := make(map[string][]ROMFile)
+	for _, game := range append(dat.Games, dat.Machines...) {
+		var files []ROMFile
+		for _, rom := range game.ROMs {
+			size, _ := strconv.ParseInt(rom.Size, 10, 64)
+			files = append(files, ROMFile{
+				Name:  rom.Name,
+				Size:  size,
+				CRC32: strings.ToLower(rom.CRC),
+				MD5:   strings.ToLower(rom.MD5),
+				SHA1:  strings.ToLower(rom.SHA1),
+			})
+		}
+		if len(files) > 0 {
+			games[strings.ToLower(game.Name)] = files
+		}
+	}
+	return games, nil
+}
+
+// catalogBaseName strips the archive extension from a catalog entry so it can
+// be matched against a DAT game name
+func catalogBaseName(name string) string {
+	for _, ext := range []string{".zip", ".7z", ".chd", ".rar"} {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
+
+// importDAT stores the checksums from a DAT file for the catalog entries of
+// systemID it matches, replacing those of an earlier import of the system.
+// It returns how many entries were matched.
+func importDAT(systemID, datPath string) (matched, total int, err error) {
+	config, ok := systems[systemID]
+	if !ok {
+		return 0, 0, fmt.Errorf("unknown system '%s'", systemID)
+	}
+	if config.SpecialDownload == "wiiu" {
+		return 0, 0, fmt.Errorf("%s titles are not covered by DAT files", config.Name)
+	}
+
+	games, err := parseDAT(datPath)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	roms, err := loadCatalog(systemID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	imported := make(map[string][]ROMFile)
+	for _, rom := range roms {
+		if files, ok := games[strings.ToLower(catalogBaseName(rom.Name))]; ok {
+			imported[rom.Name] = files
+			matched++
+		}
+	}
+
+	checksumsMu.Lock()
+	defer checksumsMu.Unlock()
+	importedChecksums[systemID] = imported
+	if err := saveChecksums(); err != nil {
+		return 0, 0, err
+	}
+	return matched, len(roms), nil
+}
+
+// importDATHeadless runs importDAT from the command line
+func importDATHeadless(systemID, datPath string) {
+	if systemID == "" || datPath == "" {
+		fmt.Printf("Usage: %s --import-dat <system> <dat_file>\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	matched, total, err := importDAT(systemID, datPath)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Imported checksums for %d of %d %s games\n", matched, total, systems[systemID].Name)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// useTestSystem points the launcher at a temporary EmuBuddy folder holding a
+// catalog for one system, for the length of a test
+func useTestSystem(t *testing.T, systemID string, config SystemConfig, catalog []ROM) {
+	t.Helper()
+	savedBase, savedRoms, savedSystems := baseDir, romsDir, systems
+	savedChecksumsPath, savedChecksums := checksumsPath, importedChecksums
+	t.Cleanup(func() {
+		baseDir, romsDir, systems = savedBase, savedRoms, savedSystems
+		checksumsPath, importedChecksums = savedChecksumsPath, savedChecksums
+	})
+
+	baseDir = t.TempDir()
+	romsDir = filepath.Join(baseDir, "roms")
+	checksumsPath = filepath.Join(baseDir, "checksums.json")
+	importedChecksums = make(map[string]map[string][]ROMFile)
+
+	if config.RomJsonFile == "" {
+		config.RomJsonFile = systemID + ".json"
+	}
+	if config.Dir == "" {
+		config.Dir = systemID
+	}
+	systems = map[string]SystemConfig{systemID: config}
+
+	data, err := json.Marshal(catalog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(baseDir, "1g1rsets", config.RomJsonFile), string(data))
+}
+
+func writeTestFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const testDAT = `<?xml version="1.0"?>
+<!DOCTYPE datafile PUBLIC "-//Logiqx//DTD ROM Management Datafile//EN" "http://www.logiqx.com/dtds/datafile.dtd">
+<datafile>
+	<header><name>Nintendo - Nintendo Entertainment System</name></header>
+	<game name="Super Mario Bros. (World)">
+		<description>Super Mario Bros. (World)</description>
+		<rom name="Super Mario Bros. (World).nes" size="40976" crc="3337EC46" md5="811B027EAF99C2DEF7B933C5208636DE" sha1="EA343F4E445A9050D4B4FBAC2C77D0693B1D0922"/>
+	</game>
+	<game name="Metroid (USA)">
+		<rom name="Metroid (USA).nes" size="131088" crc="a0c3ff60"/>
+	</game>
+	<game name="Final Fantasy (USA) (Disc 1)">
+		<rom name="Final Fantasy (USA) (Disc 1).cue" size="98" crc="11111111"/>
+		<rom name="Final Fantasy (USA) (Disc 1).bin" size="123456" crc="22222222"/>
+	</game>
+	<game name="Empty (USA)"/>
+</datafile>`
+
+func TestParseDAT(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nes.dat")
+	writeTestFile(t, path, testDAT)
+	games, err := parseDAT(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]ROMFile{
+		"super mario bros. (world)": {{
+			Name: "Super Mario Bros. (World).nes", Size: 40976, CRC32: "3337ec46",
+			MD5: "811b027eaf99c2def7b933c5208636de", SHA1: "ea343f4e445a9050d4b4fbac2c77d0693b1d0922",
+		}},
+		"metroid (usa)": {{Name: "Metroid (USA).nes", Size: 131088, CRC32: "a0c3ff60"}},
+		"final fantasy (usa) (disc 1)": {
+			{Name: "Final Fantasy (USA) (Disc 1).cue", Size: 98, CRC32: "11111111"},
+			{Name: "Final Fantasy (USA) (Disc 1).bin", Size: 123456, CRC32: "22222222"},
+		},
+	}
+	if !reflect.DeepEqual(games, want) {
+		t.Errorf("got %+v\nwant %+v", games, want)
+	}
+}
+
+func TestParseDATMachines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mame.dat")
+	writeTestFile(t, path, `<datafile><machine name="pacman"><rom name="pacman.6e" size="4096" crc="c1e6ab10"/></machine></datafile>`)
+	games, err := parseDAT(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files := games["pacman"]; len(files) != 1 || files[0].CRC32 != "c1e6ab10" {
+		t.Errorf("got %+v", games)
+	}
+
+	writeTestFile(t, path, `<datafile><game name="broken"`)
+	if _, err := parseDAT(path); err == nil {
+		t.Error("a broken DAT was parsed")
+	}
+}
+
+func TestImportDAT(t *testing.T) {
+	useTestSystem(t, "nes", SystemConfig{Name: "NES"}, []ROM{
+		{Name: "Super Mario Bros. (World).zip"},
+		{Name: "metroid (usa).7z"},
+		{Name: "Final Fantasy (USA) (Disc 1).chd"},
+		// The catalog's own checksums are replaced by the DAT's
+		{Name: "Zelda (USA).zip", Files: []ROMFile{{Name: "Zelda (USA).nes", CRC32: "deadbeef"}}},
+		{Name: "Not In DAT (USA).zip"},
+	})
+	datPath := filepath.Join(t.TempDir(), "nes.dat")
+	writeTestFile(t, datPath, testDAT)
+
+	matched, total, err := importDAT("nes", datPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if matched != 3 || total != 5 {
+		t.Errorf("matched %d of %d, want 3 of 5", matched, total)
+	}
+
+	// Merged into the catalog when it's loaded, by catalog name
+	roms, err := loadCatalog("nes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]int)
+	for _, rom := range roms {
+		files[rom.Name] = len(rom.Files)
+	}
+	want := map[string]int{
+		"Super Mario Bros. (World).zip":    1,
+		"metroid (usa).7z":                 1,
+		"Final Fantasy (USA) (Disc 1).chd": 2,
+		"Zelda (USA).zip":                  1,
+		"Not In DAT (USA).zip":             0,
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("files per game %v, want %v", files, want)
+	}
+	if roms[0].Files[0].SHA1 != "ea343f4e445a9050d4b4fbac2c77d0693b1d0922" {
+		t.Errorf("checksums of the first game: %+v", roms[0].Files)
+	}
+
+	// Kept across restarts
+	loadChecksums()
+	if got := len(importedChecksums["nes"]); got != 3 {
+		t.Errorf("checksums.json holds %d games, want 3", got)
+	}
+
+	// A later import of the system replaces the earlier one
+	writeTestFile(t, datPath, `<datafile><game name="Zelda (USA)"><rom name="Zelda (USA).nes" size="131088" crc="3fe272fb"/></game></datafile>`)
+	if matched, _, err := importDAT("nes", datPath); err != nil || matched != 1 {
+		t.Fatalf("second import: matched %d, %v", matched, err)
+	}
+	roms, _ = loadCatalog("nes")
+	for _, rom := range roms {
+		switch {
+		case rom.Name == "Zelda (USA).zip":
+			if len(rom.Files) != 1 || rom.Files[0].CRC32 != "3fe272fb" {
+				t.Errorf("Zelda has %+v after the second import", rom.Files)
+			}
+		case rom.Name == "Super Mario Bros. (World).zip" && len(rom.Files) > 0:
+			t.Errorf("checksums of the first import kept: %+v", rom.Files)
+		}
+	}
+}
+
+func TestImportDATErrors(t *testing.T) {
+	useTestSystem(t, "wiiu", SystemConfig{Name: "Wii U", SpecialDownload: "wiiu"}, nil)
+	datPath := filepath.Join(t.TempDir(), "nes.dat")
+	writeTestFile(t, datPath, testDAT)
+
+	if _, _, err := importDAT("wiiu", datPath); err == nil {
+		t.Error("imported a DAT for Wii U titles")
+	}
+	if _, _, err := importDAT("snes", datPath); err == nil {
+		t.Error("imported a DAT for an unknown system")
+	}
+	if _, err := os.Stat(checksumsPath); err == nil {
+		t.Error("checksums.json written by a failed import")
+	}
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// detailsWidth is the width of the details pane next to the game list
+const detailsWidth = 280
+
+// detailsScrollStep is how far one press scrolls the details pane
+const detailsScrollStep = 60
+
+// detailsSimilarCount is how many similar games the details pane names
+const detailsSimilarCount = 5
+
+// Heights of the images in the details pane, and the size of the box art in
+// the game list
+const (
+	detailsBoxartHeight = 200
+	detailsSnapHeight   = 100
+	listThumbnailSize   = 24
+)
+
+// buildDetailsPanel creates the pane showing the metadata of the highlighted
+// game. It sits next to the game list and can be focused to scroll long
+// descriptions.
+func (a *App) buildDetailsPanel() fyne.CanvasObject {
+	a.detailsHeader = widget.NewLabel("DETAILS")
+	a.detailsHeader.TextStyle = fyne.TextStyle{Bold: true}
+
+	a.detailsTitle = widget.NewLabel("")
+	a.detailsTitle.TextStyle = fyne.TextStyle{Bold: true}
+	a.detailsTitle.Wrapping = fyne.TextWrapWord
+	a.detailsInfo = widget.NewLabel("")
+	a.detailsInfo.Wrapping = fyne.TextWrapWord
+	a.detailsAddons = widget.NewLabel("")
+	a.detailsAddons.Wrapping = fyne.TextWrapWord
+	a.detailsDesc = widget.NewLabel("")
+	a.detailsDesc.Wrapping = fyne.TextWrapWord
+	a.detailsSimilar = widget.NewLabel("")
+	a.detailsSimilar.Wrapping = fyne.TextWrapWord
+
+	newImage := func(height float32) *canvas.Image {
+		img := canvas.NewImageFromResource(nil)
+		img.FillMode = canvas.ImageFillContain
+		img.SetMinSize(fyne.NewSize(0, height))
+		img.Hide()
+		return img
+	}
+	a.detailsBoxart = newImage(detailsBoxartHeight)
+	a.detailsTitleScreen = newImage(detailsSnapHeight)
+	a.detailsSnap = newImage(detailsSnapHeight)
+
+	a.detailsScroll = container.NewVScroll(container.NewVBox(
+		a.detailsBoxart,
+		a.detailsTitle,
+		a.detailsInfo,
+		a.detailsAddons,
+		container.NewGridWithColumns(2, a.detailsTitleScreen, a.detailsSnap),
+		widget.NewSeparator(),
+		a.detailsSimilar,
+		widget.NewSeparator(),
+		a.detailsDesc,
+	))
+
+	panel := container.NewBorder(a.detailsHeader, nil, nil, nil, a.detailsScroll)
+	return NewFixedSizeWrapper(panel, detailsWidth, 0)
+}
+
+// updateDetails shows the highlighted game in the details pane
+func (a *App) updateDetails() {
+	if a.detailsScroll == nil {
+		return
+	}
+	if a.selectedGameIdx < 0 || a.selectedGameIdx >= len(a.filteredGames) {
+		a.detailsTitle.SetText("")
+		a.detailsInfo.SetText("")
+		a.detailsAddons.SetText("")
+		a.detailsDesc.SetText("")
+		a.detailsSimilar.SetText("")
+		a.updateDetailsImages()
+		return
+	}
+
+	game := a.filteredGames[a.selectedGameIdx]
+	meta := gameMetadata(a.gameSystem(game), game.Name)
+	a.detailsScroll.ScrollToTop()
+	a.updateDetailsImages()
+	a.detailsAddons.SetText(a.wiiuAddonsText(game))
+	if meta == nil {
+		a.detailsTitle.SetText(catalogBaseName(game.Name))
+		a.detailsInfo.SetText("No details for this game")
+		a.detailsDesc.SetText("")
+		a.detailsSimilar.SetText("")
+		return
+	}
+
+	a.detailsTitle.SetText(meta.Title)
+	a.detailsInfo.SetText(detailsInfoText(meta))
+	a.detailsDesc.SetText(strings.TrimSpace(meta.Description))
+	a.detailsSimilar.SetText(similarText(similarGames(a.gameSystem(game), game, detailsSimilarCount)))
+}
+
+// updateDetailsImages shows the thumbnails of the highlighted game that have
+// been downloaded and queues the others
+func (a *App) updateDetailsImages() {
+	images := map[ThumbnailKind]*canvas.Image{
+		ThumbBoxart: a.detailsBoxart,
+		ThumbTitle:  a.detailsTitleScreen,
+		ThumbSnap:   a.detailsSnap,
+	}
+	for kind, img := range images {
+		path := ""
+		if a.selectedGameIdx >= 0 && a.selectedGameIdx < len(a.filteredGames) {
+			game := a.filteredGames[a.selectedGameIdx]
+			path = a.thumbnails.Get(a.gameSystem(game), kind, game)
+		}
+		if path == img.File {
+			continue
+		}
+		img.File = path
+		if path == "" {
+			img.Hide()
+		} else {
+			img.Show()
+		}
+		img.Refresh()
+	}
+}
+
+// onThumbnailLoaded shows a thumbnail that has just been downloaded
+func (a *App) onThumbnailLoaded() {
+	a.gameList.Refresh()
+	a.updateDetailsImages()
+}
+
+// similarText lists similar games with their systems under a heading
+func similarText(games []ROM) string {
+	if len(games) == 0 {
+		return ""
+	}
+	lines := []string{"More like this (M):"}
+	for _, game := range games {
+		lines = append(lines, fmt.Sprintf("%s [%s]", stripROMTags(catalogBaseName(game.Name)), systems[game.systemID].Name))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// detailsInfoText lists the facts about a game that are known, one per line
+func detailsInfoText(meta *GameMetadata) string {
+	var lines []string
+	add := func(label, value string) {
+		if value != "" {
+			lines = append(lines, label+": "+value)
+		}
+	}
+	add("Released", meta.Year())
+	add("Genre", strings.Join(meta.Genres, ", "))
+	add("Developer", strings.Join(meta.Developers, ", "))
+	add("Publisher", strings.Join(meta.Publishers, ", "))
+	if meta.Rating > 0 {
+		add("Rating", fmt.Sprintf("%.1f / 5", meta.Rating))
+	}
+	if meta.Metacritic > 0 {
+		add("Metacritic", fmt.Sprintf("%d", meta.Metacritic))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// focusDetails moves the focus from the game list to the details pane
+func (a *App) focusDetails() {
+	if a.selectedGameIdx < 0 || a.selectedGameIdx >= len(a.filteredGames) {
+		return
+	}
+	a.focusOnDetails = true
+	a.detailsHeader.SetText("> DETAILS")
+	help := "Details: Up/Down to scroll, Y/M for more like this, B/Esc to go back"
+	if systems[a.gameSystem(a.filteredGames[a.selectedGameIdx])].SpecialDownload == "wiiu" {
+		help = "Details: Up/Down to scroll, LB/U for update and DLC, Y/M for more like this, B/Esc to go back"
+	}
+	a.statusBar.SetText(help)
+}
+
+// unfocusDetails moves the focus back to the game list
+func (a *App) unfocusDetails() {
+	a.focusOnDetails = false
+	a.detailsHeader.SetText("DETAILS")
+	a.updateStatus()
+}
+
+func (a *App) toggleDetailsFocus() {
+	if a.focusOnDetails {
+		a.unfocusDetails()
+	} else {
+		a.focusDetails()
+	}
+}
+
+// scrollDetails scrolls the details pane by a number of steps
+func (a *App) scrollDetails(delta int) {
+	offset := a.detailsScroll.Offset.Y + float32(delta*detailsScrollStep)
+	maxOffset := a.detailsScroll.Content.MinSize().Height - a.detailsScroll.Size().Height
+	if offset > maxOffset {
+		offset = maxOffset
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	a.detailsScroll.Offset.Y = offset
+	a.detailsScroll.Refresh()
+}
+
+// handleDetailsKey handles keyboard input while the details pane is focused
+func (a *App) handleDetailsKey(ke *fyne.KeyEvent) {
+	switch ke.Name {
+	case fyne.KeyUp:
+		a.scrollDetails(-1)
+	case fyne.KeyDown:
+		a.scrollDetails(1)
+	case fyne.KeyPageUp:
+		a.scrollDetails(-5)
+	case fyne.KeyPageDown:
+		a.scrollDetails(5)
+	case fyne.KeyEscape, fyne.KeyBackspace, fyne.KeyLeft, fyne.KeyI:
+		a.unfocusDetails()
+	case fyne.KeyM:
+		a.unfocusDetails()
+		a.showSimilar()
+	case fyne.KeyU:
+		a.downloadWiiUAddons()
+	case fyne.KeyReturn, fyne.KeyEnter:
+		a.unfocusDetails()
+		a.launchSelected()
+	}
+}
+
+// showSimilar replaces the game list with games like the selected one, from
+// all systems
+func (a *App) showSimilar() {
+	if a.selectedGameIdx < 0 || a.selectedGameIdx >= len(a.filteredGames) {
+		return
+	}
+	game := a.filteredGames[a.selectedGameIdx]
+	if readyRecommendations() == nil {
+		a.statusBar.SetText("Still loading recommendations, try again in a moment")
+		return
+	}
+	if gameMetadata(a.gameSystem(game), game.Name) == nil {
+		a.statusBar.SetText("No details for this game to find similar ones")
+		return
+	}
+	game.systemID = a.gameSystem(game)
+	a.similarTo = &game
+	a.focusOnGames = true
+	a.filterGames()
+}
+
+// hideSimilar goes back to the list the recommendations were made from
+func (a *App) hideSimilar() {
+	a.similarTo = nil
+	a.filterGames()
+}
+
+func (a *App) toggleSimilar() {
+	if a.similarTo != nil {
+		a.hideSimilar()
+	} else if a.focusOnGames {
+		a.showSimilar()
+	}
+}
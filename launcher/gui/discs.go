@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/emubuddy/gui/launch"
+)
+
+// Multi-disc games
+//
+// Catalogs list each disc of a game as its own entry, e.g. "Final Fantasy VII
+// (USA) (Disc 1).chd". Discs with the same title are shown as one row that
+// downloads and launches the whole set. Sets are launched through an .m3u
+// playlist of the disc files, written next to them, so RetroArch can swap
+// discs.
+
+// discTag matches the disc number in a catalog name: "(Disc 2)", "(Disc 2 of 3)",
+// "(Disk B)" or "(CD 1)"
+var discTag = regexp.MustCompile(`(?i)\s*\((?:Disc|Disk|CD) ([0-9]+|[A-Z])(?: of [0-9]+)?\)`)
+
+// discNumber returns the number of the disc a catalog entry is, 0 if it isn't
+// part of a set. Lettered discs count from A = 1.
+func discNumber(name string) int {
+	m := discTag.FindStringSubmatch(name)
+	if m == nil {
+		return 0
+	}
+	if n, err := strconv.Atoi(m[1]); err == nil {
+		return n
+	}
+	return int(strings.ToUpper(m[1])[0]-'A') + 1
+}
+
+// discSetTitle returns the name of a disc's set: the catalog name without the
+// disc tag and the archive extension
+func discSetTitle(name string) string {
+	return discTag.ReplaceAllString(catalogBaseName(name), "")
+}
+
+// groupDiscs replaces the discs of each multi-disc game with one entry: the
+// first disc, with all discs of the set in discs. The entry takes the place
+// of the set's first disc in the list. Sets are per system.
+func groupDiscs(games []ROM) []ROM {
+	sets := make(map[string][]int) // system + title -> indexes of the discs
+	var order []string
+	for i, game := range games {
+		if discNumber(game.Name) == 0 {
+			continue
+		}
+		key := game.systemID + "/" + discSetTitle(game.Name)
+		if sets[key] == nil {
+			order = append(order, key)
+		}
+		sets[key] = append(sets[key], i)
+	}
+
+	grouped := make(map[int]ROM) // index of the set's entry -> set
+	skip := make(map[int]bool)
+	for _, key := range order {
+		idx := sets[key]
+		if len(idx) < 2 {
+			continue
+		}
+		discs := make([]ROM, len(idx))
+		for i, gameIdx := range idx {
+			discs[i] = games[gameIdx]
+			skip[gameIdx] = true
+		}
+		sort.SliceStable(discs, func(i, j int) bool {
+			return discNumber(discs[i].Name) < discNumber(discs[j].Name)
+		})
+
+		set := discs[0]
+		set.discs = discs
+		var size int64
+		for _, disc := range discs {
+			size += parseROMSize(disc.Size)
+		}
+		if size > 0 {
+			set.Size = formatROMSize(size)
+		}
+		grouped[idx[0]] = set
+	}
+	if len(grouped) == 0 {
+		return games
+	}
+
+	result := make([]ROM, 0, len(games))
+	for i, game := range games {
+		if set, ok := grouped[i]; ok {
+			result = append(result, set)
+		} else if !skip[i] {
+			result = append(result, game)
+		}
+	}
+	return result
+}
+
+// gameDiscs returns the discs of a game: those of its set, or just itself
+func gameDiscs(game ROM) []ROM {
+	if len(game.discs) > 0 {
+		return game.discs
+	}
+	return []ROM{game}
+}
+
+// discSetName is the name shown for a set in the game list
+func discSetName(game ROM) string {
+	return fmt.Sprintf("%s [%d Discs]", discSetTitle(game.Name), len(game.discs))
+}
+
+// discsDownloaded returns how many discs of a game are downloaded
+func (a *App) discsDownloaded(game ROM) int {
+	n := 0
+	for _, disc := range gameDiscs(game) {
+		if a.romCache[disc.Name] {
+			n++
+		}
+	}
+	return n
+}
+
+// isDownloaded reports whether a game, or every disc of a set, is downloaded
+func (a *App) isDownloaded(game ROM) bool {
+	return a.discsDownloaded(game) == len(gameDiscs(game))
+}
+
+// badDisc returns a downloaded disc of a game that doesn't match its checksum
+func (a *App) badDisc(game ROM) (ROM, bool) {
+	sysID := a.gameSystem(game)
+	for _, disc := range gameDiscs(game) {
+		if a.romCache[disc.Name] && hasChecksumMismatch(sysID, disc.Name) {
+			return disc, true
+		}
+	}
+	return ROM{}, false
+}
+
+func (a *App) hasBadDisc(game ROM) bool {
+	_, bad := a.badDisc(game)
+	return bad
+}
+
+// isPartial reports whether a disc of a game was partially downloaded
+func (a *App) isPartial(game ROM) bool {
+	for _, disc := range gameDiscs(game) {
+		if a.partialCache[disc.Name] {
+			return true
+		}
+	}
+	return false
+}
+
+// queuedDisc returns the first disc of a game waiting in the download queue
+func (a *App) queuedDisc(sysID string, game ROM) (DownloadItem, bool) {
+	for _, disc := range gameDiscs(game) {
+		if dl, queued := a.downloads.Lookup(sysID, disc.Name); queued && dl.Status != DownloadDone {
+			return dl, true
+		}
+	}
+	return DownloadItem{}, false
+}
+
+// writeM3U writes the playlist of a set next to its discs and returns its
+// path. The discs are listed relative to the playlist.
+func writeM3U(romDir string, game ROM, discPaths []string) (string, error) {
+	var b strings.Builder
+	for _, path := range discPaths {
+		rel, err := filepath.Rel(romDir, path)
+		if err != nil {
+			rel = path
+		}
+		b.WriteString(filepath.ToSlash(rel) + "\n")
+	}
+	m3uPath := filepath.Join(romDir, discSetTitle(game.Name)+".m3u")
+	if err := os.WriteFile(m3uPath, []byte(b.String()), 0644); err != nil {
+		return "", err
+	}
+	return m3uPath, nil
+}
+
+// downloadDiscs queues the discs of a set that aren't downloaded, or are bad
+func (a *App) downloadDiscs(sysID string, game ROM) {
+	queued := 0
+	for _, disc := range game.discs {
+		if a.romCache[disc.Name] && !hasChecksumMismatch(sysID, disc.Name) {
+			continue
+		}
+		if a.downloads.Enqueue(sysID, disc) {
+			queued++
+		}
+	}
+	name := discSetTitle(game.Name)
+	if queued == 0 {
+		a.statusBar.SetText("Already in download queue: " + name)
+		return
+	}
+	a.statusBar.SetText(fmt.Sprintf("Queued %d of %d discs for download: %s", queued, len(game.discs), name))
+}
+
+// discSetPath returns what to launch for a set: an .m3u of its discs for
+// RetroArch cores, otherwise the first disc
+func (a *App) discSetPath(env *launch.Env, romDir string, game ROM, opt EmulatorOption) (string, error) {
+	paths := make([]string, len(game.discs))
+	for i, disc := range game.discs {
+		paths[i] = findLaunchFile(env, a.gameSystem(game), disc)
+		if !fileExists(paths[i]) {
+			return "", fmt.Errorf("ROM not found: %s", disc.Name)
+		}
+	}
+	if opt.Core == "" {
+		logDebug("%s doesn't run through RetroArch, launching the first disc", opt.Label)
+		return paths[0], nil
+	}
+	m3uPath, err := writeM3U(romDir, game, paths)
+	if err != nil {
+		return "", fmt.Errorf("failed to write playlist: %v", err)
+	}
+	logDebug("Playlist for %s: %s", discSetTitle(game.Name), m3uPath)
+	return m3uPath, nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiscNumber(t *testing.T) {
+	tests := []struct {
+		name  string
+		disc  int
+		title string
+	}{
+		{"Final Fantasy VII (USA) (Disc 1).chd", 1, "Final Fantasy VII (USA)"},
+		{"Final Fantasy VII (USA) (Disc 3).zip", 3, "Final Fantasy VII (USA)"},
+		{"Riven (USA) (Disc 2 of 5).zip", 2, "Riven (USA)"},
+		{"Policenauts (Japan) (Disk B).7z", 2, "Policenauts (Japan)"},
+		{"Policenauts (Japan) (disk a).7z", 1, "Policenauts (Japan)"},
+		{"Snatcher (Japan) (CD 1).zip", 1, "Snatcher (Japan)"},
+		{"Lunar (USA) (Disc 2) (Rev 1).chd", 2, "Lunar (USA) (Rev 1)"},
+		{"Disc Station (Japan).zip", 0, "Disc Station (Japan)"},
+		{"Crash Bandicoot (USA).chd", 0, "Crash Bandicoot (USA)"},
+	}
+	for _, tt := range tests {
+		if got := discNumber(tt.name); got != tt.disc {
+			t.Errorf("discNumber(%q) = %d, want %d", tt.name, got, tt.disc)
+		}
+		if got := discSetTitle(tt.name); got != tt.title {
+			t.Errorf("discSetTitle(%q) = %q, want %q", tt.name, got, tt.title)
+		}
+	}
+}
+
+func TestGroupDiscs(t *testing.T) {
+	rom := func(systemID, name, size string) ROM {
+		return ROM{Name: name, Size: size, systemID: systemID}
+	}
+	tests := []struct {
+		name  string
+		games []ROM
+		want  []string // Entries, each set as "first disc: all discs"
+		sizes []string // Sizes of the entries, if checked
+	}{
+		{
+			"discs out of order",
+			[]ROM{
+				rom("psx", "Alundra (USA).chd", "400.0 MiB"),
+				rom("psx", "FF7 (USA) (Disc 2).chd", "600.0 MiB"),
+				rom("psx", "FF7 (USA) (Disc 1).chd", "500.0 MiB"),
+				rom("psx", "FF7 (USA) (Disc 3).chd", "1.0 GiB"),
+				rom("psx", "Xenogears (USA).chd", "700.0 MiB"),
+			},
+			[]string{
+				"Alundra (USA).chd",
+				"FF7 (USA) (Disc 1).chd: FF7 (USA) (Disc 1).chd|FF7 (USA) (Disc 2).chd|FF7 (USA) (Disc 3).chd",
+				"Xenogears (USA).chd",
+			},
+			[]string{"400.0 MiB", "2.1 GiB", "700.0 MiB"},
+		},
+		{
+			"lettered and numbered of",
+			[]ROM{
+				rom("pce", "Policenauts (Japan) (Disk B).zip", ""),
+				rom("pce", "Policenauts (Japan) (Disk A).zip", ""),
+				rom("psx", "Riven (USA) (Disc 1 of 2).zip", ""),
+				rom("psx", "Riven (USA) (Disc 2 of 2).zip", ""),
+			},
+			[]string{
+				"Policenauts (Japan) (Disk A).zip: Policenauts (Japan) (Disk A).zip|Policenauts (Japan) (Disk B).zip",
+				"Riven (USA) (Disc 1 of 2).zip: Riven (USA) (Disc 1 of 2).zip|Riven (USA) (Disc 2 of 2).zip",
+			},
+			nil,
+		},
+		{
+			// Recently Played and collections mix systems
+			"set split across systems",
+			[]ROM{
+				rom("psx", "Game (USA) (Disc 1).chd", ""),
+				rom("saturn", "Game (USA) (Disc 2).chd", ""),
+				rom("saturn", "Other (USA) (Disc 1).chd", ""),
+				rom("psx", "Other (USA) (Disc 2).chd", ""),
+				rom("saturn", "Other (USA) (Disc 2).chd", ""),
+			},
+			[]string{
+				"Game (USA) (Disc 1).chd",
+				"Game (USA) (Disc 2).chd",
+				"Other (USA) (Disc 1).chd: Other (USA) (Disc 1).chd|Other (USA) (Disc 2).chd",
+				"Other (USA) (Disc 2).chd",
+			},
+			nil,
+		},
+		{
+			"single disc of a set",
+			[]ROM{rom("psx", "Game (USA) (Disc 2).chd", ""), rom("psx", "Game (Japan) (Disc 1).chd", "")},
+			[]string{"Game (USA) (Disc 2).chd", "Game (Japan) (Disc 1).chd"},
+			nil,
+		},
+		{
+			"unknown sizes",
+			[]ROM{rom("psx", "Game (Disc 1).chd", "-"), rom("psx", "Game (Disc 2).chd", "")},
+			[]string{"Game (Disc 1).chd: Game (Disc 1).chd|Game (Disc 2).chd"},
+			[]string{"-"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := groupDiscs(tt.games)
+			var got []string
+			for _, game := range result {
+				entry := game.Name
+				if len(game.discs) > 0 {
+					var discs []string
+					for _, disc := range game.discs {
+						discs = append(discs, disc.Name)
+					}
+					entry += ": " + strings.Join(discs, "|")
+				}
+				got = append(got, entry)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got\n\t%s\nwant\n\t%s", strings.Join(got, "\n\t"), strings.Join(tt.want, "\n\t"))
+			}
+			for i, size := range tt.sizes {
+				if i < len(result) && result[i].Size != size {
+					t.Errorf("%s: size %q, want %q", result[i].Name, result[i].Size, size)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteM3U(t *testing.T) {
+	romDir := t.TempDir()
+	game := ROM{Name: "FF7 (USA) (Disc 1).zip"}
+	discs := []string{
+		filepath.Join(romDir, "FF7 (USA) (Disc 1).cue"),
+		filepath.Join(romDir, "FF7 (USA) (Disc 2).cue"),
+		filepath.Join(romDir, "extracted", "FF7 (USA) (Disc 3).chd"),
+	}
+	path, err := writeM3U(romDir, game, discs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(romDir, "FF7 (USA).m3u"); path != want {
+		t.Errorf("written to %s, want %s", path, want)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Relative and with forward slashes, so the set can be moved
+	want := "FF7 (USA) (Disc 1).cue\nFF7 (USA) (Disc 2).cue\nextracted/FF7 (USA) (Disc 3).chd\n"
+	if string(data) != want {
+		t.Errorf("playlist is\n%s\nwant\n%s", data, want)
+	}
+}
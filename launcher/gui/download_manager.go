@@ -0,0 +1,571 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emubuddy/extract"
+	"github.com/emubuddy/gui/wiiu"
+)
+
+// DownloadStatus is the state of an item in the download queue
+type DownloadStatus string
+
+const (
+	DownloadQueued DownloadStatus = "queued"
+	DownloadActive DownloadStatus = "downloading"
+	DownloadPaused DownloadStatus = "paused"
+	DownloadFailed DownloadStatus = "failed"
+	DownloadDone   DownloadStatus = "done"
+)
+
+// Download queue configuration
+const (
+	defaultParallel        = 2 // Downloads running at once unless configured otherwise
+	maxParallel            = 4
+	progressNotifyInterval = 250 * time.Millisecond
+)
+
+// DownloadItem is one game in the download queue
+type DownloadItem struct {
+	ID         string         `json:"id"`
+	SystemID   string         `json:"system"`
+	Game       ROM            `json:"game"`
+	Status     DownloadStatus `json:"status"`
+	Downloaded int64          `json:"downloaded"`
+	Total      int64          `json:"total"`
+	Error      string         `json:"error,omitempty"`
+	AddedAt    time.Time      `json:"addedAt"`
+
+	cancel  context.CancelFunc
+	removed bool // Cancelled while running; hidden until the worker has cleaned up
+}
+
+// Progress returns the completed fraction, or -1 if the size isn't known yet
+func (d DownloadItem) Progress() float64 {
+	if d.Total <= 0 {
+		return -1
+	}
+	return float64(d.Downloaded) / float64(d.Total)
+}
+
+// downloadQueueFile is the on-disk format of downloads.json
+type downloadQueueFile struct {
+	Concurrency int             `json:"concurrency"`
+	Items       []*DownloadItem `json:"items"`
+}
+
+// DownloadManager runs a persistent queue of ROM downloads in the background.
+// Items are started in queue order, up to the configured concurrency.
+type DownloadManager struct {
+	mu          sync.Mutex
+	items       []*DownloadItem
+	concurrency int
+	path        string
+	lastNotify  time.Time
+
+	// OnChange is called whenever the queue or an item's progress changes
+	OnChange func()
+	// OnFinished is called when an item finishes downloading
+	OnFinished func(item DownloadItem)
+	// OnFailed is called when an item fails, with the error in item.Error
+	OnFailed func(item DownloadItem)
+}
+
+func downloadItemID(systemID, gameName string) string {
+	return systemID + "/" + gameName
+}
+
+// NewDownloadManager loads the queue from path. Items that were running when
+// the launcher last exited go back into the queue.
+func NewDownloadManager(path string) *DownloadManager {
+	m := &DownloadManager{
+		concurrency: defaultParallel,
+		path:        path,
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return m
+	}
+	var file downloadQueueFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		logDebug("Failed to parse %s: %v", path, err)
+		return m
+	}
+	if file.Concurrency > 0 {
+		m.concurrency = file.Concurrency
+	}
+	for _, item := range file.Items {
+		if item.Status == DownloadActive {
+			item.Status = DownloadQueued
+		}
+		m.items = append(m.items, item)
+	}
+	return m
+}
+
+// Start begins processing the queue
+func (m *DownloadManager) Start() {
+	m.mu.Lock()
+	m.schedule()
+	m.mu.Unlock()
+}
+
+// Items returns a snapshot of the queue in order
+func (m *DownloadManager) Items() []DownloadItem {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := make([]DownloadItem, 0, len(m.items))
+	for _, item := range m.items {
+		if !item.removed {
+			items = append(items, *item)
+		}
+	}
+	return items
+}
+
+// Lookup returns the queue entry for a game, if there is one
+func (m *DownloadManager) Lookup(systemID, gameName string) (DownloadItem, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if item := m.find(downloadItemID(systemID, gameName)); item != nil && !item.removed {
+		return *item, true
+	}
+	return DownloadItem{}, false
+}
+
+// Concurrency returns the number of downloads allowed to run at once
+func (m *DownloadManager) Concurrency() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.concurrency
+}
+
+// SetConcurrency changes how many downloads run at once. Lowering it doesn't
+// stop running downloads; fewer are started until the count drops.
+func (m *DownloadManager) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	if n > maxParallel {
+		n = maxParallel
+	}
+	m.mu.Lock()
+	m.concurrency = n
+	m.schedule()
+	m.save()
+	m.mu.Unlock()
+	m.notify()
+}
+
+// Enqueue adds a game to the end of the queue. It returns false if the game
+// is already queued, or a cancelled download of it hasn't finished stopping:
+// both would use the same partial files.
+func (m *DownloadManager) Enqueue(systemID string, game ROM) bool {
+	id := downloadItemID(systemID, game.Name)
+
+	m.mu.Lock()
+	if existing := m.find(id); existing != nil {
+		if existing.removed || existing.Status != DownloadDone {
+			m.mu.Unlock()
+			return false
+		}
+		m.remove(id)
+	}
+	m.items = append(m.items, &DownloadItem{
+		ID:       id,
+		SystemID: systemID,
+		Game:     game,
+		Status:   DownloadQueued,
+		AddedAt:  time.Now(),
+	})
+	m.schedule()
+	m.save()
+	m.mu.Unlock()
+	m.notify()
+	return true
+}
+
+// Pause stops a queued or running download. Running downloads keep their
+// partial data so Resume continues where they stopped.
+func (m *DownloadManager) Pause(id string) {
+	m.mu.Lock()
+	item := m.find(id)
+	if item == nil || item.Status == DownloadDone || item.Status == DownloadPaused {
+		m.mu.Unlock()
+		return
+	}
+	if item.cancel != nil {
+		// The worker marks it paused once it has stopped
+		item.cancel()
+	} else {
+		item.Status = DownloadPaused
+		m.save()
+	}
+	m.mu.Unlock()
+	m.notify()
+}
+
+// Resume puts a paused or failed download back in the queue
+func (m *DownloadManager) Resume(id string) {
+	m.mu.Lock()
+	item := m.find(id)
+	if item == nil || (item.Status != DownloadPaused && item.Status != DownloadFailed) {
+		m.mu.Unlock()
+		return
+	}
+	item.Status = DownloadQueued
+	item.Error = ""
+	m.schedule()
+	m.save()
+	m.mu.Unlock()
+	m.notify()
+}
+
+// Cancel removes a download from the queue and deletes its partial data
+func (m *DownloadManager) Cancel(id string) {
+	m.mu.Lock()
+	item := m.find(id)
+	if item == nil || item.removed {
+		m.mu.Unlock()
+		return
+	}
+	if item.cancel != nil {
+		// The worker deletes the partial data and the item once it has stopped
+		item.removed = true
+		item.cancel()
+	} else {
+		m.remove(id)
+		if item.Status != DownloadDone {
+			discardDownload(item.SystemID, item.Game)
+		}
+	}
+	m.schedule()
+	m.save()
+	m.mu.Unlock()
+	m.notify()
+}
+
+// Move shifts an item up (negative delta) or down the queue
+func (m *DownloadManager) Move(id string, delta int) {
+	m.mu.Lock()
+	from := -1
+	for i, item := range m.items {
+		if item.ID == id {
+			from = i
+			break
+		}
+	}
+	to := from + delta
+	if from < 0 || to < 0 || to >= len(m.items) {
+		m.mu.Unlock()
+		return
+	}
+	item := m.items[from]
+	m.items = append(m.items[:from], m.items[from+1:]...)
+	m.items = append(m.items[:to], append([]*DownloadItem{item}, m.items[to:]...)...)
+	m.save()
+	m.mu.Unlock()
+	m.notify()
+}
+
+// ClearFinished removes completed downloads from the queue
+func (m *DownloadManager) ClearFinished() {
+	m.mu.Lock()
+	kept := m.items[:0]
+	for _, item := range m.items {
+		if item.Status != DownloadDone {
+			kept = append(kept, item)
+		}
+	}
+	m.items = kept
+	m.save()
+	m.mu.Unlock()
+	m.notify()
+}
+
+func (m *DownloadManager) find(id string) *DownloadItem {
+	for _, item := range m.items {
+		if item.ID == id {
+			return item
+		}
+	}
+	return nil
+}
+
+func (m *DownloadManager) remove(id string) {
+	for i, item := range m.items {
+		if item.ID == id {
+			m.items = append(m.items[:i], m.items[i+1:]...)
+			return
+		}
+	}
+}
+
+// schedule starts queued items while there are free slots. Caller holds m.mu.
+func (m *DownloadManager) schedule() {
+	active := 0
+	for _, item := range m.items {
+		if item.Status == DownloadActive {
+			active++
+		}
+	}
+	for _, item := range m.items {
+		if active >= m.concurrency {
+			return
+		}
+		if item.Status != DownloadQueued {
+			continue
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		item.Status = DownloadActive
+		item.Error = ""
+		item.cancel = cancel
+		active++
+		go m.run(ctx, item)
+	}
+}
+
+func (m *DownloadManager) run(ctx context.Context, item *DownloadItem) {
+	logDebug("Download started: %s", item.ID)
+	err := downloadROM(ctx, item.SystemID, item.Game, func(downloaded, total int64) {
+		m.mu.Lock()
+		item.Downloaded = downloaded
+		item.Total = total
+		m.mu.Unlock()
+		m.notifyProgress()
+	})
+
+	m.mu.Lock()
+	item.cancel = nil
+	finished, failed := false, false
+	switch {
+	case item.removed:
+		discardDownload(item.SystemID, item.Game)
+		m.remove(item.ID)
+		logDebug("Download cancelled: %s", item.ID)
+	case ctx.Err() != nil:
+		item.Status = DownloadPaused
+		logDebug("Download paused: %s", item.ID)
+	case err != nil:
+		item.Status = DownloadFailed
+		item.Error = err.Error()
+		failed = true
+		logDebug("Download failed: %s: %v", item.ID, err)
+	default:
+		item.Status = DownloadDone
+		item.Downloaded = item.Total
+		finished = true
+		logDebug("Download finished: %s", item.ID)
+	}
+	m.schedule()
+	m.save()
+	snapshot := *item
+	m.mu.Unlock()
+
+	if finished && m.OnFinished != nil {
+		m.OnFinished(snapshot)
+	}
+	if failed && m.OnFailed != nil {
+		m.OnFailed(snapshot)
+	}
+	m.notify()
+}
+
+// save writes the queue to disk. Caller holds m.mu.
+func (m *DownloadManager) save() {
+	items := make([]*DownloadItem, 0, len(m.items))
+	for _, item := range m.items {
+		if !item.removed {
+			items = append(items, item)
+		}
+	}
+	data, err := json.MarshalIndent(downloadQueueFile{Concurrency: m.concurrency, Items: items}, "", "  ")
+	if err != nil {
+		return
+	}
+	tmpPath := m.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		logDebug("Failed to save %s: %v", m.path, err)
+		return
+	}
+	if err := os.Rename(tmpPath, m.path); err != nil {
+		logDebug("Failed to save %s: %v", m.path, err)
+	}
+}
+
+func (m *DownloadManager) notify() {
+	if m.OnChange != nil {
+		m.OnChange()
+	}
+}
+
+// notifyProgress is notify rate-limited for byte-level progress updates
+func (m *DownloadManager) notifyProgress() {
+	m.mu.Lock()
+	if time.Since(m.lastNotify) < progressNotifyInterval {
+		m.mu.Unlock()
+		return
+	}
+	m.lastNotify = time.Now()
+	m.mu.Unlock()
+	m.notify()
+}
+
+// wiiuDirName returns the directory a Wii U title is installed to
+func wiiuDirName(gameName string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == '*' || r == '?' || r == '"' || r == '<' || r == '>' || r == '|' {
+			return '_'
+		}
+		return r
+	}, gameName)
+}
+
+// downloadROM downloads (and if needed extracts) one game into its system's
+// ROM directory. It stops with ctx.Err() when ctx is cancelled.
+func downloadROM(ctx context.Context, systemID string, game ROM, progress func(downloaded, total int64)) error {
+	config, ok := systems[systemID]
+	if !ok {
+		return fmt.Errorf("unknown system '%s'", systemID)
+	}
+
+	// Handle Wii U special download
+	if config.SpecialDownload == "wiiu" && game.TitleID != "" {
+		return downloadWiiUTitle(ctx, config, game, progress)
+	}
+
+	romDir := filepath.Join(romsDir, config.Dir)
+	if err := os.MkdirAll(romDir, 0755); err != nil {
+		return err
+	}
+	outputPath := filepath.Join(romDir, game.Name)
+
+	if err := downloadWithProgress(ctx, game.URL, outputPath, progress); err != nil {
+		return err
+	}
+
+	// Extract if needed
+	if config.NeedsExtract && extract.IsArchive(game.Name) {
+		// The archive is kept when extraction fails, so it can be retried
+		extracted, err := extract.Archive(outputPath, romDir, extract.Options{})
+		if err != nil {
+			return err
+		}
+		os.Remove(outputPath)
+		resolveExtracted(systemID, game, extracted)
+	}
+
+	// Check the result against the DAT checksums, if the catalog has them
+	result := verifyGame(systemID, game)
+	if result.Status != VerifyUnknown {
+		setVerifyResult(systemID, game.Name, result)
+	}
+	if result.Status == VerifyMismatch {
+		return fmt.Errorf("checksum mismatch: %s", result.Detail)
+	}
+	return nil
+}
+
+// downloadWiiUTitle downloads and decrypts a Wii U game, update or DLC (see
+// wiiu_addons.go) from the CDN
+func downloadWiiUTitle(ctx context.Context, config SystemConfig, game ROM, progress func(downloaded, total int64)) error {
+	workDir := wiiuWorkDir(config, game)
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		return err
+	}
+
+	reporter := &queueWiiUReporter{ctx: ctx, progress: progress, fileProgress: make(map[string]int64)}
+	client := &http.Client{Timeout: 0} // No timeout for large downloads
+
+	// Download and decrypt
+	err := wiiu.DownloadTitleContext(ctx, game.TitleID, workDir, true, reporter, true, client)
+
+	// DownloadTitleContext returns nil when cancelled. The contents
+	// downloaded and the files decrypted so far are kept either way, so a
+	// retry resumes where this one stopped.
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return err
+	}
+	return installWiiUTitle(workDir, wiiuInstallDir(config, game))
+}
+
+// discardDownload deletes whatever a cancelled download left behind
+func discardDownload(systemID string, game ROM) {
+	config := systems[systemID]
+	if config.SpecialDownload == "wiiu" && game.TitleID != "" {
+		// Only the staged download; the installed title stays
+		workDir := wiiuWorkDir(config, game)
+		os.RemoveAll(workDir)
+		os.Remove(filepath.Dir(workDir)) // The staging folder, once it's empty
+		return
+	}
+	discardPartialDownload(filepath.Join(romsDir, config.Dir, game.Name))
+}
+
+// queueWiiUReporter implements wiiu.ProgressReporter for queued downloads
+type queueWiiUReporter struct {
+	ctx          context.Context
+	progress     func(downloaded, total int64)
+	mu           sync.Mutex
+	downloadSize int64
+	fileProgress map[string]int64
+}
+
+func (r *queueWiiUReporter) SetGameTitle(title string) {}
+
+func (r *queueWiiUReporter) UpdateDownloadProgress(downloaded int64, filename string) {
+	r.mu.Lock()
+	r.fileProgress[filename] = downloaded
+	var total int64
+	for _, v := range r.fileProgress {
+		total += v
+	}
+	size := r.downloadSize
+	r.mu.Unlock()
+
+	if size > 0 {
+		r.progress(total, size)
+	}
+}
+
+func (r *queueWiiUReporter) UpdateDecryptionProgress(progress float64) {}
+
+func (r *queueWiiUReporter) Cancelled() bool {
+	return r.ctx.Err() != nil
+}
+
+func (r *queueWiiUReporter) SetCancelled() {}
+
+func (r *queueWiiUReporter) SetDownloadSize(size int64) {
+	r.mu.Lock()
+	r.downloadSize = size
+	r.mu.Unlock()
+}
+
+func (r *queueWiiUReporter) ResetTotals() {
+	r.mu.Lock()
+	r.fileProgress = make(map[string]int64)
+	r.mu.Unlock()
+}
+
+func (r *queueWiiUReporter) MarkFileAsDone(filename string) {}
+
+func (r *queueWiiUReporter) SetTotalDownloadedForFile(filename string, downloaded int64) {
+	r.mu.Lock()
+	r.fileProgress[filename] = downloaded
+	r.mu.Unlock()
+}
+
+func (r *queueWiiUReporter) SetStartTime(startTime time.Time) {}
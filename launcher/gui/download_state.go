@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resumable downloads
+//
+// While a download is in progress the data is written to "<file>.part" and a
+// JSON sidecar "<file>.part.json" records the remote validators (size, ETag,
+// Last-Modified) and how far each chunk has got. Re-running the download for
+// the same file - even after the launcher has been restarted - only fetches
+// the missing ranges, provided the remote file is still the same.
+
+const (
+	partSuffix        = ".part"
+	stateSuffix       = ".part.json"
+	stateSaveInterval = 2 * time.Second
+)
+
+var errRemoteChanged = errors.New("remote file changed since the download started")
+
+// chunkRange is one byte range of a download. End is inclusive and Done is the
+// number of bytes written contiguously from Start.
+type chunkRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	Done  int64 `json:"done"`
+}
+
+func (c chunkRange) complete() bool {
+	return c.Start+c.Done > c.End
+}
+
+// downloadState is the sidecar written next to a partial download
+type downloadState struct {
+	URL          string       `json:"url"`
+	TotalSize    int64        `json:"totalSize"`
+	ETag         string       `json:"etag,omitempty"`
+	LastModified string       `json:"lastModified,omitempty"`
+	Chunks       []chunkRange `json:"chunks"`
+
+	path string
+	mu   sync.Mutex
+}
+
+func partPathFor(outputPath string) string {
+	return outputPath + partSuffix
+}
+
+func statePathFor(outputPath string) string {
+	return outputPath + stateSuffix
+}
+
+func newDownloadState(outputPath, url string, totalSize int64, etag, lastModified string) *downloadState {
+	return &downloadState{
+		URL:          url,
+		TotalSize:    totalSize,
+		ETag:         etag,
+		LastModified: lastModified,
+		path:         statePathFor(outputPath),
+	}
+}
+
+// loadDownloadState reads the sidecar for outputPath. It fails if there is no
+// sidecar or the partial file it describes is gone.
+func loadDownloadState(outputPath string) (*downloadState, error) {
+	data, err := os.ReadFile(statePathFor(outputPath))
+	if err != nil {
+		return nil, err
+	}
+	if !fileExists(partPathFor(outputPath)) {
+		return nil, os.ErrNotExist
+	}
+
+	state := &downloadState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	state.path = statePathFor(outputPath)
+	return state, nil
+}
+
+// matches reports whether the partial download can be resumed against the
+// remote file described by the HEAD response values.
+func (s *downloadState) matches(url string, totalSize int64, etag, lastModified string) bool {
+	if s.URL != url || s.TotalSize != totalSize || totalSize <= 0 {
+		return false
+	}
+	if s.ETag != "" && etag != "" && s.ETag != etag {
+		return false
+	}
+	if s.LastModified != "" && lastModified != "" && s.LastModified != lastModified {
+		return false
+	}
+	return true
+}
+
+// setProgress records that a chunk has Done bytes written
+func (s *downloadState) setProgress(chunkIdx int, done int64) {
+	s.mu.Lock()
+	s.Chunks[chunkIdx].Done = done
+	s.mu.Unlock()
+}
+
+// chunk returns a copy of a chunk's progress
+func (s *downloadState) chunk(chunkIdx int) chunkRange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Chunks[chunkIdx]
+}
+
+// downloaded returns the number of bytes already written to the partial file
+func (s *downloadState) downloaded() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var total int64
+	for _, c := range s.Chunks {
+		total += c.Done
+	}
+	return total
+}
+
+// save syncs the partial file and then writes the sidecar. The chunk progress
+// is snapshotted before the sync so the sidecar never claims more than what
+// has reached the disk.
+func (s *downloadState) save(partFile *os.File) error {
+	s.mu.Lock()
+	data, err := json.Marshal(s)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if partFile != nil {
+		if err := partFile.Sync(); err != nil {
+			return err
+		}
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path)
+}
+
+// autoSave periodically saves the state until stop is closed
+func (s *downloadState) autoSave(partFile *os.File, stop <-chan struct{}) {
+	ticker := time.NewTicker(stateSaveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := s.save(partFile); err != nil {
+				logDebug("Failed to save download state %s: %v", s.path, err)
+			}
+		}
+	}
+}
+
+// setResumeHeaders makes a range request conditional on the remote file being
+// unchanged. If it has changed the server answers 200 with the full body.
+func (s *downloadState) setResumeHeaders(req *http.Request) {
+	if s.ETag != "" && !strings.HasPrefix(s.ETag, "W/") {
+		req.Header.Set("If-Range", s.ETag)
+	} else if s.LastModified != "" {
+		req.Header.Set("If-Range", s.LastModified)
+	}
+}
+
+// hasPartialDownload reports whether outputPath has a resumable partial download
+func hasPartialDownload(outputPath string) bool {
+	return fileExists(statePathFor(outputPath)) && fileExists(partPathFor(outputPath))
+}
+
+// discardPartialDownload removes the partial file and its sidecar
+func discardPartialDownload(outputPath string) {
+	os.Remove(partPathFor(outputPath))
+	os.Remove(statePathFor(outputPath))
+}
+
+// finishDownload moves a completed partial file into place and drops the sidecar
+func finishDownload(outputPath string) error {
+	if err := os.Rename(partPathFor(outputPath), outputPath); err != nil {
+		return err
+	}
+	os.Remove(statePathFor(outputPath))
+	return nil
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// buildDownloadsPanel creates the download queue panel. It's swapped into the
+// right panel the same way the emulator choice panel is.
+func (a *App) buildDownloadsPanel() {
+	a.downloadsList = widget.NewList(
+		func() int { return len(a.downloadItems) },
+		func() fyne.CanvasObject {
+			nameText := canvas.NewText("Game Name", theme.ForegroundColor())
+			nameText.TextSize = 14
+			statusText := canvas.NewText("[Downloading 100%]", theme.ForegroundColor())
+			statusText.TextSize = 14
+			content := container.NewBorder(nil, nil, nil, statusText, nameText)
+			return NewTappableListItem(content)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			if id >= len(a.downloadItems) {
+				return
+			}
+			dl := a.downloadItems[id]
+			tappable := item.(*TappableListItem)
+			tappable.SetListInfo(a.downloadsList, id, func(itemID widget.ListItemID) {
+				a.toggleSelectedDownload()
+			})
+
+			box := tappable.Content.(*fyne.Container)
+			nameText := box.Objects[0].(*canvas.Text)
+			statusText := box.Objects[1].(*canvas.Text)
+
+			name := strings.TrimSuffix(dl.Game.Name, ".zip")
+			if sys, ok := systems[dl.SystemID]; ok {
+				name = fmt.Sprintf("%s [%s]", name, sys.Name)
+			}
+			if id == a.selectedDownloadIdx {
+				name = "> " + name
+			}
+			if len(name) > 60 {
+				name = name[:57] + "..."
+			}
+			nameText.Text = name
+			nameText.Refresh()
+
+			statusText.Text = downloadStatusText(dl)
+			statusText.Refresh()
+		},
+	)
+	a.downloadsList.OnSelected = func(id widget.ListItemID) {
+		a.selectedDownloadIdx = id
+		a.updateDownloadStatus()
+		a.downloadsList.Refresh()
+	}
+
+	// Concurrency picker
+	options := []string{}
+	for i := 1; i <= maxParallel; i++ {
+		options = append(options, strconv.Itoa(i))
+	}
+	concurrency := widget.NewSelect(options, func(s string) {
+		n, _ := strconv.Atoi(s)
+		a.downloads.SetConcurrency(n)
+	})
+	concurrency.SetSelected(strconv.Itoa(a.downloads.Concurrency()))
+
+	header := widget.NewLabel("DOWNLOADS")
+	header.TextStyle = fyne.TextStyle{Bold: true}
+
+	buttons := container.NewHBox(
+		widget.NewLabel("Parallel:"), concurrency,
+		widget.NewButton("Pause/Resume", a.toggleSelectedDownload),
+		widget.NewButton("Up", func() { a.moveSelectedDownload(-1) }),
+		widget.NewButton("Down", func() { a.moveSelectedDownload(1) }),
+		widget.NewButton("Cancel", a.cancelSelectedDownload),
+		widget.NewButton("Clear Done", a.downloads.ClearFinished),
+		widget.NewButton("Back", a.hideDownloads),
+	)
+	headerRow := container.NewBorder(nil, nil, header, buttons)
+
+	a.downloadsPanel = container.NewBorder(
+		headerRow, nil, nil, nil,
+		a.downloadsList,
+	)
+}
+
+func downloadStatusText(dl DownloadItem) string {
+	switch dl.Status {
+	case DownloadActive:
+		if p := dl.Progress(); p >= 0 {
+			return fmt.Sprintf("[%.0f%% of %.1f MB]", p*100, float64(dl.Total)/1024/1024)
+		}
+		return "[Downloading]"
+	case DownloadQueued:
+		return "[Queued]"
+	case DownloadPaused:
+		if p := dl.Progress(); p >= 0 {
+			return fmt.Sprintf("[Paused %.0f%%]", p*100)
+		}
+		return "[Paused]"
+	case DownloadFailed:
+		return "[Failed]"
+	case DownloadDone:
+		return "[Done]"
+	}
+	return ""
+}
+
+// onDownloadsChanged refreshes everything that shows queue state. It's called
+// from the download manager, usually from a worker goroutine.
+func (a *App) onDownloadsChanged() {
+	a.downloadItems = a.downloads.Items()
+	if a.selectedDownloadIdx >= len(a.downloadItems) {
+		a.selectedDownloadIdx = len(a.downloadItems) - 1
+	}
+	if a.showingDownloads {
+		a.downloadsList.Refresh()
+		a.updateDownloadStatus()
+	}
+	a.gameList.Refresh()
+}
+
+// onDownloadFinished marks the game as ready if its system is on screen
+func (a *App) onDownloadFinished(item DownloadItem) {
+	if item.SystemID == a.currentSystem || isVirtualSystem(a.currentSystem) || a.searching {
+		a.romCache[item.Game.Name] = true
+		a.partialCache[item.Game.Name] = false
+		a.gameList.Refresh()
+		a.updateLaunchButton()
+	}
+	if isWiiUAddon(item.Game.TitleID) && a.detailsAddons != nil {
+		a.onWiiUAddonsLoaded()
+	}
+	if !a.showingDownloads {
+		a.statusBar.SetText("Downloaded: " + item.Game.Name)
+	}
+}
+
+// onDownloadFailed tells the user why a download failed, e.g. an archive
+// that tries to write outside the ROM folder
+func (a *App) onDownloadFailed(item DownloadItem) {
+	if !a.showingDownloads {
+		a.statusBar.SetText(fmt.Sprintf("Download failed: %s (%s)", item.Game.Name, item.Error))
+	}
+}
+
+func (a *App) showDownloads() {
+	if a.choosingEmulator || a.showingCollections {
+		return
+	}
+	a.downloadItems = a.downloads.Items()
+	if a.selectedDownloadIdx < 0 || a.selectedDownloadIdx >= len(a.downloadItems) {
+		a.selectedDownloadIdx = 0
+	}
+	a.showingDownloads = true
+	a.rightPanel.Objects = []fyne.CanvasObject{a.downloadsPanel}
+	a.rightPanel.Refresh()
+	if len(a.downloadItems) > 0 {
+		a.downloadsList.Select(a.selectedDownloadIdx)
+	}
+	a.downloadsList.Refresh()
+	a.updateDownloadStatus()
+}
+
+func (a *App) hideDownloads() {
+	a.showingDownloads = false
+	a.rightPanel.Objects = []fyne.CanvasObject{a.gamePanel}
+	a.rightPanel.Refresh()
+	a.updateStatus()
+}
+
+func (a *App) toggleDownloads() {
+	if a.showingDownloads {
+		a.hideDownloads()
+	} else {
+		a.showDownloads()
+	}
+}
+
+func (a *App) navigateDownloads(delta int) {
+	newIdx := a.selectedDownloadIdx + delta
+	if newIdx >= 0 && newIdx < len(a.downloadItems) {
+		a.selectedDownloadIdx = newIdx
+		a.downloadsList.Select(newIdx)
+		a.downloadsList.Refresh()
+	}
+}
+
+func (a *App) selectedDownload() (DownloadItem, bool) {
+	if a.selectedDownloadIdx < 0 || a.selectedDownloadIdx >= len(a.downloadItems) {
+		return DownloadItem{}, false
+	}
+	return a.downloadItems[a.selectedDownloadIdx], true
+}
+
+// toggleSelectedDownload pauses a running or queued item and resumes a paused
+// or failed one
+func (a *App) toggleSelectedDownload() {
+	dl, ok := a.selectedDownload()
+	if !ok {
+		return
+	}
+	switch dl.Status {
+	case DownloadPaused, DownloadFailed:
+		a.downloads.Resume(dl.ID)
+	case DownloadQueued, DownloadActive:
+		a.downloads.Pause(dl.ID)
+	}
+}
+
+func (a *App) cancelSelectedDownload() {
+	if dl, ok := a.selectedDownload(); ok {
+		a.downloads.Cancel(dl.ID)
+	}
+}
+
+func (a *App) moveSelectedDownload(delta int) {
+	dl, ok := a.selectedDownload()
+	if !ok {
+		return
+	}
+	a.downloads.Move(dl.ID, delta)
+	a.navigateDownloads(delta)
+}
+
+func (a *App) updateDownloadStatus() {
+	dl, ok := a.selectedDownload()
+	if !ok {
+		a.statusBar.SetText("Download queue is empty")
+		return
+	}
+	if dl.Status == DownloadFailed {
+		a.statusBar.SetText(fmt.Sprintf("Failed: %s (%s)", dl.Game.Name, dl.Error))
+		return
+	}
+	status := string(dl.Status)
+	a.statusBar.SetText(fmt.Sprintf("%s%s: %s", strings.ToUpper(status[:1]), status[1:], dl.Game.Name))
+}
+
+// handleDownloadsKey handles keyboard input while the download queue is shown
+func (a *App) handleDownloadsKey(ke *fyne.KeyEvent) {
+	switch ke.Name {
+	case fyne.KeyUp:
+		a.navigateDownloads(-1)
+	case fyne.KeyDown:
+		a.navigateDownloads(1)
+	case fyne.KeyReturn, fyne.KeyEnter, fyne.KeySpace:
+		a.toggleSelectedDownload()
+	case fyne.KeyDelete, fyne.KeyX:
+		a.cancelSelectedDownload()
+	case fyne.KeyLeftBracket:
+		a.moveSelectedDownload(-1)
+	case fyne.KeyRightBracket:
+		a.moveSelectedDownload(1)
+	case fyne.KeyC:
+		a.downloads.ClearFinished()
+	case fyne.KeyEscape, fyne.KeyBackspace, fyne.KeyQ:
+		a.hideDownloads()
+	}
+}
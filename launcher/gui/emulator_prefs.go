@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// EmulatorOption is one emulator (or RetroArch core) a game can be launched with
+type EmulatorOption struct {
+	Label    string
+	Path     string
+	Args     []string // Argument template, see launch.LaunchSpec
+	Core     string   // RetroArch core for {core}, if any
+	Key      string   // Stable identifier, stored in emulator_prefs.json
+	Emulator EmulatorConfig
+}
+
+// emulatorOptions lists the ways a system's games can be launched: each core
+// of the main emulator (or the emulator itself), then the same for the
+// standalone emulator
+func emulatorOptions(config SystemConfig) []EmulatorOption {
+	var options []EmulatorOption
+	add := func(emu EmulatorConfig, fallbackName string) {
+		if len(emu.Cores) > 0 {
+			// Cores are loaded with "-L {core}" unless the args say where
+			args := emu.Args
+			if !containsPlaceholder(args, "{core}") {
+				args = append([]string{"-L", "{core}"}, args...)
+			}
+			for _, core := range emu.Cores {
+				options = append(options, EmulatorOption{
+					Label:    fmt.Sprintf("RetroArch (%s)", core.Name),
+					Path:     emu.Path,
+					Args:     args,
+					Core:     core.GetCorePath(),
+					Key:      emu.Path + "#" + core.Name,
+					Emulator: emu,
+				})
+			}
+		} else if emu.Path != "" {
+			name := emu.Name
+			if name == "" {
+				name = fallbackName
+			}
+			options = append(options, EmulatorOption{
+				Label:    name,
+				Path:     emu.Path,
+				Args:     emu.Args,
+				Key:      emu.Path,
+				Emulator: emu,
+			})
+		}
+	}
+
+	add(config.Emulator, "Default Emulator")
+	if config.StandaloneEmulator != nil {
+		add(*config.StandaloneEmulator, "Standalone")
+	}
+	return options
+}
+
+// RememberMode is what the emulator choice panel remembers on launch
+type RememberMode int
+
+const (
+	RememberOff RememberMode = iota
+	RememberGame
+	RememberSystem
+)
+
+// emulatorPrefs is the on-disk format of emulator_prefs.json
+type emulatorPrefs struct {
+	Systems map[string]string            `json:"systems"` // system -> option key
+	Games   map[string]map[string]string `json:"games"`   // system -> game -> option key
+}
+
+var emulatorPrefsPath string
+var emuPrefs emulatorPrefs
+
+func loadEmulatorPrefs() {
+	emuPrefs = emulatorPrefs{
+		Systems: make(map[string]string),
+		Games:   make(map[string]map[string]string),
+	}
+	data, err := os.ReadFile(emulatorPrefsPath)
+	if err != nil {
+		return
+	}
+	json.Unmarshal(data, &emuPrefs)
+	if emuPrefs.Systems == nil {
+		emuPrefs.Systems = make(map[string]string)
+	}
+	if emuPrefs.Games == nil {
+		emuPrefs.Games = make(map[string]map[string]string)
+	}
+}
+
+func saveEmulatorPrefs() {
+	data, err := json.MarshalIndent(emuPrefs, "", "  ")
+	if err != nil {
+		return
+	}
+	tmpPath := emulatorPrefsPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		logDebug("Failed to save %s: %v", emulatorPrefsPath, err)
+		return
+	}
+	if err := os.Rename(tmpPath, emulatorPrefsPath); err != nil {
+		logDebug("Failed to save %s: %v", emulatorPrefsPath, err)
+	}
+}
+
+// preferredEmulator returns the remembered option for a game: its own
+// override first, then the system default. Options that no longer exist in
+// systems.json are ignored.
+func preferredEmulator(systemID, gameName string, options []EmulatorOption) (EmulatorOption, bool) {
+	for _, key := range []string{emuPrefs.Games[systemID][gameName], emuPrefs.Systems[systemID]} {
+		if key == "" {
+			continue
+		}
+		for _, opt := range options {
+			if opt.Key == key {
+				return opt, true
+			}
+		}
+	}
+	return EmulatorOption{}, false
+}
+
+// rememberEmulator stores an option as the game override or system default
+func rememberEmulator(systemID, gameName string, opt EmulatorOption, mode RememberMode) {
+	switch mode {
+	case RememberGame:
+		if emuPrefs.Games[systemID] == nil {
+			emuPrefs.Games[systemID] = make(map[string]string)
+		}
+		emuPrefs.Games[systemID][gameName] = opt.Key
+	case RememberSystem:
+		emuPrefs.Systems[systemID] = opt.Key
+		// The new default should apply to this game too
+		delete(emuPrefs.Games[systemID], gameName)
+	default:
+		return
+	}
+	saveEmulatorPrefs()
+}
+
+// forgetEmulator removes what decides the emulator of a game: its override
+// and the system default
+func forgetEmulator(systemID, gameName string) {
+	delete(emuPrefs.Games[systemID], gameName)
+	delete(emuPrefs.Systems, systemID)
+	saveEmulatorPrefs()
+}
+
+// forgetSystemEmulators removes the system default and every game override
+func forgetSystemEmulators(systemID string) {
+	delete(emuPrefs.Systems, systemID)
+	delete(emuPrefs.Games, systemID)
+	saveEmulatorPrefs()
+}
+
+// rememberLabel describes a remember mode for the emulator choice panel
+func rememberLabel(mode RememberMode, systemName string) string {
+	switch mode {
+	case RememberGame:
+		return "Remember: This Game"
+	case RememberSystem:
+		return "Remember: All " + systemName + " Games"
+	}
+	return "Remember: Off"
+}
+
+// resetEmulatorHeadless clears remembered emulators from the command line:
+// --reset-emulator <system> [game]
+func resetEmulatorHeadless(args []string) {
+	if len(args) < 1 {
+		fmt.Printf("Usage: %s --reset-emulator <system> [game]\n", os.Args[0])
+		os.Exit(1)
+	}
+	if _, ok := systems[args[0]]; !ok {
+		fmt.Printf("Error: Unknown system '%s'\n", args[0])
+		os.Exit(1)
+	}
+	if len(args) >= 2 {
+		delete(emuPrefs.Games[args[0]], args[1])
+		saveEmulatorPrefs()
+		fmt.Printf("Cleared remembered emulator for %s\n", args[1])
+		return
+	}
+	forgetSystemEmulators(args[0])
+	fmt.Printf("Cleared remembered emulators for %s\n", systems[args[0]].Name)
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Game list filters and sort orders
+//
+// Filters use the enriched metadata (genre, release decade, developer,
+// multiplayer tags) and whether a game is downloaded. They're remembered per
+// system in filters.json.
+
+// SortOrder is the order of the game list
+type SortOrder string
+
+const (
+	SortCatalog  SortOrder = "" // As listed in the catalog
+	SortName     SortOrder = "name"
+	SortReleased SortOrder = "released" // Newest first
+	SortRating   SortOrder = "rating"   // Best first
+	SortSize     SortOrder = "size"     // Largest first
+	SortAdded    SortOrder = "added"    // Newest catalog date first
+)
+
+var sortOrders = []SortOrder{SortCatalog, SortName, SortReleased, SortRating, SortSize, SortAdded}
+
+// Label names the sort order in the filter panel
+func (s SortOrder) Label() string {
+	switch s {
+	case SortName:
+		return "Name"
+	case SortReleased:
+		return "Release Date"
+	case SortRating:
+		return "Rating"
+	case SortSize:
+		return "Size"
+	case SortAdded:
+		return "Date Added"
+	}
+	return "Catalog"
+}
+
+// GameFilter is the filter and sort order of a system's game list
+type GameFilter struct {
+	Genre          string    `json:"genre,omitempty"`
+	Decade         string    `json:"decade,omitempty"` // e.g. "1990s"
+	Developer      string    `json:"developer,omitempty"`
+	DownloadedOnly bool      `json:"downloadedOnly,omitempty"`
+	Multiplayer    bool      `json:"multiplayer,omitempty"`
+	Sort           SortOrder `json:"sort,omitempty"`
+}
+
+// Count returns how many filters are set, not counting the sort order
+func (f GameFilter) Count() int {
+	n := 0
+	for _, set := range []bool{f.Genre != "", f.Decade != "", f.Developer != "", f.DownloadedOnly, f.Multiplayer} {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+// matches reports whether a game passes the filter. meta may be nil, in which
+// case only the download filter can pass.
+func (f GameFilter) matches(meta *GameMetadata, downloaded bool) bool {
+	if f.DownloadedOnly && !downloaded {
+		return false
+	}
+	if f.Genre == "" && f.Decade == "" && f.Developer == "" && !f.Multiplayer {
+		return true
+	}
+	if meta == nil {
+		return false
+	}
+	if f.Genre != "" && !containsString(meta.Genres, f.Genre) {
+		return false
+	}
+	if f.Decade != "" && releaseDecade(meta) != f.Decade {
+		return false
+	}
+	if f.Developer != "" && !containsString(meta.Developers, f.Developer) {
+		return false
+	}
+	if f.Multiplayer && !isMultiplayer(meta) {
+		return false
+	}
+	return true
+}
+
+var filtersPath string
+var gameFilters map[string]GameFilter // system -> filter
+
+func loadGameFilters() {
+	gameFilters = make(map[string]GameFilter)
+	data, err := os.ReadFile(filtersPath)
+	if err != nil {
+		return
+	}
+	json.Unmarshal(data, &gameFilters)
+	if gameFilters == nil {
+		gameFilters = make(map[string]GameFilter)
+	}
+}
+
+func saveGameFilters() {
+	data, err := json.MarshalIndent(gameFilters, "", "  ")
+	if err != nil {
+		return
+	}
+	tmpPath := filtersPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		logDebug("Failed to save %s: %v", filtersPath, err)
+		return
+	}
+	if err := os.Rename(tmpPath, filtersPath); err != nil {
+		logDebug("Failed to save %s: %v", filtersPath, err)
+	}
+}
+
+// releaseDecade returns the decade a game came out in, e.g. "1990s"
+func releaseDecade(meta *GameMetadata) string {
+	year, err := strconv.Atoi(meta.Year())
+	if err != nil {
+		return ""
+	}
+	return strconv.Itoa(year/10*10) + "s"
+}
+
+// isMultiplayer reports whether a game has any multiplayer or co-op tag
+func isMultiplayer(meta *GameMetadata) bool {
+	for _, tag := range meta.Tags {
+		tag = strings.ToLower(tag)
+		if strings.Contains(tag, "multiplayer") || strings.Contains(tag, "co-op") ||
+			strings.Contains(tag, "split screen") || strings.Contains(tag, "pvp") {
+			return true
+		}
+	}
+	return false
+}
+
+// gameFacets are the values the filters of a game list can take, most common first
+type gameFacets struct {
+	Genres     []string
+	Decades    []string // Oldest first
+	Developers []string
+}
+
+// collectFacets finds the filter values that match at least one of the games
+func collectFacets(games []ROM, metaOf func(ROM) *GameMetadata) gameFacets {
+	genres := make(map[string]int)
+	decades := make(map[string]int)
+	developers := make(map[string]int)
+	for _, game := range games {
+		meta := metaOf(game)
+		if meta == nil {
+			continue
+		}
+		for _, genre := range meta.Genres {
+			genres[genre]++
+		}
+		if decade := releaseDecade(meta); decade != "" {
+			decades[decade]++
+		}
+		for _, dev := range meta.Developers {
+			developers[dev]++
+		}
+	}
+
+	facets := gameFacets{
+		Genres:     byCount(genres),
+		Developers: byCount(developers),
+	}
+	for decade := range decades {
+		facets.Decades = append(facets.Decades, decade)
+	}
+	sort.Strings(facets.Decades)
+	return facets
+}
+
+// byCount returns the keys of counts, most common first
+func byCount(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// sortGames orders games in place. Games without the value sorted on go last.
+func sortGames(games []ROM, order SortOrder, metaOf func(ROM) *GameMetadata) {
+	var key func(ROM) (string, float64, bool) // text key, number key, known
+	switch order {
+	case SortName:
+		key = func(g ROM) (string, float64, bool) { return strings.ToLower(g.Name), 0, true }
+	case SortReleased:
+		key = func(g ROM) (string, float64, bool) {
+			if meta := metaOf(g); meta != nil && meta.Released != "" {
+				return meta.Released, 0, true
+			}
+			return "", 0, false
+		}
+	case SortRating:
+		key = func(g ROM) (string, float64, bool) {
+			if meta := metaOf(g); meta != nil && meta.Rating > 0 {
+				return "", meta.Rating, true
+			}
+			return "", 0, false
+		}
+	case SortSize:
+		key = func(g ROM) (string, float64, bool) {
+			size := parseROMSize(g.Size)
+			return "", float64(size), size > 0
+		}
+	case SortAdded:
+		key = func(g ROM) (string, float64, bool) {
+			date := parseROMDate(g.Date)
+			return "", float64(date.Unix()), !date.IsZero()
+		}
+	default:
+		return
+	}
+
+	ascending := order == SortName
+	sort.SliceStable(games, func(i, j int) bool {
+		ti, ni, oki := key(games[i])
+		tj, nj, okj := key(games[j])
+		if oki != okj {
+			return oki
+		}
+		if ti != tj {
+			return (ti < tj) == ascending
+		}
+		if ni != nj {
+			return (ni < nj) == ascending
+		}
+		return false
+	})
+}
+
+// parseROMSize parses a catalog size such as "38.4 MiB", 0 if unknown
+func parseROMSize(size string) int64 {
+	fields := strings.Fields(size)
+	if len(fields) != 2 {
+		return 0
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0
+	}
+	units := map[string]float64{
+		"B": 1, "KiB": 1 << 10, "MiB": 1 << 20, "GiB": 1 << 30,
+		"KB": 1e3, "MB": 1e6, "GB": 1e9,
+	}
+	return int64(value * units[fields[1]])
+}
+
+// formatROMSize formats a size like the catalogs do, e.g. "1.2 GiB"
+func formatROMSize(size int64) string {
+	switch {
+	case size >= 1<<30:
+		return fmt.Sprintf("%.1f GiB", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", size)
+}
+
+// parseROMDate parses a catalog date such as "18-Oct-2020 23:22"
+func parseROMDate(date string) time.Time {
+	t, _ := time.Parse("02-Jan-2006 15:04", date)
+	return t
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Rows of the filter panel
+const (
+	filterRowSort = iota
+	filterRowGenre
+	filterRowDecade
+	filterRowDeveloper
+	filterRowDownloaded
+	filterRowMultiplayer
+	filterRowCount
+)
+
+// buildFiltersPanel creates the filter and sort panel. It's swapped into the
+// right panel like the download queue. Every row is changed with left/right.
+func (a *App) buildFiltersPanel() {
+	a.filtersList = widget.NewList(
+		func() int { return filterRowCount },
+		func() fyne.CanvasObject {
+			return NewTappableListItem(widget.NewLabel("Filter: Value"))
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			tappable := item.(*TappableListItem)
+			tappable.SetListInfo(a.filtersList, id, func(itemID widget.ListItemID) {
+				a.cycleFilter(1)
+			})
+			text := a.filterRowText(id)
+			if id == a.selectedFilterIdx {
+				text = "> " + text
+			}
+			tappable.Content.(*widget.Label).SetText(text)
+		},
+	)
+	a.filtersList.OnSelected = func(id widget.ListItemID) {
+		a.selectedFilterIdx = id
+		a.filtersList.Refresh()
+	}
+
+	header := widget.NewLabel("FILTER & SORT")
+	header.TextStyle = fyne.TextStyle{Bold: true}
+	buttons := container.NewHBox(
+		widget.NewButton("<", func() { a.cycleFilter(-1) }),
+		widget.NewButton(">", func() { a.cycleFilter(1) }),
+		widget.NewButton("Clear", a.clearFilters),
+		widget.NewButton("Back", a.hideFilters),
+	)
+	headerRow := container.NewBorder(nil, nil, header, buttons)
+
+	a.filtersPanel = container.NewBorder(
+		headerRow, nil, nil, nil,
+		a.filtersList,
+	)
+}
+
+// currentFilter returns the filter of the system on screen
+func (a *App) currentFilter() GameFilter {
+	return gameFilters[a.currentSystem]
+}
+
+func (a *App) setCurrentFilter(f GameFilter) {
+	if f == (GameFilter{}) {
+		delete(gameFilters, a.currentSystem)
+	} else {
+		gameFilters[a.currentSystem] = f
+	}
+	saveGameFilters()
+	a.filterGames()
+	a.filtersList.Refresh()
+}
+
+func (a *App) filterRowText(row int) string {
+	f := a.currentFilter()
+	orAll := func(value string) string {
+		if value == "" {
+			return "All"
+		}
+		return value
+	}
+	onOff := func(on bool) string {
+		if on {
+			return "On"
+		}
+		return "Off"
+	}
+
+	switch row {
+	case filterRowSort:
+		return "Sort: " + f.Sort.Label()
+	case filterRowGenre:
+		return "Genre: " + orAll(f.Genre)
+	case filterRowDecade:
+		return "Released: " + orAll(f.Decade)
+	case filterRowDeveloper:
+		return "Developer: " + orAll(f.Developer)
+	case filterRowDownloaded:
+		return "Downloaded Only: " + onOff(f.DownloadedOnly)
+	case filterRowMultiplayer:
+		return "Multiplayer Only: " + onOff(f.Multiplayer)
+	}
+	return ""
+}
+
+// cycleFilter changes the value of the selected row to the next or previous one
+func (a *App) cycleFilter(delta int) {
+	f := a.currentFilter()
+	switch a.selectedFilterIdx {
+	case filterRowSort:
+		orders := make([]string, len(sortOrders))
+		for i, order := range sortOrders {
+			orders[i] = string(order)
+		}
+		f.Sort = SortOrder(cycleValue(orders, string(f.Sort), delta))
+	case filterRowGenre:
+		f.Genre = cycleValue(append([]string{""}, a.facets.Genres...), f.Genre, delta)
+	case filterRowDecade:
+		f.Decade = cycleValue(append([]string{""}, a.facets.Decades...), f.Decade, delta)
+	case filterRowDeveloper:
+		f.Developer = cycleValue(append([]string{""}, a.facets.Developers...), f.Developer, delta)
+	case filterRowDownloaded:
+		f.DownloadedOnly = !f.DownloadedOnly
+	case filterRowMultiplayer:
+		f.Multiplayer = !f.Multiplayer
+	}
+	a.setCurrentFilter(f)
+	a.updateFiltersStatus()
+}
+
+// cycleValue returns the value delta steps from current, wrapping around
+func cycleValue(values []string, current string, delta int) string {
+	idx := 0
+	for i, v := range values {
+		if v == current {
+			idx = i
+		}
+	}
+	idx = ((idx+delta)%len(values) + len(values)) % len(values)
+	return values[idx]
+}
+
+func (a *App) clearFilters() {
+	a.setCurrentFilter(GameFilter{Sort: a.currentFilter().Sort})
+	a.updateFiltersStatus()
+}
+
+func (a *App) updateFiltersStatus() {
+	a.statusBar.SetText(fmt.Sprintf("%d of %d games", len(a.filteredGames), len(a.allGames)))
+}
+
+// updateFiltersButton shows how many filters are set on the Filters button
+func (a *App) updateFiltersButton() {
+	if n := a.currentFilter().Count(); n > 0 {
+		a.filtersBtn.SetText(fmt.Sprintf("Filters (%d)", n))
+	} else {
+		a.filtersBtn.SetText("Filters")
+	}
+}
+
+func (a *App) showFilters() {
+	if a.choosingEmulator || a.showingDownloads || a.showingCollections {
+		return
+	}
+	if a.focusOnDetails {
+		a.unfocusDetails()
+	}
+	a.showingFilters = true
+	a.rightPanel.Objects = []fyne.CanvasObject{a.filtersPanel}
+	a.rightPanel.Refresh()
+	a.filtersList.Select(a.selectedFilterIdx)
+	a.filtersList.Refresh()
+	a.updateFiltersStatus()
+}
+
+func (a *App) hideFilters() {
+	a.showingFilters = false
+	a.rightPanel.Objects = []fyne.CanvasObject{a.gamePanel}
+	a.rightPanel.Refresh()
+	a.updateStatus()
+}
+
+func (a *App) toggleFilters() {
+	if a.showingFilters {
+		a.hideFilters()
+	} else {
+		a.showFilters()
+	}
+}
+
+func (a *App) navigateFilters(delta int) {
+	newIdx := a.selectedFilterIdx + delta
+	if newIdx >= 0 && newIdx < filterRowCount {
+		a.selectedFilterIdx = newIdx
+		a.filtersList.Select(newIdx)
+		a.filtersList.Refresh()
+	}
+}
+
+// handleFiltersKey handles keyboard input while the filter panel is shown
+func (a *App) handleFiltersKey(ke *fyne.KeyEvent) {
+	switch ke.Name {
+	case fyne.KeyUp:
+		a.navigateFilters(-1)
+	case fyne.KeyDown:
+		a.navigateFilters(1)
+	case fyne.KeyLeft:
+		a.cycleFilter(-1)
+	case fyne.KeyRight, fyne.KeyReturn, fyne.KeyEnter, fyne.KeySpace:
+		a.cycleFilter(1)
+	case fyne.KeyDelete, fyne.KeyC:
+		a.clearFilters()
+	case fyne.KeyEscape, fyne.KeyBackspace, fyne.KeyS:
+		a.hideFilters()
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGameFilterMatches(t *testing.T) {
+	meta := &GameMetadata{
+		Released:   "1997-01-31",
+		Genres:     []string{"RPG", "Adventure"},
+		Developers: []string{"Square"},
+		Tags:       []string{"Singleplayer", "Story Rich"},
+	}
+	coop := &GameMetadata{Released: "2001-06-10", Genres: []string{"Action"}, Tags: []string{"Local Co-Op"}}
+	undated := &GameMetadata{Genres: []string{"RPG"}}
+
+	tests := []struct {
+		name       string
+		filter     GameFilter
+		meta       *GameMetadata
+		downloaded bool
+		want       bool
+	}{
+		{"no filter", GameFilter{}, meta, false, true},
+		{"no filter, no metadata", GameFilter{}, nil, false, true},
+		{"downloaded only", GameFilter{DownloadedOnly: true}, nil, true, true},
+		{"downloaded only, not downloaded", GameFilter{DownloadedOnly: true}, meta, false, false},
+		{"genre", GameFilter{Genre: "RPG"}, meta, false, true},
+		{"other genre", GameFilter{Genre: "Action"}, meta, false, false},
+		{"genre, no metadata", GameFilter{Genre: "RPG"}, nil, true, false},
+		{"decade", GameFilter{Decade: "1990s"}, meta, false, true},
+		{"other decade", GameFilter{Decade: "2000s"}, meta, false, false},
+		{"decade, no release date", GameFilter{Decade: "1990s"}, undated, false, false},
+		{"developer", GameFilter{Developer: "Square"}, meta, false, true},
+		{"other developer", GameFilter{Developer: "Enix"}, meta, false, false},
+		{"multiplayer", GameFilter{Multiplayer: true}, coop, false, true},
+		{"single player", GameFilter{Multiplayer: true}, meta, false, false},
+		{"all of them", GameFilter{Genre: "RPG", Decade: "1990s", Developer: "Square", DownloadedOnly: true}, meta, true, true},
+		{"all but one", GameFilter{Genre: "RPG", Decade: "1990s", Developer: "Square", Multiplayer: true}, meta, true, false},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.matches(tt.meta, tt.downloaded); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSortGames(t *testing.T) {
+	games := []ROM{
+		{Name: "b game.zip", Size: "2.0 MiB", Date: "18-Oct-2020 23:22"},
+		{Name: "A Game.zip", Size: "-", Date: "01-Jan-2019 10:00"},
+		{Name: "c game.zip", Size: "700 KiB"},
+		{Name: "D Game.zip", Size: "1.5 GiB", Date: "05-Mar-2021 08:30"},
+	}
+	metadata := map[string]*GameMetadata{
+		"b game.zip": {Released: "1995-03-01", Rating: 4.2},
+		"A Game.zip": {Released: "1991-11-21", Rating: 4.5},
+		"D Game.zip": {Rating: 3.9},
+	}
+	metaOf := func(game ROM) *GameMetadata { return metadata[game.Name] }
+
+	tests := []struct {
+		order SortOrder
+		want  string // Games without the value last, in catalog order
+	}{
+		{SortCatalog, "b A c D"},
+		{SortName, "A b c D"},
+		{SortReleased, "b A c D"},
+		{SortRating, "A b D c"},
+		{SortSize, "D b c A"},
+		{SortAdded, "D b A c"},
+	}
+	for _, tt := range tests {
+		sorted := append([]ROM(nil), games...)
+		sortGames(sorted, tt.order, metaOf)
+		var got []string
+		for _, game := range sorted {
+			got = append(got, game.Name[:1])
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.order.Label(), strings.Join(got, " "), tt.want)
+		}
+	}
+}
+
+func TestParseROMSize(t *testing.T) {
+	tests := []struct {
+		size string
+		want int64
+	}{
+		{"38.4 MiB", 40265318},
+		{"1.5 GiB", 3 << 29},
+		{"700 KiB", 700 << 10},
+		{"512 B", 512},
+		{"1.2 GB", 1200000000},
+		{"650 MB", 650000000},
+		{"3 KB", 3000},
+		{"-", 0},
+		{"", 0},
+		{"12 parsecs", 0},
+		{"big MiB", 0},
+		{"38.4MiB", 0},
+	}
+	for _, tt := range tests {
+		if got := parseROMSize(tt.size); got != tt.want {
+			t.Errorf("parseROMSize(%q) = %d, want %d", tt.size, got, tt.want)
+		}
+	}
+
+	// Sizes of grouped discs are written back in the catalog format
+	for _, size := range []string{"1.5 GiB", "38.4 MiB", "700.0 KiB", "512 B"} {
+		if got := formatROMSize(parseROMSize(size)); got != size {
+			t.Errorf("formatROMSize(parseROMSize(%q)) = %q", size, got)
+		}
+	}
+}
+
+func TestCollectFacets(t *testing.T) {
+	metadata := map[string]*GameMetadata{
+		"a": {Released: "1994-01-01", Genres: []string{"Action", "Platformer"}, Developers: []string{"Capcom"}},
+		"b": {Released: "1987-05-01", Genres: []string{"Action"}, Developers: []string{"Konami"}},
+		"c": {Released: "1999-12-01", Genres: []string{"RPG", "Action"}, Developers: []string{"Konami"}},
+	}
+	games := []ROM{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "no metadata"}}
+	facets := collectFacets(games, func(game ROM) *GameMetadata { return metadata[game.Name] })
+
+	if got := strings.Join(facets.Genres, ","); got != "Action,Platformer,RPG" {
+		t.Errorf("genres %s, want the most common first, then by name", got)
+	}
+	if got := strings.Join(facets.Decades, ","); got != "1980s,1990s" {
+		t.Errorf("decades %s, want oldest first", got)
+	}
+	if got := strings.Join(facets.Developers, ","); got != "Konami,Capcom" {
+		t.Errorf("developers %s, want the most common first", got)
+	}
+}
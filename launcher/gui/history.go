@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Play history
+//
+// Every launch, from the GUI or --launch, is recorded in history.json once the
+// emulator exits. The history drives the "Recently Played" system and the
+// play time column of the game list.
+
+// recentSystemID is the virtual system listing recently played games
+const recentSystemID = "recent"
+
+// maxRecentGames is how many games the Recently Played system shows
+const maxRecentGames = 50
+
+// PlaySession is one run of a game
+type PlaySession struct {
+	SystemID string    `json:"system"`
+	Game     string    `json:"game"`
+	Emulator string    `json:"emulator"`
+	Core     string    `json:"core,omitempty"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Seconds  float64   `json:"seconds"`
+	ExitCode int       `json:"exitCode"`
+	Error    string    `json:"error,omitempty"`
+	Headless bool      `json:"headless,omitempty"`
+}
+
+var historyPath string
+var playHistory []PlaySession
+var playTotals map[string]float64 // seconds played per "system/game"
+var historyMu sync.Mutex
+
+func loadPlayHistory() {
+	playHistory = nil
+	playTotals = make(map[string]float64)
+	data, err := os.ReadFile(historyPath)
+	if err != nil {
+		return
+	}
+	json.Unmarshal(data, &playHistory)
+	for _, s := range playHistory {
+		playTotals[s.SystemID+"/"+s.Game] += s.Seconds
+	}
+}
+
+// savePlayHistory must be called with historyMu held
+func savePlayHistory() {
+	data, _ := json.MarshalIndent(playHistory, "", "  ")
+	tmpPath := historyPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return
+	}
+	os.Rename(tmpPath, historyPath)
+}
+
+// newPlaySession starts recording a launch with an emulator option
+func newPlaySession(systemID string, game ROM, opt EmulatorOption) *PlaySession {
+	session := &PlaySession{
+		SystemID: systemID,
+		Game:     game.Name,
+		Emulator: emulatorName(opt.Path),
+		Start:    time.Now(),
+	}
+	if opt.Core != "" {
+		session.Core = emulatorName(opt.Core)
+	}
+	return session
+}
+
+// emulatorName turns an emulator path into a short name for the history
+func emulatorName(emuPath string) string {
+	name := filepath.Base(strings.ReplaceAll(emuPath, "\\", "/"))
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// finish records the end of the session with the result of cmd.Wait and saves it
+func (s *PlaySession) finish(cmd *exec.Cmd, waitErr error) {
+	s.End = time.Now()
+	s.Seconds = s.End.Sub(s.Start).Seconds()
+	if cmd.ProcessState != nil {
+		s.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	if waitErr != nil {
+		s.Error = waitErr.Error()
+	}
+
+	historyMu.Lock()
+	playHistory = append(playHistory, *s)
+	playTotals[s.SystemID+"/"+s.Game] += s.Seconds
+	savePlayHistory()
+	historyMu.Unlock()
+}
+
+// totalPlayTime returns how long a game has been played in all sessions
+func totalPlayTime(systemID, gameName string) time.Duration {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	return time.Duration(playTotals[systemID+"/"+gameName] * float64(time.Second))
+}
+
+// formatPlayTime formats a play time for the game list, "" if never played
+func formatPlayTime(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return ""
+	case d < time.Minute:
+		return "<1m"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh %02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// recentGames returns the most recently played games, newest first, with the
+// catalog entry of each game where it can be found
+func recentGames() []ROM {
+	historyMu.Lock()
+	sessions := make([]PlaySession, len(playHistory))
+	copy(sessions, playHistory)
+	historyMu.Unlock()
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].End.After(sessions[j].End)
+	})
+
+	catalogs := make(catalogLookup)
+	seen := make(map[string]bool)
+	var games []ROM
+	for _, s := range sessions {
+		key := s.SystemID + "/" + s.Game
+		if seen[key] {
+			continue
+		}
+		if _, ok := systems[s.SystemID]; !ok {
+			continue
+		}
+		seen[key] = true
+		games = append(games, catalogs.find(s.SystemID, s.Game))
+		if len(games) >= maxRecentGames {
+			break
+		}
+	}
+	return games
+}
+
+// catalogLookup finds catalog entries by system and name, loading each
+// system's catalog once
+type catalogLookup map[string]map[string]ROM
+
+// find returns the catalog entry of a game with its systemID set, or an entry
+// with just the name if the catalog doesn't have it
+func (c catalogLookup) find(systemID, name string) ROM {
+	if c[systemID] == nil {
+		c[systemID] = catalogByBaseName(systemID)
+	}
+	// Headless launches record the file name, so match on the base name
+	game, ok := c[systemID][strings.ToLower(name)]
+	if !ok {
+		base := strings.TrimSuffix(name, filepath.Ext(name))
+		game, ok = c[systemID][strings.ToLower(base)]
+	}
+	if !ok {
+		game = ROM{Name: name}
+	}
+	game.systemID = systemID
+	return game
+}
+
+// catalogByBaseName indexes a system's catalog by lowercase name, with and
+// without the archive extension
+func catalogByBaseName(systemID string) map[string]ROM {
+	index := make(map[string]ROM)
+	roms, err := loadCatalog(systemID)
+	if err != nil {
+		return index
+	}
+	for _, rom := range roms {
+		index[strings.ToLower(rom.Name)] = rom
+		index[strings.ToLower(catalogBaseName(rom.Name))] = rom
+	}
+	return index
+}
@@ -0,0 +1,594 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Importing an existing ROM collection
+//
+// importCollection walks arbitrary directories and identifies each ROM either
+// by hash (when the catalog has DAT checksums, see dat.go) or by matching its
+// file name against the catalog. Names that don't match exactly are scored
+// like a search (see search.go), both ways, so typos and words in another
+// order still match; close but uncertain ones are reported as ambiguous.
+// Matched files are moved, copied or hard-linked
+// into romsDir/<config.Dir> under the catalog's name so buildROMCache finds them.
+
+// ImportMode is how matched files are brought into the roms directory
+type ImportMode string
+
+const (
+	ImportCopy ImportMode = "copy"
+	ImportMove ImportMode = "move"
+	ImportLink ImportMode = "link"
+)
+
+// ImportMatch is a local file that was identified as a catalog game
+type ImportMatch struct {
+	Source string
+	Dest   string
+	Game   string
+	ByHash bool
+}
+
+// ImportAmbiguous is a local file that matched several catalog games by name,
+// or one only loosely
+type ImportAmbiguous struct {
+	Source     string
+	Candidates []string
+}
+
+// ImportReport lists what an import did with every file it looked at
+type ImportReport struct {
+	SystemID  string
+	Mode      ImportMode
+	Matched   []ImportMatch
+	Ambiguous []ImportAmbiguous
+	Unknown   []string
+	Errors    []string
+}
+
+// catalogIndex looks up catalog games by checksum and by normalised name
+type catalogIndex struct {
+	games   []ROM
+	bySHA1  map[string]romRef
+	byCRC   map[string]romRef // crc32 + ":" + size
+	byName  map[string][]int  // normalised full name, tags included
+	byTitle map[string][]int  // normalised name without (...) and [...] tags
+	words   [][]string        // Title words for fuzzy matching, in catalog order
+}
+
+const (
+	importMinScore      = 0.75 // Fuzzy score a name needs to be imported
+	importNearMissScore = 0.5  // Below importMinScore, reported as ambiguous
+	importScoreMargin   = 0.05 // Scores this close to the best are candidates too
+	maxImportCandidates = 5
+)
+
+type romRef struct {
+	game int
+	file int
+}
+
+// loadCatalog reads the 1g1rsets catalog of a system, with the checksums
+// imported from DATs
+func loadCatalog(systemID string) ([]ROM, error) {
+	config, ok := systems[systemID]
+	if !ok {
+		return nil, fmt.Errorf("unknown system '%s'", systemID)
+	}
+	data, err := os.ReadFile(filepath.Join(baseDir, "1g1rsets", config.RomJsonFile))
+	if err != nil {
+		return nil, err
+	}
+	var roms []ROM
+	if err := json.Unmarshal(data, &roms); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", config.RomJsonFile, err)
+	}
+	applyChecksums(systemID, roms)
+	return roms, nil
+}
+
+func newCatalogIndex(games []ROM) *catalogIndex {
+	idx := &catalogIndex{
+		games:   games,
+		bySHA1:  make(map[string]romRef),
+		byCRC:   make(map[string]romRef),
+		byName:  make(map[string][]int),
+		byTitle: make(map[string][]int),
+	}
+	for i, game := range games {
+		for j, f := range game.Files {
+			if f.SHA1 != "" {
+				idx.bySHA1[strings.ToLower(f.SHA1)] = romRef{i, j}
+			}
+			if f.CRC32 != "" {
+				idx.byCRC[crcKey(f.CRC32, f.Size)] = romRef{i, j}
+			}
+		}
+		base := catalogBaseName(game.Name)
+		name := normalizeROMName(base)
+		idx.byName[name] = append(idx.byName[name], i)
+		title := normalizeROMName(stripROMTags(base))
+		idx.byTitle[title] = append(idx.byTitle[title], i)
+		idx.words = append(idx.words, searchWords(stripROMTags(base)))
+	}
+	return idx
+}
+
+func crcKey(crc string, size int64) string {
+	return fmt.Sprintf("%s:%d", strings.ToLower(crc), size)
+}
+
+// lookupHash finds the catalog file with the given checksums
+func (idx *catalogIndex) lookupHash(sum fileChecksums) (romRef, bool) {
+	if ref, ok := idx.bySHA1[sum.SHA1]; ok {
+		return ref, true
+	}
+	ref, ok := idx.byCRC[crcKey(sum.CRC32, sum.Size)]
+	return ref, ok
+}
+
+// lookupName returns the catalog games whose name matches a local file name.
+// An exact match (ignoring case and punctuation) wins; otherwise games with
+// the same title are candidates, narrowed down by the tags the file has.
+// Failing that, titles are compared fuzzily. sure is false when the games
+// are only near misses, to be reported rather than imported.
+func (idx *catalogIndex) lookupName(fileName string) (hits []int, sure bool) {
+	base := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	if hits := idx.byName[normalizeROMName(base)]; len(hits) > 0 {
+		return hits, true
+	}
+
+	if hits := idx.byTitle[normalizeROMName(stripROMTags(base))]; len(hits) > 0 {
+		return idx.narrowByTags(base, hits), true
+	}
+	return idx.lookupFuzzy(base)
+}
+
+// narrowByTags keeps the games that have all the (...) tags of a local file
+// name, or all of them if none does
+func (idx *catalogIndex) narrowByTags(base string, hits []int) []int {
+	if len(hits) <= 1 {
+		return hits
+	}
+	localTags := romTags(base)
+	var narrowed []int
+	for _, i := range hits {
+		all := true
+		for _, tag := range localTags {
+			if !containsString(romTags(catalogBaseName(idx.games[i].Name)), tag) {
+				all = false
+				break
+			}
+		}
+		if all {
+			narrowed = append(narrowed, i)
+		}
+	}
+	if len(narrowed) > 0 {
+		return narrowed
+	}
+	return hits
+}
+
+// lookupFuzzy compares the title of a file with every catalog title, word by
+// word with the search's matchWord, so typos and words in another order still
+// match. A single best game scoring at least importMinScore is sure;
+// otherwise the best few above importNearMissScore are returned as near
+// misses.
+func (idx *catalogIndex) lookupFuzzy(base string) ([]int, bool) {
+	local := searchWords(stripROMTags(base))
+	if len(local) == 0 {
+		return nil, false
+	}
+
+	type result struct {
+		game  int
+		score float64
+	}
+	var results []result
+	for i, words := range idx.words {
+		// Neither title may have many words the other lacks, so the lower
+		// of the two scores counts
+		s := titleScore(local, words)
+		if back := titleScore(words, local); back < s {
+			s = back
+		}
+		if s >= importNearMissScore {
+			results = append(results, result{i, s})
+		}
+	}
+	if len(results) == 0 {
+		return nil, false
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].score > results[j].score
+	})
+
+	best := results[0].score
+	var top []int
+	for _, r := range results {
+		if r.score < best-importScoreMargin || len(top) == maxImportCandidates {
+			break
+		}
+		top = append(top, r.game)
+	}
+	top = idx.narrowByTags(base, top)
+	return top, best >= importMinScore && len(top) == 1
+}
+
+// titleScore is the mean score of the best match of each word of a among the
+// words of b, a word without a match counting 0
+func titleScore(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, q := range a {
+		best := 0.0
+		for _, w := range b {
+			if s := matchWord(q, w); s > best {
+				best = s
+			}
+		}
+		total += best
+	}
+	return total / float64(len(a))
+}
+
+// normalizeROMName lowercases a name and drops everything but letters and
+// digits, so "Legend of Zelda, The - A Link..." and "legend_of_zelda_the_a_link"
+// compare equal
+func normalizeROMName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// stripROMTags removes "(USA)", "[!]" style tags from a ROM name
+func stripROMTags(name string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range name {
+		switch r {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			if depth > 0 {
+				depth--
+			}
+		default:
+			if depth == 0 {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
+
+// romTags returns the normalised contents of the (...) tags of a ROM name,
+// split on commas: "Game (USA, Europe) (Rev 1)" gives usa, europe, rev1
+func romTags(name string) []string {
+	var tags []string
+	for {
+		start := strings.Index(name, "(")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(name[start:], ")")
+		if end < 0 {
+			break
+		}
+		for _, tag := range strings.Split(name[start+1:start+end], ",") {
+			if t := normalizeROMName(tag); t != "" {
+				tags = append(tags, t)
+			}
+		}
+		name = name[start+end+1:]
+	}
+	return tags
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// importCollection imports the ROMs found under sources into a system's roms
+// directory. With dryRun set it only reports what it would do.
+func importCollection(systemID string, sources []string, mode ImportMode, dryRun bool, progress func(path string)) (*ImportReport, error) {
+	config, ok := systems[systemID]
+	if !ok {
+		return nil, fmt.Errorf("unknown system '%s'", systemID)
+	}
+	if config.SpecialDownload == "wiiu" {
+		return nil, fmt.Errorf("importing %s titles is not supported", config.Name)
+	}
+	switch mode {
+	case ImportCopy, ImportMove, ImportLink:
+	default:
+		return nil, fmt.Errorf("unknown import mode '%s' (use copy, move or link)", mode)
+	}
+
+	games, err := loadCatalog(systemID)
+	if err != nil {
+		return nil, err
+	}
+	idx := newCatalogIndex(games)
+	romDir := filepath.Join(romsDir, config.Dir)
+	if !dryRun {
+		if err := os.MkdirAll(romDir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &ImportReport{SystemID: systemID, Mode: mode}
+	claimed := make(map[string]string) // dest -> source, to catch two files mapping to one name
+
+	for _, source := range sources {
+		err := filepath.WalkDir(source, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", path, err))
+				return nil
+			}
+			if d.IsDir() {
+				// Don't re-import what's already in place
+				if same, _ := sameDir(path, romDir); same {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			ext := strings.ToLower(filepath.Ext(path))
+			if ext != ".zip" && !containsString(config.FileExtensions, ext) {
+				return nil
+			}
+			if progress != nil {
+				progress(path)
+			}
+
+			dest, game, byHash, candidates, err := identifyROM(idx, config, path)
+			switch {
+			case err != nil:
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", path, err))
+			case len(candidates) > 0:
+				report.Ambiguous = append(report.Ambiguous, ImportAmbiguous{Source: path, Candidates: candidates})
+			case dest == "":
+				report.Unknown = append(report.Unknown, path)
+			default:
+				destPath := filepath.Join(romDir, dest)
+				if other, taken := claimed[strings.ToLower(destPath)]; taken {
+					report.Errors = append(report.Errors, fmt.Sprintf("%s: %s is already imported from %s", path, dest, other))
+					return nil
+				}
+				if fileExists(destPath) {
+					report.Errors = append(report.Errors, fmt.Sprintf("%s: %s already exists", path, dest))
+					return nil
+				}
+				if !dryRun {
+					if err := placeFile(path, destPath, mode); err != nil {
+						report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", path, err))
+						return nil
+					}
+				}
+				claimed[strings.ToLower(destPath)] = path
+				report.Matched = append(report.Matched, ImportMatch{Source: path, Dest: destPath, Game: game, ByHash: byHash})
+			}
+			return nil
+		})
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", source, err))
+		}
+	}
+	return report, nil
+}
+
+// identifyROM works out which catalog game a local file is and the name it
+// should have in the roms directory. Candidates mean the name match was
+// ambiguous, or only a near miss.
+func identifyROM(idx *catalogIndex, config SystemConfig, path string) (dest, game string, byHash bool, candidates []string, err error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	isZip := ext == ".zip"
+
+	// Systems that extract their downloads expect loose files on disk
+	if isZip && config.NeedsExtract {
+		return "", "", false, nil, errors.New("archive must be extracted before importing")
+	}
+
+	// By hash: a loose file, or every entry of a zip, must match one game
+	if len(idx.bySHA1) > 0 || len(idx.byCRC) > 0 {
+		if isZip {
+			sums, err := hashZipEntries(path)
+			if err != nil {
+				return "", "", false, nil, err
+			}
+			matched := -1
+			for _, sum := range sums {
+				ref, ok := idx.lookupHash(sum)
+				if !ok || (matched >= 0 && ref.game != matched) {
+					matched = -2
+					break
+				}
+				matched = ref.game
+			}
+			if matched >= 0 {
+				g := idx.games[matched]
+				return g.Name, g.Name, true, nil, nil
+			}
+		} else {
+			sum, err := hashFile(path)
+			if err != nil {
+				return "", "", false, nil, err
+			}
+			if ref, ok := idx.lookupHash(sum); ok {
+				g := idx.games[ref.game]
+				if config.NeedsExtract {
+					return g.Files[ref.file].Name, g.Name, true, nil, nil
+				}
+				return catalogBaseName(g.Name) + ext, g.Name, true, nil, nil
+			}
+		}
+	}
+
+	// By name
+	hits, sure := idx.lookupName(filepath.Base(path))
+	if len(hits) == 0 {
+		return "", "", false, nil, nil
+	}
+	if len(hits) > 1 || !sure {
+		for _, i := range hits {
+			candidates = append(candidates, idx.games[i].Name)
+		}
+		return "", "", false, candidates, nil
+	}
+	g := idx.games[hits[0]]
+	if isZip {
+		return g.Name, g.Name, false, nil, nil
+	}
+	return catalogBaseName(g.Name) + ext, g.Name, false, nil, nil
+}
+
+// placeFile moves, copies or hard-links src to dst
+func placeFile(src, dst string, mode ImportMode) error {
+	switch mode {
+	case ImportLink:
+		return os.Link(src, dst)
+	case ImportMove:
+		if err := os.Rename(src, dst); err == nil {
+			return nil
+		}
+		// Different filesystem: copy, then remove the original
+		if err := copyFile(src, dst); err != nil {
+			return err
+		}
+		return os.Remove(src)
+	}
+	return copyFile(src, dst)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+func sameDir(a, b string) (bool, error) {
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	bi, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(ai, bi), nil
+}
+
+// String formats the report as plain text
+func (r *ImportReport) String() string {
+	var b strings.Builder
+	verb := map[ImportMode]string{ImportCopy: "Copied", ImportMove: "Moved", ImportLink: "Linked"}[r.Mode]
+	fmt.Fprintf(&b, "EmuBuddy import report - %s - %s\n", systems[r.SystemID].Name, time.Now().Format("2006-01-02 15:04"))
+	fmt.Fprintf(&b, "%d matched, %d ambiguous, %d unknown, %d errors\n",
+		len(r.Matched), len(r.Ambiguous), len(r.Unknown), len(r.Errors))
+
+	if len(r.Matched) > 0 {
+		fmt.Fprintf(&b, "\nMATCHED (%s)\n", strings.ToLower(verb))
+		for _, m := range r.Matched {
+			how := "name"
+			if m.ByHash {
+				how = "hash"
+			}
+			fmt.Fprintf(&b, "  [%s] %s -> %s\n", how, m.Source, filepath.Base(m.Dest))
+		}
+	}
+	if len(r.Ambiguous) > 0 {
+		b.WriteString("\nAMBIGUOUS\n")
+		for _, a := range r.Ambiguous {
+			fmt.Fprintf(&b, "  %s\n", a.Source)
+			sort.Strings(a.Candidates)
+			for _, c := range a.Candidates {
+				fmt.Fprintf(&b, "      ? %s\n", c)
+			}
+		}
+	}
+	if len(r.Unknown) > 0 {
+		b.WriteString("\nUNKNOWN\n")
+		for _, u := range r.Unknown {
+			fmt.Fprintf(&b, "  %s\n", u)
+		}
+	}
+	if len(r.Errors) > 0 {
+		b.WriteString("\nERRORS\n")
+		for _, e := range r.Errors {
+			fmt.Fprintf(&b, "  %s\n", e)
+		}
+	}
+	return b.String()
+}
+
+// writeImportReport saves the report next to the launcher and returns its path
+func writeImportReport(r *ImportReport) (string, error) {
+	path := filepath.Join(baseDir, fmt.Sprintf("import_report_%s.txt", r.SystemID))
+	return path, os.WriteFile(path, []byte(r.String()), 0644)
+}
+
+// importCollectionHeadless runs an import from the command line:
+// --import <system> <copy|move|link> <dir>... [--dry-run]
+func importCollectionHeadless(args []string) {
+	dryRun := false
+	var rest []string
+	for _, arg := range args {
+		if arg == "--dry-run" {
+			dryRun = true
+		} else {
+			rest = append(rest, arg)
+		}
+	}
+	if len(rest) < 3 {
+		fmt.Printf("Usage: %s --import <system> <copy|move|link> <dir>... [--dry-run]\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	report, err := importCollection(rest[0], rest[2:], ImportMode(rest[1]), dryRun, nil)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(report.String())
+	if !dryRun {
+		if path, err := writeImportReport(report); err == nil {
+			fmt.Printf("\nReport saved to %s\n", path)
+		}
+	}
+}
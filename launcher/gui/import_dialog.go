@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showImportDialog asks for a folder and an import mode, then imports that
+// folder into the current system
+func (a *App) showImportDialog() {
+	config, ok := systems[a.currentSystem]
+	if !ok {
+		a.statusBar.SetText("Select a system to import games into")
+		return
+	}
+
+	dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+		if err != nil || uri == nil {
+			return
+		}
+		source := uri.Path()
+
+		mode := widget.NewRadioGroup([]string{string(ImportCopy), string(ImportMove), string(ImportLink)}, nil)
+		mode.SetSelected(string(ImportCopy))
+		mode.Horizontal = true
+		content := container.NewVBox(
+			widget.NewLabel(fmt.Sprintf("Import %s games from:\n%s", config.Name, source)),
+			mode,
+		)
+
+		dialog.ShowCustomConfirm("Import Collection", "Import", "Cancel", content, func(ok bool) {
+			if ok {
+				go a.runImport(a.currentSystem, source, ImportMode(mode.Selected))
+			}
+		}, a.window)
+	}, a.window)
+}
+
+func (a *App) runImport(systemID, source string, mode ImportMode) {
+	report, err := importCollection(systemID, []string{source}, mode, false, func(path string) {
+		a.statusBar.SetText("Importing: " + filepath.Base(path))
+	})
+	if err != nil {
+		a.statusBar.SetText(fmt.Sprintf("Import failed: %v", err))
+		return
+	}
+
+	reportPath, _ := writeImportReport(report)
+	a.statusBar.SetText(fmt.Sprintf("Imported %d games (%d ambiguous, %d unknown)",
+		len(report.Matched), len(report.Ambiguous), len(report.Unknown)))
+
+	if systemID == a.currentSystem {
+		a.buildROMCache()
+		a.gameList.Refresh()
+		a.updateLaunchButton()
+	}
+
+	text := widget.NewLabel(report.String())
+	text.Wrapping = fyne.TextWrapOff
+	scroll := container.NewScroll(text)
+	scroll.SetMinSize(fyne.NewSize(700, 400))
+	title := "Import Report"
+	if reportPath != "" {
+		title += " - " + filepath.Base(reportPath)
+	}
+	dialog.ShowCustom(title, "Close", scroll, a.window)
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestLookupName(t *testing.T) {
+	idx := newCatalogIndex([]ROM{
+		{Name: "Legend of Zelda, The - A Link to the Past (USA).zip"},
+		{Name: "Super Mario World (USA).zip"},
+		{Name: "Super Mario World (Europe).zip"},
+		{Name: "Tetris (World) (Rev 1).zip"},
+		{Name: "Tetris (World).zip"},
+		{Name: "Pokemon - Emerald Version (USA, Europe).zip"},
+		{Name: "Donkey Kong Country (USA).zip"},
+		{Name: "Donkey Kong Country 2 - Diddy's Kong Quest (USA).zip"},
+	})
+
+	tests := []struct {
+		file string
+		want []string
+		sure bool
+	}{
+		{"Super Mario World (USA).sfc", []string{"Super Mario World (USA).zip"}, true},
+		{"legend_of_zelda_the_a_link_to_the_past_(usa).smc", []string{"Legend of Zelda, The - A Link to the Past (USA).zip"}, true},
+		// Same title, told apart by the file's tags if it has any
+		{"Super Mario World.sfc", []string{"Super Mario World (USA).zip", "Super Mario World (Europe).zip"}, true},
+		{"Super Mario World (Europe) [!].sfc", []string{"Super Mario World (Europe).zip"}, true},
+		{"Tetris (Rev 1).gb", []string{"Tetris (World) (Rev 1).zip"}, true},
+		// Fuzzy: a typo, and a title cut short
+		{"Pokemon Emrald Version (USA).gba", []string{"Pokemon - Emerald Version (USA, Europe).zip"}, true},
+		{"Donkey Kong Country 2 Diddys Kong Quest.sfc", []string{"Donkey Kong Country 2 - Diddy's Kong Quest (USA).zip"}, true},
+		// Near misses are only candidates
+		{"Mario World.sfc", []string{"Super Mario World (USA).zip", "Super Mario World (Europe).zip"}, false},
+		{"Completely Different.sfc", nil, false},
+	}
+	for _, tt := range tests {
+		hits, sure := idx.lookupName(tt.file)
+		var got []string
+		for _, i := range hits {
+			got = append(got, idx.games[i].Name)
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") || sure != tt.sure {
+			t.Errorf("%s: got %q (sure %v), want %q (sure %v)", tt.file, got, sure, tt.want, tt.sure)
+		}
+	}
+}
+
+func TestRomTags(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"Game (USA, Europe) (Rev 1)", []string{"usa", "europe", "rev1"}},
+		{"Game (Japan) [!]", []string{"japan"}},
+		{"Game", nil},
+		{"Game (Unclosed", nil},
+	}
+	for _, tt := range tests {
+		if got := romTags(tt.name); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("romTags(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// testZip returns a zip holding one file
+func testZip(t *testing.T, name, data string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte(data))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestImportCollection(t *testing.T) {
+	hashed := "data of a renamed dump"
+	sum := sha1.Sum([]byte(hashed))
+	catalog := []ROM{
+		{Name: "Super Mario World (USA).zip"},
+		{Name: "F-Zero (USA).zip"},
+		{Name: "Chrono Trigger (USA).zip", Files: []ROMFile{{Name: "Chrono Trigger (USA).sfc", SHA1: hex.EncodeToString(sum[:])}}},
+		{Name: "Tetris (Japan).zip"},
+		{Name: "Tetris (Europe).zip"},
+	}
+	config := SystemConfig{Name: "SNES", Dir: "snes", FileExtensions: []string{".sfc", ".smc"}}
+	// Not matching the checksums, so matched by name
+	fzero := testZip(t, "F-Zero (USA).sfc", "fzero")
+
+	// source returns a folder of local files to import
+	source := func(t *testing.T) string {
+		dir := t.TempDir()
+		for name, data := range map[string]string{
+			"Super Mario World (USA).smc": "smw",
+			"f-zero.zip":                  fzero,
+			"saves/chrono.sfc":            hashed,
+			"Tetris.sfc":                  "tetris",
+			"Unknown Game.sfc":            "unknown",
+			"readme.txt":                  "not a rom",
+		} {
+			writeTestFile(t, filepath.Join(dir, filepath.FromSlash(name)), data)
+		}
+		return dir
+	}
+	romDir := func() string { return filepath.Join(romsDir, "snes") }
+
+	t.Run("copy", func(t *testing.T) {
+		useTestSystem(t, "snes", config, catalog)
+		src := source(t)
+		report, err := importCollection("snes", []string{src}, ImportCopy, false, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var matched []string
+		for _, m := range report.Matched {
+			how := "name"
+			if m.ByHash {
+				how = "hash"
+			}
+			rel, _ := filepath.Rel(src, m.Source)
+			matched = append(matched, filepath.ToSlash(rel)+" -> "+filepath.Base(m.Dest)+" ("+how+")")
+		}
+		sort.Strings(matched)
+		want := []string{
+			"Super Mario World (USA).smc -> Super Mario World (USA).smc (name)",
+			"f-zero.zip -> F-Zero (USA).zip (name)",
+			"saves/chrono.sfc -> Chrono Trigger (USA).sfc (hash)",
+		}
+		if strings.Join(matched, "\n") != strings.Join(want, "\n") {
+			t.Errorf("matched\n\t%s\nwant\n\t%s", strings.Join(matched, "\n\t"), strings.Join(want, "\n\t"))
+		}
+		if len(report.Ambiguous) != 1 || filepath.Base(report.Ambiguous[0].Source) != "Tetris.sfc" || len(report.Ambiguous[0].Candidates) != 2 {
+			t.Errorf("ambiguous: %+v", report.Ambiguous)
+		}
+		if len(report.Unknown) != 1 || filepath.Base(report.Unknown[0]) != "Unknown Game.sfc" {
+			t.Errorf("unknown: %q", report.Unknown)
+		}
+		if len(report.Errors) != 0 {
+			t.Errorf("errors: %q", report.Errors)
+		}
+
+		for name, data := range map[string]string{
+			"Super Mario World (USA).smc": "smw",
+			"F-Zero (USA).zip":            fzero,
+			"Chrono Trigger (USA).sfc":    hashed,
+		} {
+			if got, err := os.ReadFile(filepath.Join(romDir(), name)); err != nil || string(got) != data {
+				t.Errorf("%s: %q, %v", name, got, err)
+			}
+		}
+		if !fileExists(filepath.Join(src, "f-zero.zip")) {
+			t.Error("copying removed the original")
+		}
+
+		// Importing again doesn't overwrite what's there
+		report, err = importCollection("snes", []string{src}, ImportCopy, false, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Matched) != 0 || len(report.Errors) != 3 {
+			t.Errorf("second import matched %d with errors %q", len(report.Matched), report.Errors)
+		}
+	})
+
+	t.Run("move", func(t *testing.T) {
+		useTestSystem(t, "snes", config, catalog)
+		src := source(t)
+		if _, err := importCollection("snes", []string{src}, ImportMove, false, nil); err != nil {
+			t.Fatal(err)
+		}
+		if fileExists(filepath.Join(src, "f-zero.zip")) || !fileExists(filepath.Join(romDir(), "F-Zero (USA).zip")) {
+			t.Error("f-zero.zip wasn't moved")
+		}
+		if !fileExists(filepath.Join(src, "Tetris.sfc")) {
+			t.Error("an ambiguous file was moved")
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		useTestSystem(t, "snes", config, catalog)
+		src := source(t)
+		report, err := importCollection("snes", []string{src}, ImportMove, true, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Matched) != 3 {
+			t.Errorf("matched %d, want 3", len(report.Matched))
+		}
+		if fileExists(romDir()) || !fileExists(filepath.Join(src, "f-zero.zip")) {
+			t.Error("a dry run changed files")
+		}
+	})
+
+	t.Run("two files for one game", func(t *testing.T) {
+		useTestSystem(t, "snes", config, catalog)
+		src := t.TempDir()
+		writeTestFile(t, filepath.Join(src, "a", "F-Zero (USA).sfc"), "one")
+		writeTestFile(t, filepath.Join(src, "b", "F-Zero (USA).sfc"), "two")
+		report, err := importCollection("snes", []string{src}, ImportCopy, false, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Matched) != 1 || len(report.Errors) != 1 {
+			t.Errorf("matched %d with errors %q, want one of each", len(report.Matched), report.Errors)
+		}
+	})
+
+	t.Run("archives of extracted systems", func(t *testing.T) {
+		extracted := config
+		extracted.NeedsExtract = true
+		useTestSystem(t, "snes", extracted, catalog)
+		src := t.TempDir()
+		writeTestFile(t, filepath.Join(src, "F-Zero (USA).zip"), "zip")
+		report, err := importCollection("snes", []string{src}, ImportCopy, false, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Matched) != 0 || len(report.Errors) != 1 {
+			t.Errorf("matched %d with errors %q, want the archive refused", len(report.Matched), report.Errors)
+		}
+	})
+
+	t.Run("bad mode", func(t *testing.T) {
+		useTestSystem(t, "snes", config, catalog)
+		if _, err := importCollection("snes", nil, ImportMode("symlink"), false, nil); err == nil {
+			t.Error("imported with an unknown mode")
+		}
+	})
+}
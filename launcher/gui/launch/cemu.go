@@ -0,0 +1,63 @@
+package launch
+
+import (
+	"encoding/xml"
+	"path/filepath"
+	"strings"
+)
+
+// CemuMLC returns the folder Cemu keeps its emulated Wii U storage (mlc01)
+// in, where updates and DLC are installed. A custom path set in Cemu's
+// settings wins; otherwise it is the default of Cemu 2 for the platform.
+func (e *Env) CemuMLC(cemu Resolved) string {
+	dataDir, configDir := e.cemuDirs(cemu)
+	if data, err := e.ReadFile(filepath.Join(configDir, "settings.xml")); err == nil {
+		var settings struct {
+			MLCPath string `xml:"mlc_path"`
+		}
+		if xml.Unmarshal(data, &settings) == nil && strings.TrimSpace(settings.MLCPath) != "" {
+			return filepath.Clean(strings.TrimSpace(settings.MLCPath))
+		}
+	}
+	return filepath.Join(dataDir, "mlc01")
+}
+
+// cemuDirs returns Cemu's user data and settings folders. A "portable"
+// folder next to the executable holds both; the AppImage runs from a
+// temporary mount, so on Linux only the XDG folders are used.
+func (e *Env) cemuDirs(cemu Resolved) (dataDir, configDir string) {
+	switch e.GOOS {
+	case "windows":
+		if portable := filepath.Join(cemu.Dir, "portable"); e.Exists(portable) {
+			return portable, portable
+		}
+		// Cemu 1.x kept everything next to the executable, and Cemu 2
+		// carries on doing so for existing installs
+		if e.Exists(filepath.Join(cemu.Dir, "settings.xml")) {
+			return cemu.Dir, cemu.Dir
+		}
+		dir := filepath.Join(e.getenv("APPDATA", filepath.Join(e.HomeDir, "AppData", "Roaming")), "Cemu")
+		return dir, dir
+	case "darwin":
+		if portable := filepath.Join(cemu.Dir, "portable"); e.Exists(portable) {
+			return portable, portable
+		}
+		dir := filepath.Join(e.HomeDir, "Library", "Application Support", "Cemu")
+		return dir, dir
+	default:
+		dataDir = filepath.Join(e.getenv("XDG_DATA_HOME", filepath.Join(e.HomeDir, ".local", "share")), "Cemu")
+		configDir = filepath.Join(e.getenv("XDG_CONFIG_HOME", filepath.Join(e.HomeDir, ".config")), "Cemu")
+		return dataDir, configDir
+	}
+}
+
+// getenv returns an environment variable, or fallback when it is empty
+func (e *Env) getenv(key, fallback string) string {
+	prefix := key + "="
+	for _, kv := range e.Environ() {
+		if strings.HasPrefix(kv, prefix) && len(kv) > len(prefix) {
+			return kv[len(prefix):]
+		}
+	}
+	return fallback
+}
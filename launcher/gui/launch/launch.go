@@ -0,0 +1,219 @@
+// Package launch builds the command that runs a game in an emulator. The GUI
+// and the headless --launch mode both use it, so a game starts the same way
+// from either.
+package launch
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/emubuddy/extract"
+)
+
+// Env is the machine games are launched on. Everything that touches the file
+// system or the PATH goes through its fields, so launches can be worked out
+// against a fake file system.
+type Env struct {
+	GOOS    string
+	BaseDir string // EmuBuddy folder; relative paths in systems.json start here
+	HomeDir string
+
+	Exists   func(path string) bool
+	Glob     func(pattern string) ([]string, error)
+	ReadDir  func(dir string) ([]string, error) // File names in a folder
+	ReadFile func(path string) ([]byte, error)
+	LookPath func(file string) (string, error)
+	Chmod    func(path string, mode os.FileMode) error
+	Environ  func() []string
+}
+
+// NewEnv returns the Env of the running system
+func NewEnv(baseDir string) *Env {
+	home, _ := os.UserHomeDir()
+	return &Env{
+		GOOS:    runtime.GOOS,
+		BaseDir: baseDir,
+		HomeDir: home,
+		Exists: func(path string) bool {
+			_, err := os.Stat(path)
+			return err == nil
+		},
+		Glob:     filepath.Glob,
+		ReadDir:  readDirNames,
+		ReadFile: os.ReadFile,
+		LookPath: exec.LookPath,
+		Chmod:    os.Chmod,
+		Environ:  os.Environ,
+	}
+}
+
+func readDirNames(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	return names, nil
+}
+
+// LaunchSpec is everything needed to run one game.
+//
+// Args and ExtraArgs are templates: {rom}, {romDir}, {core}, {baseDir},
+// {biosDir} and {saveDir} are replaced by their values, as they are in Env.
+// If no argument contains {rom}, the ROM path is added at the end.
+type LaunchSpec struct {
+	Emulator  Emulator
+	Args      []string          // Emulator arguments from systems.json, e.g. "-L {core}"
+	ExtraArgs []string          // The user's own arguments, put before Args and passed as typed
+	Core      string            // RetroArch core from systems.json, if any
+	ROM       string            // Absolute path of the file to load
+	BiosDir   string            // Absolute path for {biosDir}
+	SaveDir   string            // Absolute path for {saveDir}
+	Env       map[string]string // Extra environment variables
+	Output    io.Writer         // Receives the emulator's stdout and stderr, if set
+}
+
+// CoreNotFoundError is returned when a RetroArch core isn't installed
+type CoreNotFoundError struct {
+	Path string
+}
+
+func (e *CoreNotFoundError) Error() string {
+	return fmt.Sprintf("core not found: %s", e.Path)
+}
+
+// Command builds the command for a launch. On Linux it also makes sure an
+// AppImage is executable.
+func (e *Env) Command(spec LaunchSpec) (*exec.Cmd, error) {
+	emu := e.Resolve(spec.Emulator)
+	isAppImage := e.GOOS == "linux" && strings.HasSuffix(strings.ToLower(emu.Path), ".appimage")
+
+	core := ""
+	if spec.Core != "" {
+		core = e.ResolveArg(emu, spec.Core)
+		if e.GOOS == "linux" && !e.Exists(core) {
+			return nil, &CoreNotFoundError{Path: core}
+		}
+	}
+	vars := strings.NewReplacer(
+		"{rom}", spec.ROM,
+		"{romDir}", filepath.Dir(spec.ROM),
+		"{core}", core,
+		"{baseDir}", e.BaseDir,
+		"{biosDir}", spec.BiosDir,
+		"{saveDir}", spec.SaveDir,
+	)
+
+	// Flatpak's "run <id>" goes first
+	args := append([]string{}, emu.Args...)
+	hasROM := false
+	// The user's arguments are used as typed, only their placeholders are filled in
+	for _, arg := range spec.ExtraArgs {
+		hasROM = hasROM || strings.Contains(arg, "{rom}")
+		args = append(args, vars.Replace(arg))
+	}
+	for _, arg := range spec.Args {
+		if strings.Contains(arg, "{") {
+			hasROM = hasROM || strings.Contains(arg, "{rom}")
+			args = append(args, vars.Replace(arg))
+			continue
+		}
+		// Paths without placeholders, such as old "-L cores/..." arguments
+		resolved := e.ResolveArg(emu, arg)
+		if e.GOOS == "linux" && strings.HasSuffix(strings.ToLower(resolved), ".so") && !e.Exists(resolved) {
+			return nil, &CoreNotFoundError{Path: resolved}
+		}
+		args = append(args, resolved)
+	}
+	if !hasROM {
+		args = append(args, spec.ROM)
+	}
+
+	if isAppImage {
+		e.Chmod(emu.Path, 0755)
+	}
+
+	cmd := exec.Command(emu.Path, args...)
+	switch {
+	case emu.Flatpak:
+		// Flatpak apps run in their own sandbox, the working dir doesn't matter
+	case isAppImage:
+		// Cemu and other AppImages need to run from the EmuBuddy folder
+		cmd.Dir = e.BaseDir
+	default:
+		cmd.Dir = emu.Dir
+	}
+
+	if e.GOOS == "linux" {
+		// Force SDL and Qt to use X11 instead of Wayland (fixes EGL symbol errors in AppImages)
+		cmd.Env = append(e.Environ(),
+			"SDL_VIDEODRIVER=x11",
+			"QT_QPA_PLATFORM=xcb",
+		)
+	}
+	if len(spec.Env) > 0 {
+		if cmd.Env == nil {
+			cmd.Env = e.Environ()
+		}
+		// Added last so they win over the defaults above
+		keys := make([]string, 0, len(spec.Env))
+		for key := range spec.Env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			cmd.Env = append(cmd.Env, key+"="+vars.Replace(spec.Env[key]))
+		}
+	}
+
+	if spec.Output != nil {
+		cmd.Stdout = spec.Output
+		cmd.Stderr = spec.Output
+	}
+	return cmd, nil
+}
+
+// FindROM returns the file to load for a game in romDir. For systems whose
+// games are extracted before launching that's the extracted file named like
+// the game, picked by ResolveLaunchFile with exts as the priority list.
+// Otherwise, or if nothing was extracted, it's the file named after the game.
+// The result may not exist.
+func (e *Env) FindROM(romDir, gameName string, exts []string, needsExtract bool) string {
+	if needsExtract {
+		// Extracted files aren't always named exactly like the archive
+		baseName := extract.TrimExt(gameName)
+		var files []string
+		names, _ := e.ReadDir(romDir)
+		for _, name := range names {
+			if strings.HasPrefix(name, baseName) {
+				files = append(files, filepath.Join(romDir, name))
+			}
+		}
+		if path := e.ResolveLaunchFile(files, baseName, exts); path != "" {
+			return path
+		}
+	}
+	return filepath.Join(romDir, gameName)
+}
+
+// FindRPX returns the .rpx in the code folder of an installed Wii U title,
+// which is what Cemu loads, or the title folder if there is none
+func (e *Env) FindRPX(titleDir string) string {
+	codeDir := filepath.Join(titleDir, "code")
+	names, _ := e.ReadDir(codeDir)
+	for _, name := range names {
+		if strings.HasSuffix(strings.ToLower(name), ".rpx") {
+			return filepath.Join(codeDir, name)
+		}
+	}
+	return titleDir
+}
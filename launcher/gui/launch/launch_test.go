@@ -0,0 +1,593 @@
+package launch
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testLaunch is the default way of launching a system's games with one of
+// its emulators: the first of its cores, if it has any
+type testLaunch struct {
+	key  string // System ID, with "/standalone" for its standalone emulator
+	emu  testEmulator
+	core *Core
+	args []string
+}
+
+// testLaunches lists the launches of a system the way the launcher's
+// emulator choice does: cores are loaded with "-L {core}" unless the
+// emulator's arguments say where
+func testLaunches(sys testSystem) []testLaunch {
+	var launches []testLaunch
+	for i, emu := range sys.emulators() {
+		l := testLaunch{key: sys.ID, emu: emu, args: emu.Args}
+		if i > 0 {
+			l.key += "/standalone"
+		}
+		if len(emu.Cores) > 0 {
+			if !strings.Contains(strings.Join(l.args, " "), "{core}") {
+				l.args = append([]string{"-L", "{core}"}, l.args...)
+			}
+			core := emu.Cores[0]
+			l.core = &Core{Dll: core.Dll, So: core.So, Dylib: core.Dylib}
+		}
+		launches = append(launches, l)
+	}
+	return launches
+}
+
+// The machines a game is launched on: nothing installed, only flatpak, or
+// every emulator and core of installedFiles, and flatpak
+const (
+	machineBare      = "bare"
+	machineFlatpak   = "flatpak"
+	machineInstalled = "installed"
+)
+
+// installedFiles are the emulators and cores of a machine with everything
+// installed, by GOOS
+var installedFiles = map[string][]string{
+	"linux": {
+		"Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage",
+		"Emulators/mGBA/mGBA-0.10.5-appimage-x64.AppImage",
+		"Emulators/melonDS/melonDS-x86_64.AppImage",
+		"Emulators/Azahar/azahar-2121.2.AppImage",
+		"Emulators/Dolphin/Dolphin-2503-x86_64.AppImage",
+		"Emulators/Cemu/Cemu-2.6-x86_64.AppImage",
+		"Emulators/PPSSPP/PPSSPP-v1.19.3-anylinux-x86_64.AppImage",
+		"Emulators/PCSX2/pcsx2-v2.4.0-linux-appimage-x64-Qt.AppImage",
+	},
+	"darwin": {
+		"Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch",
+		"Emulators/mGBA/mGBA.app/Contents/MacOS/mGBA",
+		"Emulators/melonDS/melonDS.app/Contents/MacOS/melonDS",
+		"Emulators/Azahar/azahar.app/Contents/MacOS/azahar",
+		"Emulators/Dolphin/Dolphin.app/Contents/MacOS/Dolphin",
+		"Emulators/Cemu/Cemu.exe",
+		"Emulators/PPSSPP/PPSSPP.app/Contents/MacOS/PPSSPP",
+		"Emulators/PCSX2/PCSX2-v2.4.0.app/Contents/MacOS/PCSX2-qt",
+	},
+	"windows": {
+		"Emulators/RetroArch/RetroArch-Win64/retroarch.exe",
+		"Emulators/mGBA/mGBA-0.10.5-win64/mGBA.exe",
+		"Emulators/melonDS/melonDS.exe",
+		"Emulators/Azahar/azahar.exe",
+		"Emulators/Dolphin/Dolphin-x64/Dolphin.exe",
+		"Emulators/Cemu/Cemu.exe",
+		"Emulators/PPSSPP/PPSSPPWindows64.exe",
+		"Emulators/PCSX2/pcsx2-qt.exe",
+	},
+}
+
+// installedCores are the cores of testLaunches, installed on every machine
+// with everything installed
+var installedCores = []string{
+	"nestopia", "snes9x", "mupen64plus_next", "gambatte", "mgba", "melonds",
+	"citra", "ppsspp", "swanstation", "flycast", "genesis_plus_gx",
+	"mednafen_pce_fast", "mednafen_vb", "stella", "prosystem", "handy",
+	"mednafen_ngp", "gearcoleco", "freeintv", "mednafen_wswan", "mednafen_saturn",
+}
+
+// newMachine returns the files and PATH of a machine
+func newMachine(goos, machine string) *fakeFS {
+	fs := &fakeFS{files: make(map[string]string)}
+	if machine == machineBare {
+		return fs
+	}
+	fs.path = map[string]string{"flatpak": "/usr/bin/flatpak"}
+	if machine != machineInstalled {
+		return fs
+	}
+	for _, file := range installedFiles[goos] {
+		fs.files[file] = ""
+	}
+	for _, core := range installedCores {
+		switch goos {
+		case "linux":
+			fs.files["Emulators/RetroArch/RetroArch-Linux-x86_64/cores/"+core+"_libretro.so"] = ""
+		case "darwin":
+			fs.files[testHomeDir+"/Library/Application Support/RetroArch/cores/"+core+"_libretro.dylib"] = ""
+		default:
+			fs.files["Emulators/RetroArch/RetroArch-Win64/cores/"+core+"_libretro.dll"] = ""
+		}
+	}
+	return fs
+}
+
+// goldenCommand is the command a launch runs, or the error it fails with
+type goldenCommand struct {
+	installed bool // What Installed reports for the emulator
+	path      string
+	dir       string
+	args      string // Joined with spaces
+	err       string
+}
+
+// The game every launch loads, and its folders
+const (
+	goldenROM     = "/roms/Game (USA).bin"
+	goldenBiosDir = "/bios"
+	goldenSaveDir = "/saves"
+)
+
+// goldenCommands are the commands of testLaunches, keyed by launch, GOOS
+// and machine. Each launch also gets the user's argument
+// "--dirs={romDir},{baseDir},{biosDir},{saveDir}".
+var goldenCommands = map[string]goldenCommand{
+	"3ds/darwin/bare":                   {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/citra_libretro.dylib /roms/Game (USA).bin"},
+	"3ds/darwin/flatpak":                {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/citra_libretro.dylib /roms/Game (USA).bin"},
+	"3ds/darwin/installed":              {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/citra_libretro.dylib /roms/Game (USA).bin"},
+	"3ds/linux/bare":                    {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/citra_libretro.so"},
+	"3ds/linux/flatpak":                 {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/citra_libretro.so"},
+	"3ds/linux/installed":               {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/citra_libretro.so /roms/Game (USA).bin"},
+	"3ds/standalone/darwin/bare":        {path: "/emubuddy/Emulators/Azahar/azahar.app/Contents/MacOS/azahar", dir: "/emubuddy/Emulators/Azahar/azahar.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"3ds/standalone/darwin/flatpak":     {path: "/emubuddy/Emulators/Azahar/azahar.app/Contents/MacOS/azahar", dir: "/emubuddy/Emulators/Azahar/azahar.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"3ds/standalone/darwin/installed":   {installed: true, path: "/emubuddy/Emulators/Azahar/azahar.app/Contents/MacOS/azahar", dir: "/emubuddy/Emulators/Azahar/azahar.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"3ds/standalone/linux/bare":         {path: "/emubuddy/Emulators/Azahar/azahar.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"3ds/standalone/linux/flatpak":      {path: "/emubuddy/Emulators/Azahar/azahar.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"3ds/standalone/linux/installed":    {installed: true, path: "/emubuddy/Emulators/Azahar/azahar-2121.2.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"3ds/standalone/windows/bare":       {path: "/emubuddy/Emulators/Azahar/azahar.exe", dir: "/emubuddy/Emulators/Azahar", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"3ds/standalone/windows/flatpak":    {path: "/emubuddy/Emulators/Azahar/azahar.exe", dir: "/emubuddy/Emulators/Azahar", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"3ds/standalone/windows/installed":  {installed: true, path: "/emubuddy/Emulators/Azahar/azahar.exe", dir: "/emubuddy/Emulators/Azahar", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"3ds/windows/bare":                  {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/citra_libretro.dll /roms/Game (USA).bin"},
+	"3ds/windows/flatpak":               {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/citra_libretro.dll /roms/Game (USA).bin"},
+	"3ds/windows/installed":             {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/citra_libretro.dll /roms/Game (USA).bin"},
+	"atari2600/darwin/bare":             {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/stella_libretro.dylib /roms/Game (USA).bin"},
+	"atari2600/darwin/flatpak":          {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/stella_libretro.dylib /roms/Game (USA).bin"},
+	"atari2600/darwin/installed":        {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/stella_libretro.dylib /roms/Game (USA).bin"},
+	"atari2600/linux/bare":              {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/stella_libretro.so"},
+	"atari2600/linux/flatpak":           {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/stella_libretro.so"},
+	"atari2600/linux/installed":         {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/stella_libretro.so /roms/Game (USA).bin"},
+	"atari2600/windows/bare":            {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/stella_libretro.dll /roms/Game (USA).bin"},
+	"atari2600/windows/flatpak":         {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/stella_libretro.dll /roms/Game (USA).bin"},
+	"atari2600/windows/installed":       {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/stella_libretro.dll /roms/Game (USA).bin"},
+	"atari7800/darwin/bare":             {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/prosystem_libretro.dylib /roms/Game (USA).bin"},
+	"atari7800/darwin/flatpak":          {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/prosystem_libretro.dylib /roms/Game (USA).bin"},
+	"atari7800/darwin/installed":        {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/prosystem_libretro.dylib /roms/Game (USA).bin"},
+	"atari7800/linux/bare":              {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/prosystem_libretro.so"},
+	"atari7800/linux/flatpak":           {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/prosystem_libretro.so"},
+	"atari7800/linux/installed":         {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/prosystem_libretro.so /roms/Game (USA).bin"},
+	"atari7800/windows/bare":            {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/prosystem_libretro.dll /roms/Game (USA).bin"},
+	"atari7800/windows/flatpak":         {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/prosystem_libretro.dll /roms/Game (USA).bin"},
+	"atari7800/windows/installed":       {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/prosystem_libretro.dll /roms/Game (USA).bin"},
+	"coleco/darwin/bare":                {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/gearcoleco_libretro.dylib /roms/Game (USA).bin"},
+	"coleco/darwin/flatpak":             {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/gearcoleco_libretro.dylib /roms/Game (USA).bin"},
+	"coleco/darwin/installed":           {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/gearcoleco_libretro.dylib /roms/Game (USA).bin"},
+	"coleco/linux/bare":                 {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/gearcoleco_libretro.so"},
+	"coleco/linux/flatpak":              {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/gearcoleco_libretro.so"},
+	"coleco/linux/installed":            {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/gearcoleco_libretro.so /roms/Game (USA).bin"},
+	"coleco/windows/bare":               {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/gearcoleco_libretro.dll /roms/Game (USA).bin"},
+	"coleco/windows/flatpak":            {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/gearcoleco_libretro.dll /roms/Game (USA).bin"},
+	"coleco/windows/installed":          {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/gearcoleco_libretro.dll /roms/Game (USA).bin"},
+	"dreamcast/darwin/bare":             {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/flycast_libretro.dylib /roms/Game (USA).bin"},
+	"dreamcast/darwin/flatpak":          {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/flycast_libretro.dylib /roms/Game (USA).bin"},
+	"dreamcast/darwin/installed":        {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/flycast_libretro.dylib /roms/Game (USA).bin"},
+	"dreamcast/linux/bare":              {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/flycast_libretro.so"},
+	"dreamcast/linux/flatpak":           {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/flycast_libretro.so"},
+	"dreamcast/linux/installed":         {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/flycast_libretro.so /roms/Game (USA).bin"},
+	"dreamcast/windows/bare":            {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/flycast_libretro.dll /roms/Game (USA).bin"},
+	"dreamcast/windows/flatpak":         {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/flycast_libretro.dll /roms/Game (USA).bin"},
+	"dreamcast/windows/installed":       {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/flycast_libretro.dll /roms/Game (USA).bin"},
+	"ds/darwin/bare":                    {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/melonds_libretro.dylib /roms/Game (USA).bin"},
+	"ds/darwin/flatpak":                 {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/melonds_libretro.dylib /roms/Game (USA).bin"},
+	"ds/darwin/installed":               {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/melonds_libretro.dylib /roms/Game (USA).bin"},
+	"ds/linux/bare":                     {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/melonds_libretro.so"},
+	"ds/linux/flatpak":                  {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/melonds_libretro.so"},
+	"ds/linux/installed":                {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/melonds_libretro.so /roms/Game (USA).bin"},
+	"ds/standalone/darwin/bare":         {path: "/emubuddy/Emulators/melonDS/melonDS.app/Contents/MacOS/melonDS", dir: "/emubuddy/Emulators/melonDS/melonDS.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"ds/standalone/darwin/flatpak":      {path: "/emubuddy/Emulators/melonDS/melonDS.app/Contents/MacOS/melonDS", dir: "/emubuddy/Emulators/melonDS/melonDS.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"ds/standalone/darwin/installed":    {installed: true, path: "/emubuddy/Emulators/melonDS/melonDS.app/Contents/MacOS/melonDS", dir: "/emubuddy/Emulators/melonDS/melonDS.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"ds/standalone/linux/bare":          {path: "/emubuddy/Emulators/melonDS/melonDS.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"ds/standalone/linux/flatpak":       {path: "/emubuddy/Emulators/melonDS/melonDS.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"ds/standalone/linux/installed":     {installed: true, path: "/emubuddy/Emulators/melonDS/melonDS-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"ds/standalone/windows/bare":        {path: "/emubuddy/Emulators/melonDS/melonDS.exe", dir: "/emubuddy/Emulators/melonDS", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"ds/standalone/windows/flatpak":     {path: "/emubuddy/Emulators/melonDS/melonDS.exe", dir: "/emubuddy/Emulators/melonDS", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"ds/standalone/windows/installed":   {installed: true, path: "/emubuddy/Emulators/melonDS/melonDS.exe", dir: "/emubuddy/Emulators/melonDS", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"ds/windows/bare":                   {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/melonds_libretro.dll /roms/Game (USA).bin"},
+	"ds/windows/flatpak":                {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/melonds_libretro.dll /roms/Game (USA).bin"},
+	"ds/windows/installed":              {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/melonds_libretro.dll /roms/Game (USA).bin"},
+	"gamegear/darwin/bare":              {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/genesis_plus_gx_libretro.dylib /roms/Game (USA).bin"},
+	"gamegear/darwin/flatpak":           {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/genesis_plus_gx_libretro.dylib /roms/Game (USA).bin"},
+	"gamegear/darwin/installed":         {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/genesis_plus_gx_libretro.dylib /roms/Game (USA).bin"},
+	"gamegear/linux/bare":               {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/genesis_plus_gx_libretro.so"},
+	"gamegear/linux/flatpak":            {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/genesis_plus_gx_libretro.so"},
+	"gamegear/linux/installed":          {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/genesis_plus_gx_libretro.so /roms/Game (USA).bin"},
+	"gamegear/windows/bare":             {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/genesis_plus_gx_libretro.dll /roms/Game (USA).bin"},
+	"gamegear/windows/flatpak":          {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/genesis_plus_gx_libretro.dll /roms/Game (USA).bin"},
+	"gamegear/windows/installed":        {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/genesis_plus_gx_libretro.dll /roms/Game (USA).bin"},
+	"gb/darwin/bare":                    {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/gambatte_libretro.dylib /roms/Game (USA).bin"},
+	"gb/darwin/flatpak":                 {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/gambatte_libretro.dylib /roms/Game (USA).bin"},
+	"gb/darwin/installed":               {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/gambatte_libretro.dylib /roms/Game (USA).bin"},
+	"gb/linux/bare":                     {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/gambatte_libretro.so"},
+	"gb/linux/flatpak":                  {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/gambatte_libretro.so"},
+	"gb/linux/installed":                {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/gambatte_libretro.so /roms/Game (USA).bin"},
+	"gb/windows/bare":                   {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/gambatte_libretro.dll /roms/Game (USA).bin"},
+	"gb/windows/flatpak":                {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/gambatte_libretro.dll /roms/Game (USA).bin"},
+	"gb/windows/installed":              {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/gambatte_libretro.dll /roms/Game (USA).bin"},
+	"gba/darwin/bare":                   {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mgba_libretro.dylib /roms/Game (USA).bin"},
+	"gba/darwin/flatpak":                {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mgba_libretro.dylib /roms/Game (USA).bin"},
+	"gba/darwin/installed":              {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mgba_libretro.dylib /roms/Game (USA).bin"},
+	"gba/linux/bare":                    {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mgba_libretro.so"},
+	"gba/linux/flatpak":                 {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mgba_libretro.so"},
+	"gba/linux/installed":               {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mgba_libretro.so /roms/Game (USA).bin"},
+	"gba/standalone/darwin/bare":        {path: "/emubuddy/Emulators/mGBA/mGBA.app/Contents/MacOS/mGBA", dir: "/emubuddy/Emulators/mGBA/mGBA.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"gba/standalone/darwin/flatpak":     {path: "/emubuddy/Emulators/mGBA/mGBA.app/Contents/MacOS/mGBA", dir: "/emubuddy/Emulators/mGBA/mGBA.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"gba/standalone/darwin/installed":   {installed: true, path: "/emubuddy/Emulators/mGBA/mGBA.app/Contents/MacOS/mGBA", dir: "/emubuddy/Emulators/mGBA/mGBA.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"gba/standalone/linux/bare":         {path: "/emubuddy/Emulators/mGBA/mgba.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"gba/standalone/linux/flatpak":      {path: "/emubuddy/Emulators/mGBA/mgba.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"gba/standalone/linux/installed":    {installed: true, path: "/emubuddy/Emulators/mGBA/mGBA-0.10.5-appimage-x64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"gba/standalone/windows/bare":       {path: "/emubuddy/Emulators/mGBA/mGBA-0.10.5-win64/mGBA.exe", dir: "/emubuddy/Emulators/mGBA/mGBA-0.10.5-win64", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"gba/standalone/windows/flatpak":    {path: "/emubuddy/Emulators/mGBA/mGBA-0.10.5-win64/mGBA.exe", dir: "/emubuddy/Emulators/mGBA/mGBA-0.10.5-win64", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"gba/standalone/windows/installed":  {installed: true, path: "/emubuddy/Emulators/mGBA/mGBA-0.10.5-win64/mGBA.exe", dir: "/emubuddy/Emulators/mGBA/mGBA-0.10.5-win64", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"gba/windows/bare":                  {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mgba_libretro.dll /roms/Game (USA).bin"},
+	"gba/windows/flatpak":               {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mgba_libretro.dll /roms/Game (USA).bin"},
+	"gba/windows/installed":             {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mgba_libretro.dll /roms/Game (USA).bin"},
+	"gbc/darwin/bare":                   {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/gambatte_libretro.dylib /roms/Game (USA).bin"},
+	"gbc/darwin/flatpak":                {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/gambatte_libretro.dylib /roms/Game (USA).bin"},
+	"gbc/darwin/installed":              {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/gambatte_libretro.dylib /roms/Game (USA).bin"},
+	"gbc/linux/bare":                    {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/gambatte_libretro.so"},
+	"gbc/linux/flatpak":                 {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/gambatte_libretro.so"},
+	"gbc/linux/installed":               {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/gambatte_libretro.so /roms/Game (USA).bin"},
+	"gbc/windows/bare":                  {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/gambatte_libretro.dll /roms/Game (USA).bin"},
+	"gbc/windows/flatpak":               {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/gambatte_libretro.dll /roms/Game (USA).bin"},
+	"gbc/windows/installed":             {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/gambatte_libretro.dll /roms/Game (USA).bin"},
+	"gc/darwin/bare":                    {path: "/emubuddy/Emulators/Dolphin/Dolphin.app/Contents/MacOS/Dolphin", dir: "/emubuddy/Emulators/Dolphin/Dolphin.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -e /roms/Game (USA).bin"},
+	"gc/darwin/flatpak":                 {path: "/emubuddy/Emulators/Dolphin/Dolphin.app/Contents/MacOS/Dolphin", dir: "/emubuddy/Emulators/Dolphin/Dolphin.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -e /roms/Game (USA).bin"},
+	"gc/darwin/installed":               {installed: true, path: "/emubuddy/Emulators/Dolphin/Dolphin.app/Contents/MacOS/Dolphin", dir: "/emubuddy/Emulators/Dolphin/Dolphin.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -e /roms/Game (USA).bin"},
+	"gc/linux/bare":                     {path: "flatpak", dir: "", args: "run org.DolphinEmu.dolphin-emu --dirs=/roms,/emubuddy,/bios,/saves -e /roms/Game (USA).bin"},
+	"gc/linux/flatpak":                  {installed: true, path: "/usr/bin/flatpak", dir: "", args: "run org.DolphinEmu.dolphin-emu --dirs=/roms,/emubuddy,/bios,/saves -e /roms/Game (USA).bin"},
+	"gc/linux/installed":                {installed: true, path: "/emubuddy/Emulators/Dolphin/Dolphin-2503-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -e /roms/Game (USA).bin"},
+	"gc/windows/bare":                   {path: "/emubuddy/Emulators/Dolphin/Dolphin-x64/Dolphin.exe", dir: "/emubuddy/Emulators/Dolphin/Dolphin-x64", args: "--dirs=/roms,/emubuddy,/bios,/saves -e /roms/Game (USA).bin"},
+	"gc/windows/flatpak":                {path: "/emubuddy/Emulators/Dolphin/Dolphin-x64/Dolphin.exe", dir: "/emubuddy/Emulators/Dolphin/Dolphin-x64", args: "--dirs=/roms,/emubuddy,/bios,/saves -e /roms/Game (USA).bin"},
+	"gc/windows/installed":              {installed: true, path: "/emubuddy/Emulators/Dolphin/Dolphin-x64/Dolphin.exe", dir: "/emubuddy/Emulators/Dolphin/Dolphin-x64", args: "--dirs=/roms,/emubuddy,/bios,/saves -e /roms/Game (USA).bin"},
+	"genesis/darwin/bare":               {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/genesis_plus_gx_libretro.dylib /roms/Game (USA).bin"},
+	"genesis/darwin/flatpak":            {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/genesis_plus_gx_libretro.dylib /roms/Game (USA).bin"},
+	"genesis/darwin/installed":          {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/genesis_plus_gx_libretro.dylib /roms/Game (USA).bin"},
+	"genesis/linux/bare":                {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/genesis_plus_gx_libretro.so"},
+	"genesis/linux/flatpak":             {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/genesis_plus_gx_libretro.so"},
+	"genesis/linux/installed":           {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/genesis_plus_gx_libretro.so /roms/Game (USA).bin"},
+	"genesis/windows/bare":              {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/genesis_plus_gx_libretro.dll /roms/Game (USA).bin"},
+	"genesis/windows/flatpak":           {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/genesis_plus_gx_libretro.dll /roms/Game (USA).bin"},
+	"genesis/windows/installed":         {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/genesis_plus_gx_libretro.dll /roms/Game (USA).bin"},
+	"intellivision/darwin/bare":         {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/freeintv_libretro.dylib /roms/Game (USA).bin"},
+	"intellivision/darwin/flatpak":      {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/freeintv_libretro.dylib /roms/Game (USA).bin"},
+	"intellivision/darwin/installed":    {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/freeintv_libretro.dylib /roms/Game (USA).bin"},
+	"intellivision/linux/bare":          {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/freeintv_libretro.so"},
+	"intellivision/linux/flatpak":       {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/freeintv_libretro.so"},
+	"intellivision/linux/installed":     {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/freeintv_libretro.so /roms/Game (USA).bin"},
+	"intellivision/windows/bare":        {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/freeintv_libretro.dll /roms/Game (USA).bin"},
+	"intellivision/windows/flatpak":     {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/freeintv_libretro.dll /roms/Game (USA).bin"},
+	"intellivision/windows/installed":   {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/freeintv_libretro.dll /roms/Game (USA).bin"},
+	"lynx/darwin/bare":                  {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/handy_libretro.dylib /roms/Game (USA).bin"},
+	"lynx/darwin/flatpak":               {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/handy_libretro.dylib /roms/Game (USA).bin"},
+	"lynx/darwin/installed":             {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/handy_libretro.dylib /roms/Game (USA).bin"},
+	"lynx/linux/bare":                   {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/handy_libretro.so"},
+	"lynx/linux/flatpak":                {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/handy_libretro.so"},
+	"lynx/linux/installed":              {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/handy_libretro.so /roms/Game (USA).bin"},
+	"lynx/windows/bare":                 {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/handy_libretro.dll /roms/Game (USA).bin"},
+	"lynx/windows/flatpak":              {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/handy_libretro.dll /roms/Game (USA).bin"},
+	"lynx/windows/installed":            {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/handy_libretro.dll /roms/Game (USA).bin"},
+	"n64/darwin/bare":                   {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mupen64plus_next_libretro.dylib /roms/Game (USA).bin"},
+	"n64/darwin/flatpak":                {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mupen64plus_next_libretro.dylib /roms/Game (USA).bin"},
+	"n64/darwin/installed":              {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mupen64plus_next_libretro.dylib /roms/Game (USA).bin"},
+	"n64/linux/bare":                    {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mupen64plus_next_libretro.so"},
+	"n64/linux/flatpak":                 {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mupen64plus_next_libretro.so"},
+	"n64/linux/installed":               {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mupen64plus_next_libretro.so /roms/Game (USA).bin"},
+	"n64/windows/bare":                  {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mupen64plus_next_libretro.dll /roms/Game (USA).bin"},
+	"n64/windows/flatpak":               {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mupen64plus_next_libretro.dll /roms/Game (USA).bin"},
+	"n64/windows/installed":             {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mupen64plus_next_libretro.dll /roms/Game (USA).bin"},
+	"nes/darwin/bare":                   {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/nestopia_libretro.dylib /roms/Game (USA).bin"},
+	"nes/darwin/flatpak":                {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/nestopia_libretro.dylib /roms/Game (USA).bin"},
+	"nes/darwin/installed":              {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/nestopia_libretro.dylib /roms/Game (USA).bin"},
+	"nes/linux/bare":                    {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/nestopia_libretro.so"},
+	"nes/linux/flatpak":                 {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/nestopia_libretro.so"},
+	"nes/linux/installed":               {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/nestopia_libretro.so /roms/Game (USA).bin"},
+	"nes/windows/bare":                  {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/nestopia_libretro.dll /roms/Game (USA).bin"},
+	"nes/windows/flatpak":               {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/nestopia_libretro.dll /roms/Game (USA).bin"},
+	"nes/windows/installed":             {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/nestopia_libretro.dll /roms/Game (USA).bin"},
+	"ngp/darwin/bare":                   {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mednafen_ngp_libretro.dylib /roms/Game (USA).bin"},
+	"ngp/darwin/flatpak":                {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mednafen_ngp_libretro.dylib /roms/Game (USA).bin"},
+	"ngp/darwin/installed":              {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mednafen_ngp_libretro.dylib /roms/Game (USA).bin"},
+	"ngp/linux/bare":                    {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mednafen_ngp_libretro.so"},
+	"ngp/linux/flatpak":                 {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mednafen_ngp_libretro.so"},
+	"ngp/linux/installed":               {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mednafen_ngp_libretro.so /roms/Game (USA).bin"},
+	"ngp/windows/bare":                  {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mednafen_ngp_libretro.dll /roms/Game (USA).bin"},
+	"ngp/windows/flatpak":               {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mednafen_ngp_libretro.dll /roms/Game (USA).bin"},
+	"ngp/windows/installed":             {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mednafen_ngp_libretro.dll /roms/Game (USA).bin"},
+	"ngpc/darwin/bare":                  {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mednafen_ngp_libretro.dylib /roms/Game (USA).bin"},
+	"ngpc/darwin/flatpak":               {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mednafen_ngp_libretro.dylib /roms/Game (USA).bin"},
+	"ngpc/darwin/installed":             {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mednafen_ngp_libretro.dylib /roms/Game (USA).bin"},
+	"ngpc/linux/bare":                   {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mednafen_ngp_libretro.so"},
+	"ngpc/linux/flatpak":                {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mednafen_ngp_libretro.so"},
+	"ngpc/linux/installed":              {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mednafen_ngp_libretro.so /roms/Game (USA).bin"},
+	"ngpc/windows/bare":                 {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mednafen_ngp_libretro.dll /roms/Game (USA).bin"},
+	"ngpc/windows/flatpak":              {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mednafen_ngp_libretro.dll /roms/Game (USA).bin"},
+	"ngpc/windows/installed":            {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mednafen_ngp_libretro.dll /roms/Game (USA).bin"},
+	"ps1/darwin/bare":                   {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/swanstation_libretro.dylib /roms/Game (USA).bin"},
+	"ps1/darwin/flatpak":                {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/swanstation_libretro.dylib /roms/Game (USA).bin"},
+	"ps1/darwin/installed":              {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/swanstation_libretro.dylib /roms/Game (USA).bin"},
+	"ps1/linux/bare":                    {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/swanstation_libretro.so"},
+	"ps1/linux/flatpak":                 {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/swanstation_libretro.so"},
+	"ps1/linux/installed":               {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/swanstation_libretro.so /roms/Game (USA).bin"},
+	"ps1/windows/bare":                  {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/swanstation_libretro.dll /roms/Game (USA).bin"},
+	"ps1/windows/flatpak":               {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/swanstation_libretro.dll /roms/Game (USA).bin"},
+	"ps1/windows/installed":             {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/swanstation_libretro.dll /roms/Game (USA).bin"},
+	"ps2/darwin/bare":                   {path: "/emubuddy/Emulators/PCSX2/PCSX2.app/Contents/MacOS/PCSX2-qt", dir: "/emubuddy/Emulators/PCSX2/PCSX2.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"ps2/darwin/flatpak":                {path: "/emubuddy/Emulators/PCSX2/PCSX2.app/Contents/MacOS/PCSX2-qt", dir: "/emubuddy/Emulators/PCSX2/PCSX2.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"ps2/darwin/installed":              {installed: true, path: "/emubuddy/Emulators/PCSX2/PCSX2-v2.4.0.app/Contents/MacOS/PCSX2-qt", dir: "/emubuddy/Emulators/PCSX2/PCSX2-v2.4.0.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"ps2/linux/bare":                    {path: "/emubuddy/Emulators/PCSX2/pcsx2.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"ps2/linux/flatpak":                 {path: "/emubuddy/Emulators/PCSX2/pcsx2.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"ps2/linux/installed":               {installed: true, path: "/emubuddy/Emulators/PCSX2/pcsx2-v2.4.0-linux-appimage-x64-Qt.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"ps2/windows/bare":                  {path: "/emubuddy/Emulators/PCSX2/pcsx2-qt.exe", dir: "/emubuddy/Emulators/PCSX2", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"ps2/windows/flatpak":               {path: "/emubuddy/Emulators/PCSX2/pcsx2-qt.exe", dir: "/emubuddy/Emulators/PCSX2", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"ps2/windows/installed":             {installed: true, path: "/emubuddy/Emulators/PCSX2/pcsx2-qt.exe", dir: "/emubuddy/Emulators/PCSX2", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"psp/darwin/bare":                   {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/ppsspp_libretro.dylib /roms/Game (USA).bin"},
+	"psp/darwin/flatpak":                {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/ppsspp_libretro.dylib /roms/Game (USA).bin"},
+	"psp/darwin/installed":              {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/ppsspp_libretro.dylib /roms/Game (USA).bin"},
+	"psp/linux/bare":                    {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/ppsspp_libretro.so"},
+	"psp/linux/flatpak":                 {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/ppsspp_libretro.so"},
+	"psp/linux/installed":               {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/ppsspp_libretro.so /roms/Game (USA).bin"},
+	"psp/standalone/darwin/bare":        {path: "/emubuddy/Emulators/PPSSPP/PPSSPP.app/Contents/MacOS/PPSSPP", dir: "/emubuddy/Emulators/PPSSPP/PPSSPP.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"psp/standalone/darwin/flatpak":     {path: "/emubuddy/Emulators/PPSSPP/PPSSPP.app/Contents/MacOS/PPSSPP", dir: "/emubuddy/Emulators/PPSSPP/PPSSPP.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"psp/standalone/darwin/installed":   {installed: true, path: "/emubuddy/Emulators/PPSSPP/PPSSPP.app/Contents/MacOS/PPSSPP", dir: "/emubuddy/Emulators/PPSSPP/PPSSPP.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"psp/standalone/linux/bare":         {path: "/emubuddy/Emulators/PPSSPP/ppsspp.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"psp/standalone/linux/flatpak":      {path: "/emubuddy/Emulators/PPSSPP/ppsspp.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"psp/standalone/linux/installed":    {installed: true, path: "/emubuddy/Emulators/PPSSPP/PPSSPP-v1.19.3-anylinux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"psp/standalone/windows/bare":       {path: "/emubuddy/Emulators/PPSSPP/PPSSPPWindows64.exe", dir: "/emubuddy/Emulators/PPSSPP", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"psp/standalone/windows/flatpak":    {path: "/emubuddy/Emulators/PPSSPP/PPSSPPWindows64.exe", dir: "/emubuddy/Emulators/PPSSPP", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"psp/standalone/windows/installed":  {installed: true, path: "/emubuddy/Emulators/PPSSPP/PPSSPPWindows64.exe", dir: "/emubuddy/Emulators/PPSSPP", args: "--dirs=/roms,/emubuddy,/bios,/saves /roms/Game (USA).bin"},
+	"psp/windows/bare":                  {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/ppsspp_libretro.dll /roms/Game (USA).bin"},
+	"psp/windows/flatpak":               {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/ppsspp_libretro.dll /roms/Game (USA).bin"},
+	"psp/windows/installed":             {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/ppsspp_libretro.dll /roms/Game (USA).bin"},
+	"saturn/darwin/bare":                {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mednafen_saturn_libretro.dylib /roms/Game (USA).bin"},
+	"saturn/darwin/flatpak":             {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mednafen_saturn_libretro.dylib /roms/Game (USA).bin"},
+	"saturn/darwin/installed":           {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mednafen_saturn_libretro.dylib /roms/Game (USA).bin"},
+	"saturn/linux/bare":                 {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mednafen_saturn_libretro.so"},
+	"saturn/linux/flatpak":              {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mednafen_saturn_libretro.so"},
+	"saturn/linux/installed":            {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mednafen_saturn_libretro.so /roms/Game (USA).bin"},
+	"saturn/windows/bare":               {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mednafen_saturn_libretro.dll /roms/Game (USA).bin"},
+	"saturn/windows/flatpak":            {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mednafen_saturn_libretro.dll /roms/Game (USA).bin"},
+	"saturn/windows/installed":          {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mednafen_saturn_libretro.dll /roms/Game (USA).bin"},
+	"sms/darwin/bare":                   {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/genesis_plus_gx_libretro.dylib /roms/Game (USA).bin"},
+	"sms/darwin/flatpak":                {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/genesis_plus_gx_libretro.dylib /roms/Game (USA).bin"},
+	"sms/darwin/installed":              {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/genesis_plus_gx_libretro.dylib /roms/Game (USA).bin"},
+	"sms/linux/bare":                    {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/genesis_plus_gx_libretro.so"},
+	"sms/linux/flatpak":                 {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/genesis_plus_gx_libretro.so"},
+	"sms/linux/installed":               {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/genesis_plus_gx_libretro.so /roms/Game (USA).bin"},
+	"sms/windows/bare":                  {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/genesis_plus_gx_libretro.dll /roms/Game (USA).bin"},
+	"sms/windows/flatpak":               {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/genesis_plus_gx_libretro.dll /roms/Game (USA).bin"},
+	"sms/windows/installed":             {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/genesis_plus_gx_libretro.dll /roms/Game (USA).bin"},
+	"snes/darwin/bare":                  {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/snes9x_libretro.dylib /roms/Game (USA).bin"},
+	"snes/darwin/flatpak":               {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/snes9x_libretro.dylib /roms/Game (USA).bin"},
+	"snes/darwin/installed":             {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/snes9x_libretro.dylib /roms/Game (USA).bin"},
+	"snes/linux/bare":                   {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/snes9x_libretro.so"},
+	"snes/linux/flatpak":                {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/snes9x_libretro.so"},
+	"snes/linux/installed":              {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/snes9x_libretro.so /roms/Game (USA).bin"},
+	"snes/windows/bare":                 {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/snes9x_libretro.dll /roms/Game (USA).bin"},
+	"snes/windows/flatpak":              {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/snes9x_libretro.dll /roms/Game (USA).bin"},
+	"snes/windows/installed":            {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/snes9x_libretro.dll /roms/Game (USA).bin"},
+	"tg16/darwin/bare":                  {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mednafen_pce_fast_libretro.dylib /roms/Game (USA).bin"},
+	"tg16/darwin/flatpak":               {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mednafen_pce_fast_libretro.dylib /roms/Game (USA).bin"},
+	"tg16/darwin/installed":             {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mednafen_pce_fast_libretro.dylib /roms/Game (USA).bin"},
+	"tg16/linux/bare":                   {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mednafen_pce_fast_libretro.so"},
+	"tg16/linux/flatpak":                {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mednafen_pce_fast_libretro.so"},
+	"tg16/linux/installed":              {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mednafen_pce_fast_libretro.so /roms/Game (USA).bin"},
+	"tg16/windows/bare":                 {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mednafen_pce_fast_libretro.dll /roms/Game (USA).bin"},
+	"tg16/windows/flatpak":              {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mednafen_pce_fast_libretro.dll /roms/Game (USA).bin"},
+	"tg16/windows/installed":            {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mednafen_pce_fast_libretro.dll /roms/Game (USA).bin"},
+	"virtualboy/darwin/bare":            {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mednafen_vb_libretro.dylib /roms/Game (USA).bin"},
+	"virtualboy/darwin/flatpak":         {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mednafen_vb_libretro.dylib /roms/Game (USA).bin"},
+	"virtualboy/darwin/installed":       {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mednafen_vb_libretro.dylib /roms/Game (USA).bin"},
+	"virtualboy/linux/bare":             {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mednafen_vb_libretro.so"},
+	"virtualboy/linux/flatpak":          {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mednafen_vb_libretro.so"},
+	"virtualboy/linux/installed":        {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mednafen_vb_libretro.so /roms/Game (USA).bin"},
+	"virtualboy/windows/bare":           {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mednafen_vb_libretro.dll /roms/Game (USA).bin"},
+	"virtualboy/windows/flatpak":        {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mednafen_vb_libretro.dll /roms/Game (USA).bin"},
+	"virtualboy/windows/installed":      {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mednafen_vb_libretro.dll /roms/Game (USA).bin"},
+	"wii/darwin/bare":                   {path: "/emubuddy/Emulators/Dolphin/Dolphin.app/Contents/MacOS/Dolphin", dir: "/emubuddy/Emulators/Dolphin/Dolphin.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -e /roms/Game (USA).bin"},
+	"wii/darwin/flatpak":                {path: "/emubuddy/Emulators/Dolphin/Dolphin.app/Contents/MacOS/Dolphin", dir: "/emubuddy/Emulators/Dolphin/Dolphin.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -e /roms/Game (USA).bin"},
+	"wii/darwin/installed":              {installed: true, path: "/emubuddy/Emulators/Dolphin/Dolphin.app/Contents/MacOS/Dolphin", dir: "/emubuddy/Emulators/Dolphin/Dolphin.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -e /roms/Game (USA).bin"},
+	"wii/linux/bare":                    {path: "flatpak", dir: "", args: "run org.DolphinEmu.dolphin-emu --dirs=/roms,/emubuddy,/bios,/saves -e /roms/Game (USA).bin"},
+	"wii/linux/flatpak":                 {installed: true, path: "/usr/bin/flatpak", dir: "", args: "run org.DolphinEmu.dolphin-emu --dirs=/roms,/emubuddy,/bios,/saves -e /roms/Game (USA).bin"},
+	"wii/linux/installed":               {installed: true, path: "/emubuddy/Emulators/Dolphin/Dolphin-2503-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -e /roms/Game (USA).bin"},
+	"wii/windows/bare":                  {path: "/emubuddy/Emulators/Dolphin/Dolphin-x64/Dolphin.exe", dir: "/emubuddy/Emulators/Dolphin/Dolphin-x64", args: "--dirs=/roms,/emubuddy,/bios,/saves -e /roms/Game (USA).bin"},
+	"wii/windows/flatpak":               {path: "/emubuddy/Emulators/Dolphin/Dolphin-x64/Dolphin.exe", dir: "/emubuddy/Emulators/Dolphin/Dolphin-x64", args: "--dirs=/roms,/emubuddy,/bios,/saves -e /roms/Game (USA).bin"},
+	"wii/windows/installed":             {installed: true, path: "/emubuddy/Emulators/Dolphin/Dolphin-x64/Dolphin.exe", dir: "/emubuddy/Emulators/Dolphin/Dolphin-x64", args: "--dirs=/roms,/emubuddy,/bios,/saves -e /roms/Game (USA).bin"},
+	"wiiu/darwin/bare":                  {path: "/emubuddy/Emulators/Cemu/Cemu.exe", dir: "/emubuddy/Emulators/Cemu", args: "--dirs=/roms,/emubuddy,/bios,/saves -g /roms/Game (USA).bin"},
+	"wiiu/darwin/flatpak":               {path: "/emubuddy/Emulators/Cemu/Cemu.exe", dir: "/emubuddy/Emulators/Cemu", args: "--dirs=/roms,/emubuddy,/bios,/saves -g /roms/Game (USA).bin"},
+	"wiiu/darwin/installed":             {installed: true, path: "/emubuddy/Emulators/Cemu/Cemu.exe", dir: "/emubuddy/Emulators/Cemu", args: "--dirs=/roms,/emubuddy,/bios,/saves -g /roms/Game (USA).bin"},
+	"wiiu/linux/bare":                   {path: "/emubuddy/Emulators/Cemu/Cemu.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -g /roms/Game (USA).bin"},
+	"wiiu/linux/flatpak":                {path: "/emubuddy/Emulators/Cemu/Cemu.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -g /roms/Game (USA).bin"},
+	"wiiu/linux/installed":              {installed: true, path: "/emubuddy/Emulators/Cemu/Cemu-2.6-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -g /roms/Game (USA).bin"},
+	"wiiu/windows/bare":                 {path: "/emubuddy/Emulators/Cemu/Cemu.exe", dir: "/emubuddy/Emulators/Cemu", args: "--dirs=/roms,/emubuddy,/bios,/saves -g /roms/Game (USA).bin"},
+	"wiiu/windows/flatpak":              {path: "/emubuddy/Emulators/Cemu/Cemu.exe", dir: "/emubuddy/Emulators/Cemu", args: "--dirs=/roms,/emubuddy,/bios,/saves -g /roms/Game (USA).bin"},
+	"wiiu/windows/installed":            {installed: true, path: "/emubuddy/Emulators/Cemu/Cemu.exe", dir: "/emubuddy/Emulators/Cemu", args: "--dirs=/roms,/emubuddy,/bios,/saves -g /roms/Game (USA).bin"},
+	"wonderswan/darwin/bare":            {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mednafen_wswan_libretro.dylib /roms/Game (USA).bin"},
+	"wonderswan/darwin/flatpak":         {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mednafen_wswan_libretro.dylib /roms/Game (USA).bin"},
+	"wonderswan/darwin/installed":       {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mednafen_wswan_libretro.dylib /roms/Game (USA).bin"},
+	"wonderswan/linux/bare":             {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mednafen_wswan_libretro.so"},
+	"wonderswan/linux/flatpak":          {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mednafen_wswan_libretro.so"},
+	"wonderswan/linux/installed":        {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mednafen_wswan_libretro.so /roms/Game (USA).bin"},
+	"wonderswan/windows/bare":           {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mednafen_wswan_libretro.dll /roms/Game (USA).bin"},
+	"wonderswan/windows/flatpak":        {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mednafen_wswan_libretro.dll /roms/Game (USA).bin"},
+	"wonderswan/windows/installed":      {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mednafen_wswan_libretro.dll /roms/Game (USA).bin"},
+	"wonderswancolor/darwin/bare":       {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mednafen_wswan_libretro.dylib /roms/Game (USA).bin"},
+	"wonderswancolor/darwin/flatpak":    {path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mednafen_wswan_libretro.dylib /roms/Game (USA).bin"},
+	"wonderswancolor/darwin/installed":  {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", dir: "/emubuddy/Emulators/RetroArch/RetroArch.app/Contents/MacOS", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /home/player/Library/Application Support/RetroArch/cores/mednafen_wswan_libretro.dylib /roms/Game (USA).bin"},
+	"wonderswancolor/linux/bare":        {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mednafen_wswan_libretro.so"},
+	"wonderswancolor/linux/flatpak":     {err: "core not found: /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mednafen_wswan_libretro.so"},
+	"wonderswancolor/linux/installed":   {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage", dir: "/emubuddy", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Linux-x86_64/cores/mednafen_wswan_libretro.so /roms/Game (USA).bin"},
+	"wonderswancolor/windows/bare":      {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mednafen_wswan_libretro.dll /roms/Game (USA).bin"},
+	"wonderswancolor/windows/flatpak":   {path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mednafen_wswan_libretro.dll /roms/Game (USA).bin"},
+	"wonderswancolor/windows/installed": {installed: true, path: "/emubuddy/Emulators/RetroArch/RetroArch-Win64/retroarch.exe", dir: "/emubuddy/Emulators/RetroArch/RetroArch-Win64", args: "--dirs=/roms,/emubuddy,/bios,/saves -L /emubuddy/Emulators/RetroArch/RetroArch-Win64/cores/mednafen_wswan_libretro.dll /roms/Game (USA).bin"},
+}
+
+func TestLaunchEverySystem(t *testing.T) {
+	checked := make(map[string]bool)
+	for _, sys := range loadTestSystems(t) {
+		for _, l := range testLaunches(sys) {
+			for _, goos := range []string{"linux", "darwin", "windows"} {
+				for _, machine := range []string{machineBare, machineFlatpak, machineInstalled} {
+					key := l.key + "/" + goos + "/" + machine
+					checked[key] = true
+					t.Run(key, func(t *testing.T) {
+						want, ok := goldenCommands[key]
+						if !ok {
+							t.Fatal("not in goldenCommands")
+						}
+						checkLaunch(t, goos, machine, l, want)
+					})
+				}
+			}
+		}
+	}
+	for key := range goldenCommands {
+		if !checked[key] {
+			t.Errorf("goldenCommands has %s, which isn't a launch in systems.json", key)
+		}
+	}
+}
+
+func checkLaunch(t *testing.T, goos, machine string, l testLaunch, want goldenCommand) {
+	fs := newMachine(goos, machine)
+	env := newTestEnv(goos, fs)
+	if got := env.Installed(l.emu.location()); got != want.installed {
+		t.Errorf("Installed: got %v, want %v", got, want.installed)
+	}
+
+	spec := LaunchSpec{
+		Emulator:  l.emu.location(),
+		Args:      l.args,
+		ExtraArgs: []string{"--dirs={romDir},{baseDir},{biosDir},{saveDir}"},
+		ROM:       goldenROM,
+		BiosDir:   goldenBiosDir,
+		SaveDir:   goldenSaveDir,
+		Env:       map[string]string{"EMUBUDDY_SAVES": "{saveDir}"},
+	}
+	if l.core != nil {
+		spec.Core = env.CorePath(*l.core)
+	}
+	cmd, err := env.Command(spec)
+	if want.err != "" {
+		var notFound *CoreNotFoundError
+		if !errors.As(err, &notFound) || err.Error() != want.err {
+			t.Fatalf("Command: got %v, want %s", err, want.err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("Command: %v", err)
+	}
+
+	// cmd.Path of a bare "flatpak" depends on the PATH of the machine running the test
+	if cmd.Args[0] != want.path || (strings.HasPrefix(want.path, "/") && cmd.Path != want.path) {
+		t.Errorf("Command runs %s (%s), want %s", cmd.Path, cmd.Args[0], want.path)
+	}
+	if got := strings.Join(cmd.Args[1:], " "); got != want.args {
+		t.Errorf("Command args:\n\tgot  %s\n\twant %s", got, want.args)
+	}
+	if cmd.Dir != want.dir {
+		t.Errorf("Command dir: got %q, want %q", cmd.Dir, want.dir)
+	}
+	if appImage := strings.HasSuffix(want.path, ".AppImage"); appImage != (len(fs.chmoded) == 1 && fs.chmoded[0] == want.path) {
+		t.Errorf("Command made %v executable", fs.chmoded)
+	}
+
+	environ := strings.Join(cmd.Env, "\n")
+	if !strings.Contains(environ, "EMUBUDDY_SAVES="+goldenSaveDir) {
+		t.Errorf("Command environment has no EMUBUDDY_SAVES: %q", cmd.Env)
+	}
+	if (goos == "linux") != strings.Contains(environ, "SDL_VIDEODRIVER=x11") {
+		t.Errorf("Command environment on %s: %q", goos, cmd.Env)
+	}
+}
+
+func TestCommandAppendsROM(t *testing.T) {
+	emu := Emulator{Path: "Emulators/melonDS/melonDS.exe"}
+	env := newTestEnv("windows", &fakeFS{})
+	rom := base("roms/ds/Game.nds")
+
+	cmd, err := env.Command(LaunchSpec{Emulator: emu, Args: []string{"-f"}, ROM: rom})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(cmd.Args[1:], " "); got != "-f "+rom {
+		t.Errorf("without {rom}: got %q", got)
+	}
+
+	cmd, err = env.Command(LaunchSpec{Emulator: emu, Args: []string{"--rom={rom}", "-f"}, ROM: rom})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(cmd.Args[1:], " "); got != "--rom="+rom+" -f" {
+		t.Errorf("with {rom}: got %q", got)
+	}
+}
+
+func TestCommandOldCoreArgument(t *testing.T) {
+	// Arguments from before {core}, such as "-L cores/x.so", are resolved
+	// against the emulator and must exist on Linux
+	emu := Emulator{
+		Path:      "Emulators/RetroArch/RetroArch-Win64/retroarch.exe",
+		Platforms: map[string]PlatformPath{"linux": {Path: "Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage"}},
+	}
+	spec := LaunchSpec{Emulator: emu, Args: []string{"-L", "cores/snes9x_libretro.so"}, ROM: base("roms/snes/Game.sfc")}
+	core := base("Emulators/RetroArch/RetroArch-Linux-x86_64/cores/snes9x_libretro.so")
+
+	var notFound *CoreNotFoundError
+	if _, err := newTestEnv("linux", &fakeFS{}).Command(spec); !errors.As(err, &notFound) || notFound.Path != core {
+		t.Errorf("without core: got %v", err)
+	}
+
+	cmd, err := newTestEnv("linux", &fakeFS{files: map[string]string{core: ""}}).Command(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(cmd.Args[1:], " "); got != "-L "+core+" "+spec.ROM {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestCommandExtraArgsUnchanged(t *testing.T) {
+	// The user's own arguments are not systems.json paths: they keep their
+	// slashes and aren't looked for as cores
+	emu := Emulator{
+		Path:      "Emulators/RetroArch/RetroArch-Win64/retroarch.exe",
+		Platforms: map[string]PlatformPath{"linux": {Path: "Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage"}},
+	}
+	spec := LaunchSpec{
+		Emulator:  emu,
+		ExtraArgs: []string{"--config=/home/me/x.cfg", "--shader", "shaders/crt.slangp", "--preload", "libhook.so", "--saves={saveDir}"},
+		ROM:       base("roms/snes/Game.sfc"),
+		SaveDir:   base("saves/snes"),
+	}
+	cmd, err := newTestEnv("linux", &fakeFS{}).Command(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--config=/home/me/x.cfg --shader shaders/crt.slangp --preload libhook.so --saves=" + spec.SaveDir + " " + spec.ROM
+	if got := strings.Join(cmd.Args[1:], " "); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
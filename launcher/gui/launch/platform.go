@@ -0,0 +1,152 @@
+package launch
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Platform-specific emulator locations
+//
+// The "path" of an emulator in systems.json is its Windows location. Other
+// operating systems are described by the emulator's "platforms" block, keyed
+// by GOOS:
+//
+//	"platforms": {
+//	  "linux":  {"glob": "Emulators/PCSX2/*.AppImage", "path": "Emulators/PCSX2/pcsx2.AppImage", "command": "pcsx2-qt"},
+//	  "darwin": {"glob": "Emulators/PCSX2/PCSX2*.app/Contents/MacOS/PCSX2-qt"}
+//	}
+//
+// Candidates are tried in order: glob, path (if it exists), flatpak (if
+// flatpak is installed), command on the PATH. If none is found the path, or
+// failing that the Windows path, is returned so the error names a file.
+
+// PlatformPath describes where an emulator lives on one operating system.
+// Relative paths are relative to the EmuBuddy folder; "~/" is the home folder.
+type PlatformPath struct {
+	Path     string `json:"path,omitempty"`
+	Glob     string `json:"glob,omitempty"`     // e.g. an AppImage with a version in its name; the last match in sorted order wins
+	Flatpak  string `json:"flatpak,omitempty"`  // Flatpak app ID, run with "flatpak run <id>"
+	Command  string `json:"command,omitempty"`  // Executable name looked up on the PATH
+	CoresDir string `json:"coresDir,omitempty"` // Where RetroArch cores are, if not next to the executable
+}
+
+// Emulator is the location of an emulator as configured in systems.json
+type Emulator struct {
+	Path      string                  // Windows path
+	Platforms map[string]PlatformPath // Keyed by GOOS
+}
+
+// Resolved is an emulator located on this machine
+type Resolved struct {
+	Path     string   // Executable to run
+	Args     []string // Arguments that go before the emulator's own, e.g. "run <id>" for flatpak
+	Dir      string   // Folder of the executable; relative core paths are resolved against it
+	CoresDir string
+	Flatpak  bool
+}
+
+// abs turns a systems.json path into an absolute one
+func (e *Env) abs(path string) string {
+	path = filepath.FromSlash(strings.ReplaceAll(path, "\\", "/"))
+	if strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return filepath.Join(e.HomeDir, path[2:])
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(e.BaseDir, path)
+}
+
+// Resolve finds the executable of an emulator for this operating system
+func (e *Env) Resolve(emu Emulator) Resolved {
+	plat, ok := emu.Platforms[e.GOOS]
+	if !ok {
+		plat = PlatformPath{Path: emu.Path}
+	}
+	found := func(path string) Resolved {
+		return Resolved{Path: path, Dir: filepath.Dir(path), CoresDir: plat.CoresDir}
+	}
+
+	if plat.Glob != "" {
+		if matches, _ := e.Glob(e.abs(plat.Glob)); len(matches) > 0 {
+			sort.Strings(matches)
+			return found(matches[len(matches)-1]) // Highest version when names differ only by version
+		}
+	}
+	if plat.Path != "" && e.Exists(e.abs(plat.Path)) {
+		return found(e.abs(plat.Path))
+	}
+	flatpak := Resolved{Path: "flatpak", Args: []string{"run", plat.Flatpak}, Flatpak: true, CoresDir: plat.CoresDir}
+	if plat.Flatpak != "" {
+		if path, err := e.LookPath("flatpak"); err == nil {
+			flatpak.Path = path
+			return flatpak
+		}
+	}
+	if plat.Command != "" {
+		if path, err := e.LookPath(plat.Command); err == nil {
+			return found(path)
+		}
+	}
+
+	// Nothing found: return the most specific guess so the error makes sense
+	switch {
+	case plat.Path != "":
+		return found(e.abs(plat.Path))
+	case plat.Flatpak != "":
+		return flatpak
+	}
+	return found(e.abs(emu.Path))
+}
+
+// Installed reports whether an emulator is found on this machine. Flatpak
+// apps count as installed when flatpak is.
+func (e *Env) Installed(emu Emulator) bool {
+	r := e.Resolve(emu)
+	if r.Flatpak {
+		return r.Path != "flatpak"
+	}
+	return e.Exists(r.Path)
+}
+
+// ResolveArg resolves a path argument from systems.json, such as a RetroArch
+// core, for the located emulator. Arguments that aren't paths are unchanged.
+func (e *Env) ResolveArg(emu Resolved, arg string) string {
+	if !strings.Contains(arg, "/") && !strings.Contains(arg, "\\") {
+		return arg
+	}
+	slashed := strings.ReplaceAll(arg, "\\", "/")
+	if emu.CoresDir != "" && strings.HasPrefix(slashed, "cores/") {
+		return filepath.Join(e.abs(emu.CoresDir), filepath.Base(slashed))
+	}
+	if strings.HasPrefix(slashed, "~/") || filepath.IsAbs(arg) {
+		return e.abs(arg)
+	}
+	return filepath.Join(emu.Dir, filepath.FromSlash(slashed))
+}
+
+// Core is a RetroArch core as configured in systems.json
+type Core struct {
+	Dll   string // Windows core, e.g. "cores/nestopia_libretro.dll"
+	So    string // Linux core, if not named like the .dll
+	Dylib string // macOS core, if not named like the .dll
+}
+
+// CorePath returns the core file for this operating system. Unless the core
+// names one, the .dll becomes a .so on Linux and a .dylib on macOS.
+func (e *Env) CorePath(core Core) string {
+	switch e.GOOS {
+	case "linux":
+		if core.So != "" {
+			return core.So
+		}
+		return strings.TrimSuffix(core.Dll, ".dll") + ".so"
+	case "darwin":
+		if core.Dylib != "" {
+			return core.Dylib
+		}
+		return strings.TrimSuffix(core.Dll, ".dll") + ".dylib"
+	}
+	return core.Dll
+}
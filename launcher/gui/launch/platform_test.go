@@ -0,0 +1,392 @@
+package launch
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const (
+	testBaseDir = "/emubuddy"
+	testHomeDir = "/home/player"
+)
+
+// fakeFS is an in-memory machine for Env: files with their contents and the
+// executables on the PATH
+type fakeFS struct {
+	files   map[string]string
+	path    map[string]string // Executable name -> full path
+	chmoded []string
+}
+
+// newTestEnv returns an Env for goos over fs. Relative file names are in the
+// EmuBuddy folder.
+func newTestEnv(goos string, fs *fakeFS) *Env {
+	if fs.files == nil {
+		fs.files = make(map[string]string)
+	}
+	for name, data := range fs.files {
+		if !filepath.IsAbs(name) {
+			delete(fs.files, name)
+			fs.files[filepath.Join(testBaseDir, filepath.FromSlash(name))] = data
+		}
+	}
+	for _, path := range fs.path {
+		fs.files[path] = ""
+	}
+	isDir := func(path string) bool {
+		for name := range fs.files {
+			if strings.HasPrefix(name, path+string(filepath.Separator)) {
+				return true
+			}
+		}
+		return false
+	}
+	return &Env{
+		GOOS:    goos,
+		BaseDir: testBaseDir,
+		HomeDir: testHomeDir,
+		Exists: func(path string) bool {
+			_, ok := fs.files[path]
+			return ok || isDir(path)
+		},
+		Glob: func(pattern string) ([]string, error) {
+			var matches []string
+			for name := range fs.files {
+				if ok, err := filepath.Match(pattern, name); err != nil {
+					return nil, err
+				} else if ok {
+					matches = append(matches, name)
+				}
+			}
+			// Backwards, so callers can't rely on the order
+			sort.Sort(sort.Reverse(sort.StringSlice(matches)))
+			return matches, nil
+		},
+		ReadDir: func(dir string) ([]string, error) {
+			seen := make(map[string]bool)
+			var names []string
+			for name := range fs.files {
+				rel, err := filepath.Rel(dir, name)
+				if err != nil || strings.HasPrefix(rel, "..") {
+					continue
+				}
+				first := strings.Split(rel, string(filepath.Separator))[0]
+				if !seen[first] {
+					seen[first] = true
+					names = append(names, first)
+				}
+			}
+			if len(names) == 0 {
+				return nil, os.ErrNotExist
+			}
+			sort.Strings(names)
+			return names, nil
+		},
+		ReadFile: func(path string) ([]byte, error) {
+			data, ok := fs.files[path]
+			if !ok {
+				return nil, os.ErrNotExist
+			}
+			return []byte(data), nil
+		},
+		LookPath: func(file string) (string, error) {
+			if path, ok := fs.path[file]; ok {
+				return path, nil
+			}
+			return "", errors.New("executable file not found in $PATH")
+		},
+		Chmod: func(path string, mode os.FileMode) error {
+			fs.chmoded = append(fs.chmoded, path)
+			return nil
+		},
+		Environ: func() []string { return []string{"HOME=" + testHomeDir} },
+	}
+}
+
+// testEmulator and testSystem are the parts of systems.json the tests use
+type testEmulator struct {
+	Path  string
+	Args  []string
+	Cores []struct {
+		Name, Dll, So, Dylib string
+	}
+	Platforms map[string]PlatformPath
+}
+
+type testSystem struct {
+	ID                 string
+	Emulator           testEmulator
+	StandaloneEmulator *testEmulator
+	NeedsExtract       bool
+}
+
+// loadTestSystems reads the systems.json shipped with EmuBuddy
+func loadTestSystems(t *testing.T) []testSystem {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "..", "systems.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config struct {
+		Systems []testSystem
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Systems) == 0 {
+		t.Fatal("no systems in systems.json")
+	}
+	return config.Systems
+}
+
+// emulators returns the emulators of a system
+func (s testSystem) emulators() []testEmulator {
+	emus := []testEmulator{s.Emulator}
+	if s.StandaloneEmulator != nil {
+		emus = append(emus, *s.StandaloneEmulator)
+	}
+	return emus
+}
+
+func (emu testEmulator) location() Emulator {
+	return Emulator{Path: emu.Path, Platforms: emu.Platforms}
+}
+
+func base(path string) string {
+	return filepath.Join(testBaseDir, filepath.FromSlash(path))
+}
+
+// oldPaths is where the launcher looked for each emulator before the
+// locations moved into systems.json: the folder searched for an AppImage
+// and the file used when there was none on Linux, and the file on macOS
+var oldPaths = map[string]struct {
+	linuxDir, linux, darwin string
+}{
+	"Emulators/RetroArch/RetroArch-Win64/retroarch.exe": {
+		"Emulators/RetroArch/RetroArch-Linux-x86_64", "Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage",
+		"Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch",
+	},
+	"Emulators/PCSX2/pcsx2-qt.exe": {
+		"Emulators/PCSX2", "Emulators/PCSX2/pcsx2.AppImage",
+		"Emulators/PCSX2/PCSX2.app/Contents/MacOS/PCSX2-qt",
+	},
+	"Emulators/PPSSPP/PPSSPPWindows64.exe": {
+		"Emulators/PPSSPP", "Emulators/PPSSPP/ppsspp.AppImage",
+		"Emulators/PPSSPP/PPSSPP.app/Contents/MacOS/PPSSPP",
+	},
+	"Emulators/mGBA/mGBA-0.10.5-win64/mGBA.exe": {
+		"Emulators/mGBA", "Emulators/mGBA/mgba.AppImage",
+		"Emulators/mGBA/mGBA.app/Contents/MacOS/mGBA",
+	},
+	"Emulators/melonDS/melonDS.exe": {
+		"Emulators/melonDS", "Emulators/melonDS/melonDS.AppImage",
+		"Emulators/melonDS/melonDS.app/Contents/MacOS/melonDS",
+	},
+	"Emulators/Azahar/azahar.exe": {
+		"Emulators/Azahar", "Emulators/Azahar/azahar.AppImage",
+		"Emulators/Azahar/azahar.app/Contents/MacOS/azahar",
+	},
+	"Emulators/Cemu/Cemu.exe": {
+		"Emulators/Cemu", "Emulators/Cemu/Cemu.AppImage",
+		"Emulators/Cemu/Cemu.exe", // No macOS build, the Windows path was kept
+	},
+	"Emulators/Dolphin/Dolphin-x64/Dolphin.exe": {
+		"Emulators/Dolphin", "", // Flatpak
+		"Emulators/Dolphin/Dolphin.app/Contents/MacOS/Dolphin",
+	},
+}
+
+func TestResolveMatchesOldPaths(t *testing.T) {
+	for _, sys := range loadTestSystems(t) {
+		for _, emu := range sys.emulators() {
+			old, ok := oldPaths[emu.Path]
+			if !ok {
+				t.Errorf("%s: no old path for %s", sys.ID, emu.Path)
+				continue
+			}
+
+			if got := newTestEnv("windows", &fakeFS{}).Resolve(emu.location()).Path; got != base(emu.Path) {
+				t.Errorf("%s on windows: got %s, want %s", sys.ID, got, base(emu.Path))
+			}
+			if got := newTestEnv("darwin", &fakeFS{}).Resolve(emu.location()).Path; got != base(old.darwin) {
+				t.Errorf("%s on darwin: got %s, want %s", sys.ID, got, base(old.darwin))
+			}
+
+			if old.linux != "" {
+				if got := newTestEnv("linux", &fakeFS{}).Resolve(emu.location()).Path; got != base(old.linux) {
+					t.Errorf("%s on linux without AppImage: got %s, want %s", sys.ID, got, base(old.linux))
+				}
+			}
+			// Any AppImage in the folder is used, whatever its name
+			appImage := old.linuxDir + "/Emulator-v1.2-x86_64.AppImage"
+			env := newTestEnv("linux", &fakeFS{files: map[string]string{appImage: ""}})
+			if got := env.Resolve(emu.location()).Path; got != base(appImage) {
+				t.Errorf("%s on linux with AppImage: got %s, want %s", sys.ID, got, base(appImage))
+			}
+		}
+	}
+}
+
+func TestResolveGlobOrder(t *testing.T) {
+	emu := Emulator{
+		Path: "Emulators/PCSX2/pcsx2-qt.exe",
+		Platforms: map[string]PlatformPath{
+			"linux":  {Glob: "Emulators/PCSX2/*.AppImage", Path: "Emulators/PCSX2/pcsx2.AppImage"},
+			"darwin": {Glob: "Emulators/PCSX2/PCSX2*.app/Contents/MacOS/PCSX2-qt", Path: "Emulators/PCSX2/PCSX2.app/Contents/MacOS/PCSX2-qt"},
+		},
+	}
+
+	tests := []struct {
+		goos  string
+		files []string
+		want  string
+	}{
+		{"linux", []string{"Emulators/PCSX2/pcsx2-v2.0.2.AppImage", "Emulators/PCSX2/pcsx2-v2.1.0.AppImage", "Emulators/PCSX2/pcsx2-v1.6.0.AppImage"}, "Emulators/PCSX2/pcsx2-v2.1.0.AppImage"},
+		{"linux", []string{"Emulators/PCSX2/pcsx2.AppImage", "Emulators/PCSX2/pcsx2-v2.0.2.AppImage"}, "Emulators/PCSX2/pcsx2.AppImage"},
+		{"linux", []string{"Emulators/PCSX2/readme.txt", "Emulators/PCSX2/bios/scph.AppImage"}, "Emulators/PCSX2/pcsx2.AppImage"},
+		{"darwin", []string{"Emulators/PCSX2/PCSX2-v2.0.2.app/Contents/MacOS/PCSX2-qt", "Emulators/PCSX2/PCSX2-v2.1.0.app/Contents/MacOS/PCSX2-qt"}, "Emulators/PCSX2/PCSX2-v2.1.0.app/Contents/MacOS/PCSX2-qt"},
+		{"darwin", nil, "Emulators/PCSX2/PCSX2.app/Contents/MacOS/PCSX2-qt"},
+	}
+	for _, tt := range tests {
+		fs := &fakeFS{files: make(map[string]string)}
+		for _, file := range tt.files {
+			fs.files[file] = ""
+		}
+		r := newTestEnv(tt.goos, fs).Resolve(emu)
+		if r.Path != base(tt.want) {
+			t.Errorf("%s %v: got %s, want %s", tt.goos, tt.files, r.Path, base(tt.want))
+		}
+		if r.Dir != filepath.Dir(r.Path) {
+			t.Errorf("%s %v: Dir is %s, want the executable's folder", tt.goos, tt.files, r.Dir)
+		}
+	}
+}
+
+func TestResolveDolphinFlatpak(t *testing.T) {
+	emu := Emulator{
+		Path: "Emulators/Dolphin/Dolphin-x64/Dolphin.exe",
+		Platforms: map[string]PlatformPath{
+			"linux": {Glob: "Emulators/Dolphin/*.AppImage", Flatpak: "org.DolphinEmu.dolphin-emu"},
+		},
+	}
+	onPath := map[string]string{"flatpak": "/usr/bin/flatpak"}
+
+	// An AppImage wins over flatpak
+	env := newTestEnv("linux", &fakeFS{files: map[string]string{"Emulators/Dolphin/Dolphin.AppImage": ""}, path: onPath})
+	if r := env.Resolve(emu); r.Flatpak || r.Path != base("Emulators/Dolphin/Dolphin.AppImage") {
+		t.Errorf("with AppImage: got %+v", r)
+	}
+
+	env = newTestEnv("linux", &fakeFS{path: onPath})
+	r := env.Resolve(emu)
+	if !r.Flatpak || r.Path != "/usr/bin/flatpak" || strings.Join(r.Args, " ") != "run org.DolphinEmu.dolphin-emu" {
+		t.Errorf("with flatpak: got %+v", r)
+	}
+	if !env.Installed(emu) {
+		t.Error("with flatpak: not installed")
+	}
+
+	// Without flatpak the error names it
+	env = newTestEnv("linux", &fakeFS{})
+	r = env.Resolve(emu)
+	if !r.Flatpak || r.Path != "flatpak" || strings.Join(r.Args, " ") != "run org.DolphinEmu.dolphin-emu" {
+		t.Errorf("without flatpak: got %+v", r)
+	}
+	if env.Installed(emu) {
+		t.Error("without flatpak: installed")
+	}
+}
+
+func TestResolveCommand(t *testing.T) {
+	emu := Emulator{
+		Path:      "Emulators/PCSX2/pcsx2-qt.exe",
+		Platforms: map[string]PlatformPath{"linux": {Path: "Emulators/PCSX2/pcsx2.AppImage", Command: "pcsx2-qt"}},
+	}
+	env := newTestEnv("linux", &fakeFS{path: map[string]string{"pcsx2-qt": "/usr/bin/pcsx2-qt"}})
+	if r := env.Resolve(emu); r.Path != "/usr/bin/pcsx2-qt" || r.Dir != "/usr/bin" {
+		t.Errorf("got %+v", r)
+	}
+	if !env.Installed(emu) {
+		t.Error("not installed")
+	}
+
+	env = newTestEnv("linux", &fakeFS{})
+	if r := env.Resolve(emu); r.Path != base("Emulators/PCSX2/pcsx2.AppImage") {
+		t.Errorf("without command: got %s", r.Path)
+	}
+	if env.Installed(emu) {
+		t.Error("without command: installed")
+	}
+}
+
+func TestResolveArg(t *testing.T) {
+	retroArch := Emulator{
+		Path: "Emulators/RetroArch/RetroArch-Win64/retroarch.exe",
+		Platforms: map[string]PlatformPath{
+			"darwin": {Path: "Emulators/RetroArch/RetroArch.app/Contents/MacOS/RetroArch", CoresDir: "~/Library/Application Support/RetroArch/cores"},
+			"linux":  {Glob: "Emulators/RetroArch/RetroArch-Linux-x86_64/*.AppImage", Path: "Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage"},
+		},
+	}
+
+	tests := []struct {
+		goos, arg, want string
+	}{
+		// Cores, as the old launcher found them
+		{"windows", "cores/nestopia_libretro.dll", base("Emulators/RetroArch/RetroArch-Win64/cores/nestopia_libretro.dll")},
+		{"windows", "cores\\nestopia_libretro.dll", base("Emulators/RetroArch/RetroArch-Win64/cores/nestopia_libretro.dll")},
+		{"linux", "cores/nestopia_libretro.so", base("Emulators/RetroArch/RetroArch-Linux-x86_64/cores/nestopia_libretro.so")},
+		{"darwin", "cores/nestopia_libretro.dylib", filepath.Join(testHomeDir, "Library", "Application Support", "RetroArch", "cores", "nestopia_libretro.dylib")},
+		// Other arguments
+		{"linux", "-L", "-L"},
+		{"linux", "--fullscreen", "--fullscreen"},
+		{"darwin", "~/Games/shaders/crt.slangp", filepath.Join(testHomeDir, "Games", "shaders", "crt.slangp")},
+		{"linux", "/opt/roms/game.nes", "/opt/roms/game.nes"},
+		{"darwin", "config/retroarch.cfg", base("Emulators/RetroArch/RetroArch.app/Contents/MacOS/config/retroarch.cfg")},
+	}
+	for _, tt := range tests {
+		env := newTestEnv(tt.goos, &fakeFS{})
+		if got := env.ResolveArg(env.Resolve(retroArch), tt.arg); got != tt.want {
+			t.Errorf("%s %q: got %s, want %s", tt.goos, tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestCorePath(t *testing.T) {
+	tests := []struct {
+		goos string
+		core Core
+		want string
+	}{
+		{"windows", Core{Dll: "cores/nestopia_libretro.dll"}, "cores/nestopia_libretro.dll"},
+		{"linux", Core{Dll: "cores/nestopia_libretro.dll"}, "cores/nestopia_libretro.so"},
+		{"darwin", Core{Dll: "cores/nestopia_libretro.dll"}, "cores/nestopia_libretro.dylib"},
+		{"linux", Core{Dll: "cores/mupen64plus_next_libretro.dll", So: "cores/mupen64plus_next_gles3_libretro.so"}, "cores/mupen64plus_next_gles3_libretro.so"},
+		{"darwin", Core{Dll: "cores/pcsx_rearmed_libretro.dll", So: "cores/other.so", Dylib: "cores/pcsx_rearmed_interpreter_libretro.dylib"}, "cores/pcsx_rearmed_interpreter_libretro.dylib"},
+		{"windows", Core{Dll: "cores/gpsp_libretro.dll", So: "cores/gpsp.so", Dylib: "cores/gpsp.dylib"}, "cores/gpsp_libretro.dll"},
+	}
+	for _, tt := range tests {
+		if got := newTestEnv(tt.goos, &fakeFS{}).CorePath(tt.core); got != tt.want {
+			t.Errorf("%s %+v: got %s, want %s", tt.goos, tt.core, got, tt.want)
+		}
+	}
+
+	// Every core in systems.json keeps its name on the other systems
+	for _, sys := range loadTestSystems(t) {
+		for _, emu := range sys.emulators() {
+			for _, core := range emu.Cores {
+				name := strings.TrimSuffix(filepath.Base(core.Dll), ".dll")
+				for goos, ext := range map[string]string{"linux": ".so", "darwin": ".dylib"} {
+					got := newTestEnv(goos, &fakeFS{}).CorePath(Core{Dll: core.Dll, So: core.So, Dylib: core.Dylib})
+					if core.So == "" && core.Dylib == "" && got != "cores/"+name+ext {
+						t.Errorf("%s %s on %s: got %s", sys.ID, core.Name, goos, got)
+					}
+				}
+			}
+		}
+	}
+}
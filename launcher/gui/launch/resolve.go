@@ -0,0 +1,147 @@
+package launch
+
+import (
+	"bufio"
+	"bytes"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Launch files of extracted games
+//
+// A disc game can extract to several files: a .cue with its .bin tracks, a
+// .gdi with its tracks, an .m3u listing the discs. The file to launch is
+// picked by extension, in the system's priority order. Sheets (.cue, .gdi,
+// .m3u) only count when every file they list is there, so a sheet left
+// without its tracks isn't launched. Among files of the same kind, the one
+// named like the game wins, then the first by name.
+
+// ResolveLaunchFile returns which of files, full paths, to launch for the game
+// called baseName (without its archive extension). It returns "" if none has
+// an extension from priority.
+func (e *Env) ResolveLaunchFile(files []string, baseName string, priority []string) string {
+	for _, ext := range priority {
+		var candidates []string
+		for _, file := range files {
+			if strings.EqualFold(filepath.Ext(file), ext) && e.sheetComplete(file) {
+				candidates = append(candidates, file)
+			}
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+		sort.Strings(candidates)
+		for _, file := range candidates {
+			name := filepath.Base(file)
+			if strings.EqualFold(strings.TrimSuffix(name, filepath.Ext(name)), baseName) {
+				return file
+			}
+		}
+		return candidates[0]
+	}
+	return ""
+}
+
+// sheetComplete reports whether the files a sheet lists exist. Files that
+// aren't sheets are complete.
+func (e *Env) sheetComplete(path string) bool {
+	var parse func([]byte) []string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".cue":
+		parse = cueFiles
+	case ".gdi":
+		parse = gdiFiles
+	case ".m3u":
+		parse = m3uFiles
+	default:
+		return true
+	}
+	if e.ReadFile == nil {
+		return true
+	}
+	data, err := e.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	files := parse(data)
+	if len(files) == 0 {
+		return false
+	}
+	dir := filepath.Dir(path)
+	for _, file := range files {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		if !e.Exists(file) {
+			return false
+		}
+	}
+	return true
+}
+
+// cueFiles returns the files of a cue sheet's FILE lines:
+//
+//	FILE "Game (Track 1).bin" BINARY
+func cueFiles(data []byte) []string {
+	var files []string
+	for _, line := range sheetLines(data) {
+		if len(line) < 5 || !strings.EqualFold(line[:5], "FILE ") {
+			continue
+		}
+		rest := strings.TrimSpace(line[5:])
+		if strings.HasPrefix(rest, "\"") {
+			if end := strings.Index(rest[1:], "\""); end >= 0 {
+				files = append(files, rest[1:end+1])
+			}
+		} else if fields := strings.Fields(rest); len(fields) > 0 {
+			files = append(files, fields[0])
+		}
+	}
+	return files
+}
+
+// gdiFiles returns the track files of a GD-ROM sheet. The first line is the
+// number of tracks, then one line per track:
+//
+//	1 0 4 2352 "track01.bin" 0
+func gdiFiles(data []byte) []string {
+	lines := sheetLines(data)
+	var files []string
+	for i, line := range lines {
+		if i == 0 {
+			continue
+		}
+		if start := strings.Index(line, "\""); start >= 0 {
+			if end := strings.Index(line[start+1:], "\""); end >= 0 {
+				files = append(files, line[start+1:start+1+end])
+			}
+		} else if fields := strings.Fields(line); len(fields) >= 5 {
+			files = append(files, fields[4])
+		}
+	}
+	return files
+}
+
+// m3uFiles returns the entries of a playlist, leaving out comments
+func m3uFiles(data []byte) []string {
+	var files []string
+	for _, line := range sheetLines(data) {
+		if !strings.HasPrefix(line, "#") {
+			files = append(files, filepath.FromSlash(line))
+		}
+	}
+	return files
+}
+
+// sheetLines returns the non-empty lines of a text file, trimmed
+func sheetLines(data []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,159 @@
+package launch
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Launch priorities of systems.json
+var (
+	ps1Priority       = []string{".m3u", ".chd", ".cue", ".pbp", ".bin"}
+	dreamcastPriority = []string{".m3u", ".chd", ".gdi", ".cdi", ".cue"}
+)
+
+func TestSheetFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func([]byte) []string
+		sheet string
+		want  []string
+	}{
+		{"cue, quoted", cueFiles, "FILE \"Game (USA) (Track 1).bin\" BINARY\n  TRACK 01 MODE2/2352\n    INDEX 01 00:00:00\nFILE \"Game (USA) (Track 2).bin\" BINARY\n  TRACK 02 AUDIO\n",
+			[]string{"Game (USA) (Track 1).bin", "Game (USA) (Track 2).bin"}},
+		{"cue, unquoted and lowercase", cueFiles, "REM COMMENT \"x.bin\"\nfile track01.bin BINARY\r\n  TRACK 01 MODE1/2352\r\n", []string{"track01.bin"}},
+		{"cue, no files", cueFiles, "REM only a comment\n", nil},
+		{"gdi, quoted", gdiFiles, "3\n1 0 4 2352 \"Game (Track 1).bin\" 0\n2 600 0 2352 \"Game (Track 2).raw\" 0\n3 45000 4 2352 \"Game (Track 3).bin\" 0\n",
+			[]string{"Game (Track 1).bin", "Game (Track 2).raw", "Game (Track 3).bin"}},
+		{"gdi, unquoted", gdiFiles, "2\r\n1 0 4 2352 track01.bin 0\r\n2 756 0 2352 track02.raw 0\r\n", []string{"track01.bin", "track02.raw"}},
+		{"m3u", m3uFiles, "#EXTM3U\nGame (Disc 1).cue\n\n# Second disc\nGame (Disc 2).cue\n", []string{"Game (Disc 1).cue", "Game (Disc 2).cue"}},
+		{"m3u, subfolder", m3uFiles, "discs/Game (Disc 1).chd\n", []string{filepath.Join("discs", "Game (Disc 1).chd")}},
+	}
+	for _, tt := range tests {
+		if got := tt.parse([]byte(tt.sheet)); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResolveLaunchFile(t *testing.T) {
+	cue := "FILE \"Game (USA) (Track 1).bin\" BINARY\nFILE \"Game (USA) (Track 2).bin\" BINARY\n"
+	gdi := "2\n1 0 4 2352 \"Game (USA) (Track 1).bin\" 0\n2 600 0 2352 \"Game (USA) (Track 2).raw\" 0\n"
+	m3u := "Game (USA) (Disc 1).cue\nGame (USA) (Disc 2).cue\n"
+	disc := func(n string) string { return "FILE \"Game (USA) (Disc " + n + ").bin\" BINARY\n" }
+
+	tests := []struct {
+		name     string
+		files    map[string]string // Extracted files and their contents
+		priority []string
+		want     string // "" if nothing can be launched
+	}{
+		{
+			"cue with its bins",
+			map[string]string{"Game (USA).cue": cue, "Game (USA) (Track 1).bin": "", "Game (USA) (Track 2).bin": ""},
+			ps1Priority, "Game (USA).cue",
+		},
+		{
+			"cue missing a bin",
+			map[string]string{"Game (USA).cue": cue, "Game (USA) (Track 1).bin": ""},
+			ps1Priority, "Game (USA) (Track 1).bin",
+		},
+		{
+			"cue without bins, nothing else",
+			map[string]string{"Game (USA).cue": cue},
+			ps1Priority, "",
+		},
+		{
+			"gdi with its tracks",
+			map[string]string{"Game (USA).gdi": gdi, "Game (USA) (Track 1).bin": "", "Game (USA) (Track 2).raw": "", "Game (USA).cue": cue},
+			dreamcastPriority, "Game (USA).gdi",
+		},
+		{
+			"gdi missing a track",
+			map[string]string{"Game (USA).gdi": gdi, "Game (USA) (Track 1).bin": ""},
+			dreamcastPriority, "",
+		},
+		{
+			"m3u with its discs",
+			map[string]string{
+				"Game (USA).m3u": m3u, "Game (USA) (Disc 1).cue": disc("1"), "Game (USA) (Disc 1).bin": "",
+				"Game (USA) (Disc 2).cue": disc("2"), "Game (USA) (Disc 2).bin": "",
+			},
+			ps1Priority, "Game (USA).m3u",
+		},
+		{
+			"m3u listing a missing disc",
+			map[string]string{"Game (USA).m3u": m3u, "Game (USA) (Disc 1).cue": disc("1"), "Game (USA) (Disc 1).bin": ""},
+			ps1Priority, "Game (USA) (Disc 1).cue",
+		},
+		{
+			"file named like the game first",
+			map[string]string{"Bonus Disc.cue": "FILE bonus.bin BINARY", "bonus.bin": "", "Game (USA).cue": cue, "Game (USA) (Track 1).bin": "", "Game (USA) (Track 2).bin": ""},
+			ps1Priority, "Game (USA).cue",
+		},
+		{
+			"otherwise the first by name",
+			map[string]string{"Game (Track 2).bin": "", "Game (Track 1).bin": ""},
+			ps1Priority, "Game (Track 1).bin",
+		},
+		{
+			"extension case",
+			map[string]string{"GAME (USA).CUE": cue, "Game (USA) (Track 1).bin": "", "Game (USA) (Track 2).bin": ""},
+			ps1Priority, "GAME (USA).CUE",
+		},
+		{
+			"nothing launchable",
+			map[string]string{"readme.txt": "", "Game (USA).sbi": ""},
+			ps1Priority, "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := &fakeFS{files: make(map[string]string)}
+			var files []string
+			for name, data := range tt.files {
+				path := base("roms/psx/" + name)
+				fs.files[path] = data
+				files = append(files, path)
+			}
+			want := ""
+			if tt.want != "" {
+				want = base("roms/psx/" + tt.want)
+			}
+			if got := newTestEnv("linux", fs).ResolveLaunchFile(files, "Game (USA)", tt.priority); got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestFindROMExtracted(t *testing.T) {
+	// findLaunchFile in the launcher remembers what FindROM returns, unless
+	// it's the fallback of the archive's own name
+	romDir := base("roms/psx")
+	fs := &fakeFS{files: map[string]string{
+		"roms/psx/Game (USA).cue":           "FILE \"Game (USA) (Track 1).bin\" BINARY",
+		"roms/psx/Game (USA) (Track 1).bin": "",
+		"roms/psx/Other Game (USA).cue":     "FILE \"Other Game (USA).bin\" BINARY",
+		"roms/psx/Other Game (USA).bin":     "",
+		"roms/psx/Broken (USA).cue":         "FILE \"Broken (USA).bin\" BINARY",
+	}}
+	env := newTestEnv("linux", fs)
+
+	tests := []struct {
+		game, want string
+	}{
+		{"Game (USA).zip", "Game (USA).cue"},
+		{"Other Game (USA).zip", "Other Game (USA).cue"},
+		{"Broken (USA).zip", "Broken (USA).zip"}, // The cue's bin is missing
+		{"Missing (USA).zip", "Missing (USA).zip"},
+	}
+	for _, tt := range tests {
+		if got := env.FindROM(romDir, tt.game, ps1Priority, true); got != filepath.Join(romDir, tt.want) {
+			t.Errorf("%s: got %s, want %s", tt.game, got, filepath.Join(romDir, tt.want))
+		}
+	}
+	if got := env.FindROM(romDir, "Game (USA).cue", ps1Priority, false); got != filepath.Join(romDir, "Game (USA).cue") {
+		t.Errorf("without extraction: got %s", got)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/emubuddy/gui/launch"
+)
+
+// Launch files of extracted games
+//
+// Systems with "needsExtract" launch a file that came out of the game's zip.
+// Which one is worked out once, by launch.ResolveLaunchFile with the system's
+// "launchPriority" (its "fileExtensions" if unset), and kept in
+// launch_files.json by system and catalog name, relative to the system's ROM
+// folder. Later launches use the recorded file as long as it's there.
+
+var launchFilesPath string
+var launchFiles map[string]map[string]string
+var launchFilesMu sync.Mutex
+
+func loadLaunchFiles() {
+	launchFiles = make(map[string]map[string]string)
+	data, err := os.ReadFile(launchFilesPath)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &launchFiles); err != nil {
+		logDebug("launch_files.json ignored: %v", err)
+	}
+}
+
+// saveLaunchFiles must be called with launchFilesMu held
+func saveLaunchFiles() {
+	data, _ := json.MarshalIndent(launchFiles, "", "  ")
+	os.WriteFile(launchFilesPath, data, 0644)
+}
+
+// launchPriority is the order in which extracted files are considered for launching
+func (c SystemConfig) launchPriority() []string {
+	if len(c.LaunchPriority) > 0 {
+		return c.LaunchPriority
+	}
+	return c.FileExtensions
+}
+
+// recordedLaunchFile returns the recorded launch file of a game, if it's still there
+func recordedLaunchFile(systemID, gameName string) (string, bool) {
+	launchFilesMu.Lock()
+	rel, ok := launchFiles[systemID][gameName]
+	launchFilesMu.Unlock()
+	if !ok {
+		return "", false
+	}
+	path := filepath.Join(romsDir, systems[systemID].Dir, rel)
+	return path, fileExists(path)
+}
+
+func setLaunchFile(systemID, gameName, path string) {
+	rel, err := filepath.Rel(filepath.Join(romsDir, systems[systemID].Dir), path)
+	if err != nil {
+		return
+	}
+	launchFilesMu.Lock()
+	defer launchFilesMu.Unlock()
+	if launchFiles[systemID] == nil {
+		launchFiles[systemID] = make(map[string]string)
+	}
+	if launchFiles[systemID][gameName] == rel {
+		return
+	}
+	launchFiles[systemID][gameName] = rel
+	saveLaunchFiles()
+}
+
+// resolveExtracted picks the launch file among the files extracted from a
+// game's zip and records it. It returns "" if none of them can be launched.
+func resolveExtracted(systemID string, game ROM, extracted []string) string {
+	config := systems[systemID]
+	path := launch.NewEnv(baseDir).ResolveLaunchFile(extracted, catalogBaseName(game.Name), config.launchPriority())
+	if path == "" {
+		logDebug("No launch file among the %d files extracted for %s", len(extracted), game.Name)
+		return ""
+	}
+	logDebug("Launch file of %s: %s", game.Name, path)
+	setLaunchFile(systemID, game.Name, path)
+	return path
+}
+
+// findLaunchFile returns the file to launch for a downloaded game. For
+// systems that extract, the recorded launch file is used if there is one;
+// otherwise the folder is searched and the result recorded. The result may
+// not exist.
+func findLaunchFile(env *launch.Env, systemID string, game ROM) string {
+	config := systems[systemID]
+	romDir := filepath.Join(romsDir, config.Dir)
+	if !config.NeedsExtract {
+		return env.FindROM(romDir, game.Name, config.FileExtensions, false)
+	}
+	if path, ok := recordedLaunchFile(systemID, game.Name); ok {
+		return path
+	}
+	path := env.FindROM(romDir, game.Name, config.launchPriority(), true)
+	if path != filepath.Join(romDir, game.Name) && fileExists(path) {
+		setLaunchFile(systemID, game.Name, path)
+	}
+	return path
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/emubuddy/gui/launch"
+)
+
+// User launch options
+//
+// launch_options.json holds extra emulator arguments and environment
+// variables for a whole system or a single game. Arguments are templates like
+// the "args" of systems.json, so they can use {rom}, {core}, {saveDir}, etc:
+//
+//	{
+//	  "systems": {"ps2": {"args": ["-fullscreen"]}},
+//	  "games": {"gc": {"Some Game (USA).zip": {"args": ["-b"], "env": {"DOLPHIN_EMU_USERPATH": "{saveDir}"}}}}
+//	}
+//
+// A game's options are used after its system's; for env the game wins.
+
+// LaunchOptions are extra arguments and environment variables for launches
+type LaunchOptions struct {
+	Args []string          `json:"args,omitempty"`
+	Env  map[string]string `json:"env,omitempty"`
+}
+
+// launchOptionsFile is the on-disk format of launch_options.json
+type launchOptionsFile struct {
+	Systems map[string]LaunchOptions            `json:"systems,omitempty"` // system -> options
+	Games   map[string]map[string]LaunchOptions `json:"games,omitempty"`   // system -> game -> options
+}
+
+var launchOptionsPath string
+var launchOpts launchOptionsFile
+
+func loadLaunchOptions() {
+	launchOpts = launchOptionsFile{}
+	data, err := os.ReadFile(launchOptionsPath)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &launchOpts); err != nil {
+		configWarning("launch_options.json ignored: %v", err)
+	}
+}
+
+// gameLaunchOptions combines the options of a system and one of its games
+func gameLaunchOptions(systemID, gameName string) LaunchOptions {
+	var opts LaunchOptions
+	for _, o := range []LaunchOptions{launchOpts.Systems[systemID], launchOpts.Games[systemID][gameName]} {
+		opts.Args = append(opts.Args, o.Args...)
+		for key, value := range o.Env {
+			if opts.Env == nil {
+				opts.Env = make(map[string]string)
+			}
+			opts.Env[key] = value
+		}
+	}
+	return opts
+}
+
+// launchSpec describes the launch of a game with an emulator option, for both
+// the GUI and --launch
+func launchSpec(systemID string, game ROM, opt EmulatorOption, romPath string) launch.LaunchSpec {
+	config := systems[systemID]
+	user := gameLaunchOptions(systemID, game.Name)
+
+	spec := launch.LaunchSpec{
+		Emulator:  opt.Emulator.Location(),
+		Args:      opt.Args,
+		ExtraArgs: user.Args,
+		Core:      opt.Core,
+		ROM:       romPath,
+		BiosDir:   configDir(config.BiosDir, filepath.Join("bios", config.Dir)),
+		SaveDir:   configDir(config.SaveDir, filepath.Join("saves", config.Dir)),
+		Env:       user.Env,
+	}
+
+	// Emulators don't always create the save folder they're pointed at,
+	// whether by an argument or an environment variable
+	envValues := make([]string, 0, len(spec.Env))
+	for _, value := range spec.Env {
+		envValues = append(envValues, value)
+	}
+	if containsPlaceholder(spec.Args, "{saveDir}") || containsPlaceholder(spec.ExtraArgs, "{saveDir}") || containsPlaceholder(envValues, "{saveDir}") {
+		os.MkdirAll(spec.SaveDir, 0755)
+	}
+	return spec
+}
+
+// containsPlaceholder reports whether any argument or value uses a placeholder
+func containsPlaceholder(args []string, placeholder string) bool {
+	for _, arg := range args {
+		if strings.Contains(arg, placeholder) {
+			return true
+		}
+	}
+	return false
+}
+
+// configDir returns a folder from systems.json as an absolute path, relative
+// to the EmuBuddy folder, or the default if it isn't set
+func configDir(dir, fallback string) string {
+	if dir == "" {
+		dir = fallback
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(baseDir, filepath.FromSlash(dir))
+}
@@ -1,9 +1,9 @@
 package main
 
 import (
-	"archive/zip"
-	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -23,8 +23,8 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/0xcafed00d/joystick"
-
-	"github.com/emubuddy/gui/wiiu"
+	"github.com/emubuddy/extract"
+	"github.com/emubuddy/gui/launch"
 )
 
 // Debug logging
@@ -172,12 +172,16 @@ func (t *TappableListItem) Tapped(e *fyne.PointEvent) {
 func (t *TappableListItem) TappedSecondary(e *fyne.PointEvent) {}
 
 type ROM struct {
-	Name    string `json:"name"`
-	URL     string `json:"url"`
-	Size    string `json:"size"`
-	Date    string `json:"date"`
-	TitleID string `json:"titleId,omitempty"` // For Wii U games
-	Region  string `json:"region,omitempty"`  // For Wii U games
+	Name    string    `json:"name"`
+	URL     string    `json:"url"`
+	Size    string    `json:"size"`
+	Date    string    `json:"date,omitempty"`
+	TitleID string    `json:"titleId,omitempty"` // For Wii U games
+	Region  string    `json:"region,omitempty"`  // For Wii U games
+	Files   []ROMFile `json:"files,omitempty"`   // Checksums imported from a DAT
+
+	systemID string // Set on entries of virtual systems such as Recently Played
+	discs    []ROM  // All discs, on the entry of a multi-disc game (see discs.go)
 }
 
 type CoreConfig struct {
@@ -189,29 +193,20 @@ type CoreConfig struct {
 
 // GetCorePath returns the appropriate core path for the current OS
 func (c *CoreConfig) GetCorePath() string {
-	switch runtime.GOOS {
-	case "linux":
-		if c.So != "" {
-			return c.So
-		}
-		// Auto-convert from dll
-		return strings.TrimSuffix(c.Dll, ".dll") + ".so"
-	case "darwin":
-		if c.Dylib != "" {
-			return c.Dylib
-		}
-		// Auto-convert from dll
-		return strings.TrimSuffix(c.Dll, ".dll") + ".dylib"
-	default:
-		return c.Dll
-	}
+	return launch.NewEnv(baseDir).CorePath(launch.Core{Dll: c.Dll, So: c.So, Dylib: c.Dylib})
 }
 
 type EmulatorConfig struct {
-	Path  string       `json:"path"`
-	Args  []string     `json:"args"`
-	Cores []CoreConfig `json:"cores"`
-	Name  string       `json:"name"`
+	Path      string                         `json:"path"` // Windows path; other systems use Platforms
+	Args      []string                       `json:"args"`
+	Cores     []CoreConfig                   `json:"cores"`
+	Name      string                         `json:"name"`
+	Platforms map[string]launch.PlatformPath `json:"platforms,omitempty"` // Keyed by GOOS, see launch/platform.go
+}
+
+// Location returns where the emulator is installed, for the launch package
+func (e EmulatorConfig) Location() launch.Emulator {
+	return launch.Emulator{Path: e.Path, Platforms: e.Platforms}
 }
 
 type SystemConfig struct {
@@ -224,11 +219,15 @@ type SystemConfig struct {
 	StandaloneEmulator *EmulatorConfig `json:"standaloneEmulator"`
 	FileExtensions     []string        `json:"fileExtensions"`
 	NeedsExtract       bool            `json:"needsExtract"`
+	LaunchPriority     []string        `json:"launchPriority,omitempty"` // Extracted files to launch, by extension, see launch_files.go
 	SpecialDownload    string          `json:"specialDownload,omitempty"`
+	BiosDir            string          `json:"biosDir,omitempty"` // For {biosDir}, default bios/<dir>
+	SaveDir            string          `json:"saveDir,omitempty"` // For {saveDir}, default saves/<dir>
 }
 
 type SystemsConfig struct {
-	Systems []SystemConfig `json:"systems"`
+	Systems       []SystemConfig `json:"systems"`
+	ThumbnailsURL string         `json:"thumbnailsUrl,omitempty"` // See thumbnails.go
 }
 
 var systems map[string]SystemConfig
@@ -238,6 +237,8 @@ var favorites map[string]map[string]bool
 var baseDir string
 var romsDir string
 var favoritesPath string
+var downloadsPath string
+var systemsConfigErr error // set if systems.json couldn't be loaded at all
 
 func init() {
 	exe, err := os.Executable()
@@ -259,9 +260,27 @@ func init() {
 
 	romsDir = filepath.Join(baseDir, "roms")
 	favoritesPath = filepath.Join(baseDir, "favorites.json")
-
-	loadSystemsConfig()
+	downloadsPath = filepath.Join(baseDir, "downloads.json")
+	verifyPath = filepath.Join(baseDir, "verification.json")
+	historyPath = filepath.Join(baseDir, "history.json")
+	emulatorPrefsPath = filepath.Join(baseDir, "emulator_prefs.json")
+	launchOptionsPath = filepath.Join(baseDir, "launch_options.json")
+	filtersPath = filepath.Join(baseDir, "filters.json")
+	thumbnailsDir = filepath.Join(baseDir, "thumbnails")
+	collectionsPath = filepath.Join(baseDir, "collections.json")
+	launchFilesPath = filepath.Join(baseDir, "launch_files.json")
+	checksumsPath = filepath.Join(baseDir, "checksums.json")
+
+	systemsConfigErr = loadSystemsConfig()
 	loadFavorites()
+	loadVerifyResults()
+	loadPlayHistory()
+	loadEmulatorPrefs()
+	loadLaunchOptions()
+	loadGameFilters()
+	loadCollections()
+	loadLaunchFiles()
+	loadChecksums()
 }
 
 func fileExists(path string) bool {
@@ -269,24 +288,42 @@ func fileExists(path string) bool {
 	return err == nil
 }
 
-func loadSystemsConfig() {
+// loadSystemsConfig reads the shipped systems.json and applies the user's
+// systems.user.json on top of it
+func loadSystemsConfig() error {
+	systems = make(map[string]SystemConfig)
+	systemsList = nil
+
 	configPath := filepath.Join(baseDir, "systems.json")
 	data, err := os.ReadFile(configPath)
 	if err != nil {
-		panic(fmt.Sprintf("Failed to load systems.json: %v", err))
+		return fmt.Errorf("failed to load systems.json: %w", err)
 	}
 
 	var config SystemsConfig
 	if err := json.Unmarshal(data, &config); err != nil {
-		panic(fmt.Sprintf("Failed to parse systems.json: %v", err))
+		return fmt.Errorf("failed to parse systems.json: %w", err)
+	}
+	if config.ThumbnailsURL != "" {
+		thumbnailsURL = config.ThumbnailsURL
 	}
 
-	systems = make(map[string]SystemConfig)
-	systemsList = make([]string, 0, len(config.Systems))
-	for _, sys := range config.Systems {
+	merged := applySystemsOverlay(config.Systems)
+	systemsList = make([]string, 0, len(merged))
+	for _, sys := range merged {
+		if _, dup := systems[sys.ID]; dup {
+			configWarning("duplicate system ID '%s' ignored", sys.ID)
+			continue
+		}
+		// The shipped systems are checked like the overlay's
+		if err := validateSystem(sys); err != nil {
+			configWarning("system '%s' ignored: %v", sys.ID, err)
+			continue
+		}
 		systems[sys.ID] = sys
 		systemsList = append(systemsList, sys.ID)
 	}
+	return nil
 }
 
 func loadFavorites() {
@@ -303,205 +340,17 @@ func saveFavorites() {
 	os.WriteFile(favoritesPath, data, 0644)
 }
 
-// resolvePlatformPath converts Windows paths from systems.json to platform-specific paths
-func resolvePlatformPath(windowsPath string) string {
-	platform := runtime.GOOS
-
-	if platform == "windows" {
-		return windowsPath
-	}
-
-	// Convert to forward slashes
-	path := filepath.ToSlash(windowsPath)
-
-	if platform == "darwin" {
-		// macOS-specific path resolution
-
-		// Handle RetroArch
-		if strings.Contains(path, "RetroArch/RetroArch-Win64/retroarch.exe") {
-			return strings.Replace(path, "RetroArch/RetroArch-Win64/retroarch.exe", "RetroArch/RetroArch.app/Contents/MacOS/RetroArch", 1)
-		}
-
-		// Handle RetroArch cores
-		// On macOS, cores are stored in ~/Library/Application Support/RetroArch/cores/
-		// Path arrives as .dylib already (converted by GetCorePath())
-		if strings.Contains(path, "cores/") && strings.HasSuffix(path, ".dylib") {
-			coreName := filepath.Base(path)
-			homeDir, _ := os.UserHomeDir()
-			return filepath.Join(homeDir, "Library/Application Support/RetroArch/cores", coreName)
-		}
-
-		// Handle Dolphin
-		if strings.Contains(path, "Dolphin/Dolphin-x64/Dolphin.exe") {
-			return strings.Replace(path, "Dolphin/Dolphin-x64/Dolphin.exe", "Dolphin/Dolphin.app/Contents/MacOS/Dolphin", 1)
-		}
-
-		// Handle PCSX2
-		if strings.Contains(path, "PCSX2/pcsx2-qt.exe") {
-			// Find the actual .app bundle (version may vary)
-			pcsx2Dir := filepath.Join(baseDir, "Emulators", "PCSX2")
-			if entries, err := os.ReadDir(pcsx2Dir); err == nil {
-				for _, entry := range entries {
-					if strings.HasPrefix(entry.Name(), "PCSX2") && strings.HasSuffix(entry.Name(), ".app") {
-						return fmt.Sprintf("Emulators/PCSX2/%s/Contents/MacOS/PCSX2-qt", entry.Name())
-					}
-				}
-			}
-			return strings.Replace(path, "PCSX2/pcsx2-qt.exe", "PCSX2/PCSX2.app/Contents/MacOS/PCSX2-qt", 1)
-		}
-
-		// Handle PPSSPP
-		if strings.Contains(path, "PPSSPP/PPSSPPWindows64.exe") {
-			return strings.Replace(path, "PPSSPP/PPSSPPWindows64.exe", "PPSSPP/PPSSPP.app/Contents/MacOS/PPSSPP", 1)
-		}
-
-		// Handle mGBA
-		if strings.Contains(path, "mGBA/mGBA-0.10.5-win64/mGBA.exe") {
-			return strings.Replace(path, "mGBA/mGBA-0.10.5-win64/mGBA.exe", "mGBA/mGBA.app/Contents/MacOS/mGBA", 1)
-		}
-
-		// Handle melonDS
-		if strings.Contains(path, "melonDS/melonDS.exe") {
-			return strings.Replace(path, "melonDS/melonDS.exe", "melonDS/melonDS.app/Contents/MacOS/melonDS", 1)
-		}
-
-		// Handle Azahar
-		if strings.Contains(path, "Azahar/azahar.exe") {
-			return strings.Replace(path, "Azahar/azahar.exe", "Azahar/azahar.app/Contents/MacOS/azahar", 1)
-		}
-	}
-
-	if platform == "linux" {
-		// Linux-specific path resolution
-
-		// Handle RetroArch - it's an AppImage on Linux
-		if strings.Contains(path, "RetroArch/RetroArch-Win64/retroarch.exe") {
-			// Find the actual AppImage in the RetroArch directory
-			retroarchDir := filepath.Join(baseDir, "Emulators", "RetroArch", "RetroArch-Linux-x86_64")
-			if entries, err := os.ReadDir(retroarchDir); err == nil {
-				for _, entry := range entries {
-					if strings.HasSuffix(strings.ToLower(entry.Name()), ".appimage") {
-						return fmt.Sprintf("Emulators/RetroArch/RetroArch-Linux-x86_64/%s", entry.Name())
-					}
-				}
-			}
-			return "Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage"
-		}
-
-		// Handle RetroArch cores - .dll -> .so, and update path for Linux
-		if strings.Contains(path, "cores/") && strings.HasSuffix(path, ".dll") {
-			// Change extension and update the RetroArch path
-			path = strings.TrimSuffix(path, ".dll") + ".so"
-			path = strings.Replace(path, "RetroArch-Win64", "RetroArch-Linux-x86_64", 1)
-			return path
-		}
-
-		// Handle PCSX2 - find the AppImage in the PCSX2 folder
-		if strings.Contains(path, "PCSX2/pcsx2-qt.exe") {
-			pcsx2Dir := filepath.Join(baseDir, "Emulators", "PCSX2")
-			if entries, err := os.ReadDir(pcsx2Dir); err == nil {
-				for _, entry := range entries {
-					if strings.HasSuffix(strings.ToLower(entry.Name()), ".appimage") {
-						return fmt.Sprintf("Emulators/PCSX2/%s", entry.Name())
-					}
-				}
-			}
-			return "Emulators/PCSX2/pcsx2.AppImage"
-		}
-
-		// Handle PPSSPP - find the AppImage in the PPSSPP folder
-		if strings.Contains(path, "PPSSPP/PPSSPPWindows64.exe") {
-			ppssppDir := filepath.Join(baseDir, "Emulators", "PPSSPP")
-			if entries, err := os.ReadDir(ppssppDir); err == nil {
-				for _, entry := range entries {
-					if strings.HasSuffix(strings.ToLower(entry.Name()), ".appimage") {
-						return fmt.Sprintf("Emulators/PPSSPP/%s", entry.Name())
-					}
-				}
-			}
-			return "Emulators/PPSSPP/ppsspp.AppImage"
-		}
-
-		// Handle mGBA - find the AppImage in the mGBA folder
-		if strings.Contains(path, "mGBA/mGBA-0.10.5-win64/mGBA.exe") {
-			mgbaDir := filepath.Join(baseDir, "Emulators", "mGBA")
-			if entries, err := os.ReadDir(mgbaDir); err == nil {
-				for _, entry := range entries {
-					if strings.HasSuffix(strings.ToLower(entry.Name()), ".appimage") {
-						return fmt.Sprintf("Emulators/mGBA/%s", entry.Name())
-					}
-				}
-			}
-			return "Emulators/mGBA/mgba.AppImage"
-		}
-
-		// Handle melonDS - find the AppImage in the melonDS folder
-		if strings.Contains(path, "melonDS/melonDS.exe") {
-			melondsDir := filepath.Join(baseDir, "Emulators", "melonDS")
-			if entries, err := os.ReadDir(melondsDir); err == nil {
-				for _, entry := range entries {
-					if strings.HasSuffix(strings.ToLower(entry.Name()), ".appimage") {
-						return fmt.Sprintf("Emulators/melonDS/%s", entry.Name())
-					}
-				}
-			}
-			return "Emulators/melonDS/melonDS.AppImage"
-		}
-
-		// Handle Azahar - find the AppImage in the Azahar folder
-		if strings.Contains(path, "Azahar/azahar.exe") {
-			azaharDir := filepath.Join(baseDir, "Emulators", "Azahar")
-			if entries, err := os.ReadDir(azaharDir); err == nil {
-				for _, entry := range entries {
-					if strings.HasSuffix(strings.ToLower(entry.Name()), ".appimage") {
-						return fmt.Sprintf("Emulators/Azahar/%s", entry.Name())
-					}
-				}
-			}
-			return "Emulators/Azahar/azahar.AppImage"
-		}
-		// Handle Cemu - find the AppImage in the Cemu folder
-		if strings.Contains(path, "Cemu/Cemu.exe") {
-			cemuDir := filepath.Join(baseDir, "Emulators", "Cemu")
-			if entries, err := os.ReadDir(cemuDir); err == nil {
-				for _, entry := range entries {
-					if strings.HasSuffix(strings.ToLower(entry.Name()), ".appimage") {
-						return fmt.Sprintf("Emulators/Cemu/%s", entry.Name())
-					}
-				}
-			}
-			return "Emulators/Cemu/Cemu.AppImage"
-		}
-
-
-		// Handle Dolphin - currently Flatpak, but if installed as AppImage
-		if strings.Contains(path, "Dolphin/Dolphin-x64/Dolphin.exe") {
-			dolphinDir := filepath.Join(baseDir, "Emulators", "Dolphin")
-			// Check for AppImage first
-			if entries, err := os.ReadDir(dolphinDir); err == nil {
-				for _, entry := range entries {
-					if strings.HasSuffix(strings.ToLower(entry.Name()), ".appimage") {
-						return fmt.Sprintf("Emulators/Dolphin/%s", entry.Name())
-					}
-				}
-			}
-			// Dolphin is a Flatpak on Linux - return a special marker that launchGame can handle
-			return "flatpak:org.DolphinEmu.dolphin-emu"
-		}
-	}
-
-	return windowsPath
-}
-
 // App holds the application state
 type App struct {
 	window          fyne.Window
 	windowFocused   bool
 	currentSystem   string
+	systemIDs       []string // Systems shown in the system list, virtual ones first
 	allGames        []ROM
 	filteredGames   []ROM
 	showFavsOnly    bool
 	romCache        map[string]bool
+	partialCache    map[string]bool // games with a resumable partial download
 	selectedGameIdx int
 	selectedSysIdx  int
 	focusOnGames    bool // true = game list focused, false = system list focused
@@ -512,11 +361,10 @@ type App struct {
 
 	// Emulator choice state
 	choosingEmulator    bool
-	emulatorChoices     []string
-	emulatorPaths       []string
-	emulatorArgs        [][]string
+	emulatorChoices     []EmulatorOption
 	selectedEmulatorIdx int
 	pendingGame         ROM
+	rememberMode        RememberMode
 	
 	// Mouse double-click tracking
 	lastClickTime time.Time
@@ -528,12 +376,14 @@ type App struct {
 	statusBar         *widget.Label
 	searchEntry       *widget.Entry
 	searchQuery       string
+	searching         bool // The game list shows search results or recommendations from all systems
 	instructions      *widget.Label
 	favsCheck         *widget.Check
 	launchBtn         *widget.Button
 	
 	// Emulator choice UI
 	emulatorList      *widget.List
+	rememberBtn       *widget.Button
 	emulatorSelectBtn *widget.Button
 	emulatorCancelBtn *widget.Button
 	mainContainer     *fyne.Container
@@ -542,8 +392,49 @@ type App struct {
 	emulatorPanel     *fyne.Container
 	rightPanel        *fyne.Container  // Container that holds either gamePanel or emulatorPanel
 
+	// Download queue
+	downloads           *DownloadManager
+	thumbnails          *thumbnailCache
+	downloadItems       []DownloadItem // snapshot shown in the downloads panel
+	selectedDownloadIdx int
+	showingDownloads    bool
+	downloadsList       *widget.List
+	downloadsPanel      *fyne.Container
+
+	// Filter and sort panel
+	facets            gameFacets // Filter values of the current system
+	showingFilters    bool
+	selectedFilterIdx int
+	filtersList       *widget.List
+	filtersPanel      *fyne.Container
+	filtersBtn        *widget.Button
+
+	// Collections panel
+	showingCollections    bool
+	selectedCollectionIdx int
+	collectionsList       *widget.List
+	collectionsPanel      *fyne.Container
+	collectionsHeader     *widget.Label
+	collectionsGame       *ROM // The game being put in collections, nil if none
+	relistingSystems      bool // The system list is being rebuilt, so selecting doesn't reload
+
+	// Game details pane
+	focusOnDetails     bool
+	detailsHeader      *widget.Label
+	detailsTitle       *widget.Label
+	detailsInfo        *widget.Label
+	detailsAddons      *widget.Label // Update and DLC of Wii U games
+	detailsDesc        *widget.Label
+	detailsSimilar     *widget.Label
+	detailsBoxart      *canvas.Image
+	detailsTitleScreen *canvas.Image
+	detailsSnap        *canvas.Image
+	detailsScroll      *container.Scroll
+	similarTo          *ROM // The game list shows games like this one
+
 	// Disclaimer dialog reference for controller dismissal
 	disclaimerDialog  dialog.Dialog
+	warningsDialog    dialog.Dialog // Problems in the systems config, until dismissed
 }
 
 // isSetupComplete checks if emulators have been installed
@@ -626,6 +517,13 @@ func launchROMHeadless(systemID string, romPath string) {
 		os.Exit(1)
 	}
 
+	// An archive extracted by an earlier launch is gone, find what came out of it
+	// the same way the GUI does
+	env := launch.NewEnv(baseDir)
+	if !fileExists(romPath) && config.NeedsExtract {
+		romPath = env.FindROM(filepath.Dir(romPath), filepath.Base(romPath), config.launchPriority(), true)
+	}
+
 	if !fileExists(romPath) {
 		fmt.Printf("Error: ROM not found: %s\n", romPath)
 		os.Exit(1)
@@ -640,133 +538,97 @@ func launchROMHeadless(systemID string, romPath string) {
 		}
 	}
 
-	// Create a minimal game ROM struct
+	// Create a minimal game ROM struct, named like the catalog entry if there
+	// is one so the play history matches the game list
 	game := ROM{
 		Name: filepath.Base(romPath),
 	}
+	base := strings.TrimSuffix(game.Name, filepath.Ext(game.Name))
+	if rom, ok := catalogByBaseName(systemID)[strings.ToLower(base)]; ok {
+		game.Name = rom.Name
+	}
 
 	// Handle extraction if needed (for systems like Dolphin that can't read zips)
 	actualRomPath := romPath
-	if config.NeedsExtract && strings.HasSuffix(strings.ToLower(romPath), ".zip") {
-		fmt.Printf("[DEBUG] System requires extraction, extracting ZIP...\n")
+	if config.NeedsExtract && extract.IsArchive(romPath) {
+		fmt.Printf("[DEBUG] System requires extraction, extracting archive...\n")
 		romDir := filepath.Dir(romPath)
-		extractedPath, err := extractZip(romPath, romDir)
+		extracted, err := extract.Archive(romPath, romDir, extract.Options{})
 		if err != nil {
-			fmt.Printf("Error extracting ROM: %v\n", err)
+			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
-		if extractedPath != "" {
-			actualRomPath = extractedPath
-			fmt.Printf("[DEBUG] Extracted to: %s\n", actualRomPath)
-			// Remove the zip after extraction to save space
-			os.Remove(romPath)
+		extractedPath := resolveExtracted(systemID, game, extracted)
+		if extractedPath == "" {
+			// Nothing launchable came out of the archive, look for the
+			// game's files in its folder the way the GUI does
+			path := env.FindROM(romDir, filepath.Base(romPath), config.launchPriority(), true)
+			if path != romPath && fileExists(path) {
+				extractedPath = path
+				setLaunchFile(systemID, game.Name, path)
+			}
+		}
+		if extractedPath == "" {
+			fmt.Printf("Error: none of the %d files extracted from %s can be launched (looking for %s)\n",
+				len(extracted), filepath.Base(romPath), strings.Join(config.launchPriority(), ", "))
+			os.Exit(1)
 		}
+		actualRomPath = extractedPath
+		fmt.Printf("[DEBUG] Extracted to: %s\n", actualRomPath)
+		// Remove the archive after extraction to save space
+		os.Remove(romPath)
 	}
 
-	fmt.Printf("Launching %s: %s\n", config.Name, game.Name)
-
-	// Use first emulator/core
-	emuPath := config.Emulator.Path
-	var emuArgs []string
-
-	if len(config.Emulator.Cores) > 0 {
-		// Use first core - GetCorePath() handles OS-specific paths
-		corePath := config.Emulator.Cores[0].GetCorePath()
-		emuArgs = []string{"-L", corePath}
-		fmt.Printf("[DEBUG] Using RetroArch core: %s\n", corePath)
-	} else {
-		emuArgs = config.Emulator.Args
-		fmt.Printf("[DEBUG] Using standalone emulator with args: %v\n", emuArgs)
+	// Wii U games are a folder, Cemu loads the rpx in it
+	if config.SpecialDownload == "wiiu" {
+		actualRomPath = env.FindRPX(romPath)
 	}
 
-	// Launch the game (reuse existing logic)
-	launchGameHeadless(game, actualRomPath, emuPath, emuArgs)
-}
-
-// launchGameHeadless launches a game without GUI
-func launchGameHeadless(game ROM, romPath string, emuPath string, emuArgs []string) {
-	// Resolve platform-specific path
-	emuPath = resolvePlatformPath(emuPath)
-
-	// Handle flatpak on Linux
-	isFlatpak := strings.HasPrefix(emuPath, "flatpak:")
-	var flatpakAppID string
-	if isFlatpak {
-		flatpakAppID = strings.TrimPrefix(emuPath, "flatpak:")
-		emuPath = "flatpak"
-	} else {
-		emuPath = filepath.Join(baseDir, emuPath)
-	}
-	emuDir := filepath.Dir(emuPath)
+	fmt.Printf("Launching %s: %s\n", config.Name, game.Name)
 
-	// On Linux, ensure AppImages are executable
-	if runtime.GOOS == "linux" && strings.HasSuffix(strings.ToLower(emuPath), ".appimage") {
-		os.Chmod(emuPath, 0755)
+	// Use the remembered emulator/core, otherwise the first one
+	options := emulatorOptions(config)
+	if len(options) == 0 {
+		fmt.Printf("Error: No emulator configured for %s\n", config.Name)
+		os.Exit(1)
 	}
-
-	// Build args
-	args := []string{}
-
-	// For flatpak, add "run" and the app ID first
-	if isFlatpak {
-		args = append(args, "run", flatpakAppID)
+	opt, remembered := preferredEmulator(systemID, game.Name, options)
+	if !remembered {
+		opt = options[0]
 	}
+	fmt.Printf("[DEBUG] Using %s (remembered=%v) with args: %v\n", opt.Label, remembered, opt.Args)
 
-	for _, arg := range emuArgs {
-		if strings.Contains(arg, "/") || strings.Contains(arg, "\\") {
-			// Resolve platform-specific core paths
-			resolvedArg := resolvePlatformPath(arg)
-			if filepath.IsAbs(resolvedArg) {
-				args = append(args, resolvedArg)
-			} else {
-				resolvedPath := filepath.Join(emuDir, resolvedArg)
-
-				// On Linux, verify core file exists
-				if runtime.GOOS == "linux" && strings.HasSuffix(strings.ToLower(resolvedPath), ".so") {
-					if !fileExists(resolvedPath) {
-						fmt.Printf("ERROR: Core file not found: %s\n", resolvedPath)
-						os.Exit(1)
-					}
-				}
-
-				args = append(args, resolvedPath)
-			}
-		} else {
-			args = append(args, arg)
-		}
-	}
-	args = append(args, romPath)
+	session := newPlaySession(systemID, game, opt)
+	session.Headless = true
 
-	fmt.Printf("Command: %s %v\n", emuPath, args)
+	// Launch the game (reuse existing logic)
+	launchGameHeadless(game, actualRomPath, opt, session)
+}
 
-	cmd := exec.Command(emuPath, args...)
-	if !isFlatpak {
-		// On Linux, for AppImages (standalone emulators), use base directory as working dir
-		// This fixes issues with Cemu and other AppImages that need to run from the project root
-		if runtime.GOOS == "linux" && strings.HasSuffix(strings.ToLower(emuPath), ".appimage") {
-			cmd.Dir = baseDir
-			fmt.Printf("Using base directory as working dir for AppImage: %s\n", baseDir)
-		} else {
-			cmd.Dir = emuDir
-		}
+// launchGameHeadless launches a game without GUI and waits for it to exit so
+// the session can be recorded
+func launchGameHeadless(game ROM, romPath string, opt EmulatorOption, session *PlaySession) {
+	spec := launchSpec(session.SystemID, game, opt, romPath)
+	spec.Output = os.Stdout
+	cmd, err := launch.NewEnv(baseDir).Command(spec)
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
 	}
 
-	// On Linux, set environment variables to fix AppImage compatibility
-	if runtime.GOOS == "linux" {
-		cmd.Env = append(os.Environ(),
-			"SDL_VIDEODRIVER=x11",
-			"QT_QPA_PLATFORM=xcb",
-		)
-	}
+	fmt.Printf("Command: %s %v\n", cmd.Path, cmd.Args[1:])
+	fmt.Printf("Working directory: %s\n", cmd.Dir)
 
-	// Use Start() instead of Run() so we don't wait for the emulator to exit
-	// This allows the launcher to exit immediately after launching
 	if err := cmd.Start(); err != nil {
 		fmt.Printf("Launch failed: %v\n", err)
 		os.Exit(1)
 	}
 	
 	fmt.Println("Emulator launched successfully")
+
+	err = cmd.Wait()
+	session.finish(cmd, err)
+	fmt.Printf("Emulator exited after %s\n", time.Duration(session.Seconds*float64(time.Second)).Round(time.Second))
 }
 
 func main() {
@@ -783,6 +645,19 @@ func main() {
 		fmt.Println("[DEBUG] No arguments received - starting GUI mode")
 	}
 
+	// Report problems with the systems config instead of crashing
+	for _, warning := range configWarnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	if systemsConfigErr != nil {
+		fmt.Printf("Error: %v\n", systemsConfigErr)
+		if len(os.Args) > 1 {
+			os.Exit(1)
+		}
+		showConfigError(systemsConfigErr)
+		return
+	}
+
 	// Check for CLI arguments for headless ROM launch FIRST (before setup check)
 	// This allows testing even if setup isn't complete
 	if len(os.Args) >= 2 && os.Args[1] == "--launch" {
@@ -799,6 +674,44 @@ func main() {
 		return
 	}
 
+	// Import checksums from a No-Intro/Redump DAT into a system's catalog
+	if len(os.Args) >= 2 && os.Args[1] == "--import-dat" {
+		var systemID, datPath string
+		if len(os.Args) >= 4 {
+			systemID, datPath = os.Args[2], os.Args[3]
+		}
+		importDATHeadless(systemID, datPath)
+		return
+	}
+
+	// Check a Wii U title's contents against its TMD
+	if len(os.Args) >= 2 && os.Args[1] == "--verify-wiiu" {
+		verifyWiiUHeadless(os.Args[2:])
+		return
+	}
+
+	// Clear remembered emulator choices
+	if len(os.Args) >= 2 && os.Args[1] == "--reset-emulator" {
+		resetEmulatorHeadless(os.Args[2:])
+		return
+	}
+
+	// Import an existing ROM collection into a system's roms folder
+	if len(os.Args) >= 2 && os.Args[1] == "--import" {
+		importCollectionHeadless(os.Args[2:])
+		return
+	}
+
+	// Share named collections between machines
+	if len(os.Args) >= 2 && os.Args[1] == "--export-collection" {
+		exportCollectionHeadless(os.Args[2:])
+		return
+	}
+	if len(os.Args) >= 2 && os.Args[1] == "--import-collections" {
+		importCollectionsHeadless(os.Args[2:])
+		return
+	}
+
 	// Check if setup has been run (Emulators folder should have content)
 	if !isSetupComplete() {
 		runSetupAndExit()
@@ -814,12 +727,22 @@ func main() {
 	appState := &App{
 		window:        myWindow,
 		romCache:      make(map[string]bool),
+		partialCache:  make(map[string]bool),
 		windowFocused: true,
+		downloads:     NewDownloadManager(downloadsPath),
+		thumbnails:    newThumbnailCache(),
 	}
 
 	appState.buildUI()
+	appState.downloads.OnChange = appState.onDownloadsChanged
+	appState.downloads.OnFinished = appState.onDownloadFinished
+	appState.downloads.OnFailed = appState.onDownloadFailed
+	appState.downloads.Start()
+	appState.thumbnails.OnLoaded = appState.onThumbnailLoaded
 	appState.showDisclaimer()
 	go appState.pollController()
+	go buildSearchIndex()
+	go buildRecommendations()
 	myWindow.ShowAndRun()
 }
 
@@ -848,8 +771,10 @@ Press A or click "I Understand" to continue • Press B or click "Exit" to quit`
 		a.disclaimerShown = false
 		if !accepted && !a.disclaimerAcceptedByController {
 			a.window.Close()
+			return
 		}
 		a.disclaimerAcceptedByController = false
+		a.showConfigWarnings()
 	}, a.window)
 	d.Resize(fyne.NewSize(500, 350))
 	a.disclaimerDialog = d
@@ -857,19 +782,25 @@ Press A or click "I Understand" to continue • Press B or click "Exit" to quit`
 }
 
 func (a *App) buildUI() {
-	// System list on left
+	// System list on left, with Recently Played and the collections at the top
+	a.systemIDs = systemListIDs()
 	a.systemList = widget.NewList(
-		func() int { return len(systemsList) },
+		func() int { return len(a.systemIDs) },
 		func() fyne.CanvasObject {
 			return widget.NewLabel("System Name Here")
 		},
 		func(id widget.ListItemID, item fyne.CanvasObject) {
-			if id >= len(systemsList) {
+			if id >= len(a.systemIDs) {
 				return
 			}
 			label := item.(*widget.Label)
-			sysID := systemsList[id]
+			sysID := a.systemIDs[id]
 			name := systems[sysID].Name
+			if sysID == recentSystemID {
+				name = "Recently Played"
+			} else if c, ok := collectionForSystem(sysID); ok {
+				name = c.Name + " [Collection]"
+			}
 			if !a.focusOnGames && id == a.selectedSysIdx {
 				name = "> " + name
 			}
@@ -878,9 +809,12 @@ func (a *App) buildUI() {
 	)
 
 	a.systemList.OnSelected = func(id widget.ListItemID) {
+		if a.relistingSystems {
+			return
+		}
 		a.selectedSysIdx = id
 		a.focusOnGames = false
-		a.selectSystem(systemsList[id])
+		a.selectSystem(a.systemIDs[id])
 		a.systemList.Refresh()
 	}
 
@@ -896,8 +830,13 @@ func (a *App) buildUI() {
 			statusText.TextSize = 14
 			sizeText := canvas.NewText("999.9 MiB", theme.ForegroundColor())
 			sizeText.TextSize = 14
-			content := container.NewBorder(nil, nil, nil,
-				container.NewHBox(statusText, sizeText),
+			playText := canvas.NewText("99h 59m", theme.ForegroundColor())
+			playText.TextSize = 14
+			boxart := canvas.NewImageFromResource(nil)
+			boxart.FillMode = canvas.ImageFillContain
+			boxart.SetMinSize(fyne.NewSize(listThumbnailSize, listThumbnailSize))
+			content := container.NewBorder(nil, nil, boxart,
+				container.NewHBox(statusText, playText, sizeText),
 				nameText,
 			)
 			return NewTappableListItem(content)
@@ -914,16 +853,29 @@ func (a *App) buildUI() {
 			
 			box := tappable.Content.(*fyne.Container)
 			nameText := box.Objects[0].(*canvas.Text)
-			rightBox := box.Objects[1].(*fyne.Container)
+			boxart := box.Objects[1].(*canvas.Image)
+			rightBox := box.Objects[2].(*fyne.Container)
 			statusText := rightBox.Objects[0].(*canvas.Text)
-			sizeText := rightBox.Objects[1].(*canvas.Text)
+			playText := rightBox.Objects[1].(*canvas.Text)
+			sizeText := rightBox.Objects[2].(*canvas.Text)
+			sysID := a.gameSystem(game)
+
+			if path := a.thumbnails.Get(sysID, ThumbBoxart, game); path != boxart.File {
+				boxart.File = path
+				boxart.Refresh()
+			}
 
 			// Name with favorite indicator
-			name := strings.TrimSuffix(game.Name, ".zip")
-			name = strings.TrimSuffix(name, ".chd")
-			if a.isFavorite(game.Name) {
+			name := catalogBaseName(game.Name)
+			if len(game.discs) > 0 {
+				name = discSetName(game)
+			}
+			if a.isFavorite(game) {
 				name = "[FAV] " + name
 			}
+			if game.systemID != "" {
+				name = fmt.Sprintf("%s [%s]", name, systems[sysID].Name)
+			}
 			if a.focusOnGames && id == a.selectedGameIdx {
 				name = "> " + name
 			}
@@ -935,13 +887,24 @@ func (a *App) buildUI() {
 			nameText.Refresh()
 
 			// Status
-			if a.romCache[game.Name] {
+			if a.hasBadDisc(game) {
+				statusText.Text = "[BAD]"
+			} else if a.isDownloaded(game) {
 				statusText.Text = "[Ready]"
+			} else if dl, queued := a.queuedDisc(sysID, game); queued {
+				statusText.Text = downloadStatusText(dl)
+			} else if a.isPartial(game) {
+				statusText.Text = "[Part]"
+			} else if n := a.discsDownloaded(game); n > 0 {
+				statusText.Text = fmt.Sprintf("[%d/%d]", n, len(game.discs))
 			} else {
 				statusText.Text = "[DL]"
 			}
 			statusText.Refresh()
 
+			playText.Text = formatPlayTime(totalPlayTime(sysID, game.Name))
+			playText.Refresh()
+
 			sizeText.Text = game.Size
 			sizeText.Refresh()
 		},
@@ -958,9 +921,10 @@ func (a *App) buildUI() {
 
 	// Search box
 	a.searchEntry = widget.NewEntry()
-	a.searchEntry.SetPlaceHolder("Type to search...")
+	a.searchEntry.SetPlaceHolder("Search all systems...")
 	a.searchEntry.OnChanged = func(s string) {
 		a.searchQuery = s
+		a.similarTo = nil
 		a.filterGames()
 	}
 
@@ -968,7 +932,7 @@ func (a *App) buildUI() {
 	a.statusBar = widget.NewLabel("Select a system")
 
 	// Instructions
-	a.instructions = widget.NewLabel("Controller: L-Stick=Sys R-Stick=Games A=Select B=Back X=DL Y=Fav | Keyboard: Arrows/Enter/Esc/D=DL/F=Fav | Mouse: Double-click=Launch")
+	a.instructions = widget.NewLabel("Controller: L-Stick=Sys R-Stick=Games A=Select B=Back X=DL Y=Fav LB=Emu Back=Queue RB=Info Y(Systems)=Filter | Keyboard: Arrows/Enter/Esc/D=DL/F=Fav/E=Emu/Q=Queue/I=Info/S=Filter | Mouse: Double-click=Launch")
 	a.instructions.TextStyle = fyne.TextStyle{Italic: true}
 
 	// Title
@@ -1001,7 +965,7 @@ func (a *App) buildUI() {
 			})
 			
 			label := tappable.Content.(*widget.Label)
-			name := a.emulatorChoices[id]
+			name := a.emulatorChoices[id].Label
 			if id == a.selectedEmulatorIdx {
 				name = "> " + name
 			}
@@ -1025,7 +989,7 @@ func (a *App) buildUI() {
 			return
 		}
 		game := a.filteredGames[a.selectedGameIdx]
-		if a.romCache[game.Name] {
+		if a.isDownloaded(game) {
 			logDebug("Launch button clicked - launching")
 			a.launchSelected()
 		} else {
@@ -1034,15 +998,35 @@ func (a *App) buildUI() {
 		}
 	})
 	
+	// Downloads button - shows the download queue
+	downloadsBtn := widget.NewButton("Downloads", func() {
+		a.toggleDownloads()
+	})
+
+	// Import button - brings an existing ROM folder into the current system
+	importBtn := widget.NewButton("Import", func() {
+		a.showImportDialog()
+	})
+
+	// Filters button - shows the filter and sort panel
+	a.filtersBtn = widget.NewButton("Filters", func() {
+		a.toggleFilters()
+	})
+
+	// Collections button - puts the selected game in collections
+	collectionsBtn := widget.NewButton("Collections", func() {
+		a.showCollections()
+	})
+
 	// Game panel with header, favorites checkbox, launch button, and search
 	gamesLabel := widget.NewLabel("GAMES")
 	gameHeader := container.NewBorder(nil, nil,
-		container.NewHBox(gamesLabel, a.favsCheck, a.launchBtn),
+		container.NewHBox(gamesLabel, a.favsCheck, a.launchBtn, a.filtersBtn, collectionsBtn, downloadsBtn, importBtn),
 		nil,
 		a.searchEntry,
 	)
 	a.gamePanel = container.NewBorder(
-		gameHeader, nil, nil, nil,
+		gameHeader, nil, nil, a.buildDetailsPanel(),
 		a.gameList,
 	)
 
@@ -1059,7 +1043,14 @@ func (a *App) buildUI() {
 		a.cancelEmulatorChoice()
 	})
 	
-	emulatorButtons := container.NewHBox(a.emulatorSelectBtn, a.emulatorCancelBtn)
+	a.rememberBtn = widget.NewButton(rememberLabel(RememberOff, ""), func() {
+		a.cycleRememberMode()
+	})
+	forgetBtn := widget.NewButton("Forget", func() {
+		a.forgetEmulatorChoice()
+	})
+
+	emulatorButtons := container.NewHBox(a.rememberBtn, forgetBtn, a.emulatorSelectBtn, a.emulatorCancelBtn)
 	emulatorHeaderRow := container.NewBorder(nil, nil, emulatorHeader, emulatorButtons)
 	
 	a.emulatorPanel = container.NewBorder(
@@ -1067,6 +1058,15 @@ func (a *App) buildUI() {
 		a.emulatorList,
 	)
 
+	// Download queue panel
+	a.buildDownloadsPanel()
+
+	// Filter and sort panel
+	a.buildFiltersPanel()
+
+	// Collections panel
+	a.buildCollectionsPanel()
+
 	// Main layout - use custom FixedWidthLayout that returns constant MinSize
 	a.systemPanel = systemPanel
 	a.rightPanel = container.NewMax(a.gamePanel)
@@ -1091,6 +1091,29 @@ func (a *App) buildUI() {
 	a.window.Canvas().SetOnTypedKey(func(ke *fyne.KeyEvent) {
 		// Don't handle keys if search box is focused or dialog is open
 		if a.dialogOpen {
+			if a.warningsDialog != nil && (ke.Name == fyne.KeyReturn || ke.Name == fyne.KeyEnter || ke.Name == fyne.KeyEscape) {
+				a.warningsDialog.Hide()
+			}
+			return
+		}
+
+		// The download queue panel has its own keys
+		if a.showingDownloads {
+			a.handleDownloadsKey(ke)
+			return
+		}
+
+		// So do the filter and collections panels and the details pane
+		if a.showingFilters {
+			a.handleFiltersKey(ke)
+			return
+		}
+		if a.showingCollections {
+			a.handleCollectionsKey(ke)
+			return
+		}
+		if a.focusOnDetails {
+			a.handleDetailsKey(ke)
 			return
 		}
 		
@@ -1115,6 +1138,8 @@ func (a *App) buildUI() {
 			// Escape/Backspace - Go back
 			if a.choosingEmulator {
 				a.cancelEmulatorChoice()
+			} else if a.similarTo != nil {
+				a.hideSimilar()
 			} else if a.focusOnGames {
 				a.focusOnGames = false
 				a.systemList.Refresh()
@@ -1134,7 +1159,7 @@ func (a *App) buildUI() {
 					a.gameList.Select(a.selectedGameIdx)
 				}
 			} else {
-				if a.selectedSysIdx < len(systemsList)-1 {
+				if a.selectedSysIdx < len(a.systemIDs)-1 {
 					a.selectedSysIdx++
 					a.systemList.Select(a.selectedSysIdx)
 				}
@@ -1190,6 +1215,77 @@ func (a *App) buildUI() {
 			if a.focusOnGames && !a.choosingEmulator {
 				a.toggleSelectedFavorite()
 			}
+
+		case fyne.KeyQ:
+			// Q key - Show download queue
+			a.showDownloads()
+
+		case fyne.KeyE:
+			// E key - Choose the emulator, even if one is remembered
+			if a.focusOnGames && !a.choosingEmulator {
+				a.chooseEmulatorSelected()
+			}
+
+		case fyne.KeyS:
+			// S key - Show the filter and sort panel
+			if !a.choosingEmulator {
+				a.showFilters()
+			}
+
+		case fyne.KeyI:
+			// I key - Focus the details pane to scroll it
+			if a.focusOnGames && !a.choosingEmulator {
+				a.focusDetails()
+			}
+
+		case fyne.KeyM:
+			// M key - List games like the selected one, or go back
+			if !a.choosingEmulator {
+				a.toggleSimilar()
+			}
+
+		case fyne.KeyU:
+			// U key - Download the update and DLC of a Wii U game
+			if a.focusOnGames && !a.choosingEmulator {
+				a.downloadWiiUAddons()
+			}
+
+		case fyne.KeyV:
+			// V key - Check the selected game's files against its checksums
+			if a.focusOnGames && !a.choosingEmulator {
+				a.verifySelected()
+			}
+
+		case fyne.KeyR:
+			// R key - Change what the emulator choice remembers
+			if a.choosingEmulator {
+				a.cycleRememberMode()
+			}
+
+		case fyne.KeyDelete:
+			// Delete - Forget the remembered emulator, or take the game out of
+			// the collection on screen
+			if a.choosingEmulator {
+				a.forgetEmulatorChoice()
+			} else if a.focusOnGames {
+				a.removeFromCollection()
+			}
+
+		case fyne.KeyC:
+			// C key - Put the selected game in collections
+			if !a.choosingEmulator {
+				a.showCollections()
+			}
+
+		case fyne.KeyLeftBracket, fyne.KeyRightBracket:
+			// [ and ] - Move the selected game up or down its collection
+			if a.focusOnGames && !a.choosingEmulator {
+				if ke.Name == fyne.KeyLeftBracket {
+					a.moveInCollection(-1)
+				} else {
+					a.moveInCollection(1)
+				}
+			}
 			
 		case fyne.KeyTab:
 			// Tab - Toggle between systems and games
@@ -1239,10 +1335,48 @@ func (a *App) buildUI() {
 		}
 	})
 
-	// Select first system
-	if len(systemsList) > 0 {
-		a.systemList.Select(0)
+	// Select first real system
+	if first := len(a.systemIDs) - len(systemsList); first < len(a.systemIDs) {
+		a.systemList.Select(first)
+	}
+}
+
+// remapLinuxButtons converts the button bits of a controller on Linux to the
+// layout the rest of the launcher uses: bit 0=A, 1=B, 2=X, 3=Y, 4=LB, 5=RB,
+// 6=Back, 7=Start. The Linux joystick driver numbers buttons in evdev order,
+// leaving gaps for buttons the pad doesn't have: bit 0=A, 1=B, 3=X, 4=Y,
+// 6=LB, 7=RB, 10=Back, 11=Start (as seen on the Steam Deck).
+func remapLinuxButtons(buttons uint32) uint32 {
+	remapped := uint32(0)
+	if buttons&0x0001 != 0 {
+		remapped |= 0x0001 // A
+	}
+	if buttons&0x0002 != 0 {
+		remapped |= 0x0002 // B
+	}
+	if buttons&0x0008 != 0 {
+		remapped |= 0x0004 // X (bit 3 -> bit 2)
+	}
+	if buttons&0x0010 != 0 {
+		remapped |= 0x0008 // Y (bit 4 -> bit 3)
+	}
+	if buttons&0x0020 != 0 {
+		remapped |= 0x0020 // Keep bit 5 as-is
+	}
+	if buttons&0x0040 != 0 {
+		remapped |= 0x0010 // LB (bit 6 -> bit 4)
+	}
+	if buttons&0x0080 != 0 {
+		remapped |= 0x0020 // RB (bit 7 -> bit 5)
+	}
+	if buttons&0x0400 != 0 {
+		remapped |= 0x0040 // Back (bit 10 -> bit 6)
 	}
+	if buttons&0x0800 != 0 {
+		remapped |= 0x0080 // Start (bit 11 -> bit 7)
+	}
+	// Copy any other bits we haven't explicitly mapped
+	return remapped | buttons&0xFFFFF300
 }
 
 func (a *App) pollController() {
@@ -1318,16 +1452,7 @@ func (a *App) pollController() {
 			}
 			if runtime.GOOS == "linux" {
 				originalButtons := buttons
-				remapped := uint32(0)
-				if buttons&0x0001 != 0 { remapped |= 0x0001 } // A -> A (bit 0)
-				if buttons&0x0002 != 0 { remapped |= 0x0002 } // B -> B (bit 1)
-				if buttons&0x0008 != 0 { remapped |= 0x0004 } // X -> X (bit 3 -> bit 2)
-				if buttons&0x0010 != 0 { remapped |= 0x0008 } // RB -> Y (bit 4 -> bit 3)
-				if buttons&0x0020 != 0 { remapped |= 0x0020 }
-				if buttons&0x0040 != 0 { remapped |= 0x0040 }
-				if buttons&0x0800 != 0 { remapped |= 0x0080 }
-				remapped |= buttons & 0xFFFFF700
-				buttons = remapped
+				buttons = remapLinuxButtons(buttons)
 				logDebug("Disclaimer button remap: 0x%08X -> 0x%08X", originalButtons, buttons)
 			}
 
@@ -1359,8 +1484,11 @@ func (a *App) pollController() {
 			continue
 		}
 
-		// Skip if other dialog is open
+		// Skip if other dialog is open. Any button closes the config warnings.
 		if a.dialogOpen {
+			if a.warningsDialog != nil && state.Buttons&^lastButtons != 0 {
+				a.warningsDialog.Hide()
+			}
 			lastButtons = state.Buttons
 			continue
 		}
@@ -1444,31 +1572,13 @@ func (a *App) pollController() {
 			buttons = remapped
 		}
 
-		// Remap buttons for Linux (observed button mapping differs from expected)
-		// User testing shows:
-		// - RB favorites/unfavorites games (should be Y, bit 3)
-		// - Y does nothing
-		// - Start toggles favorites list (working, bit 7)
-		// Looking at logs: bit 4 (0x10) is being pressed when RB is pressed
-		// Expected: bit 0=A, bit 1=B, bit 2=X, bit 3=Y, bit 7=Start
-		// Actual: bit 0=A, bit 1=B, bit 3=X, bit 4=RB(should be Y), bit 11=Start
+		// Remap buttons for Linux (observed button mapping differs from expected),
+		// see remapLinuxButtons
 		if runtime.GOOS == "linux" {
 			originalButtons := buttons
-			remapped := uint32(0)
-			// Remap buttons correctly
-			if buttons&0x0001 != 0 { remapped |= 0x0001 } // A -> A (bit 0)
-			if buttons&0x0002 != 0 { remapped |= 0x0002 } // B -> B (bit 1)
-			if buttons&0x0008 != 0 { remapped |= 0x0004 } // X -> X (bit 3 -> bit 2)
-			if buttons&0x0010 != 0 { remapped |= 0x0008 } // RB -> Y (bit 4 -> bit 3)
-			if buttons&0x0020 != 0 { remapped |= 0x0020 } // Keep bit 5 as-is
-			if buttons&0x0040 != 0 { remapped |= 0x0040 } // Back -> Back (bit 6)
-			// bit 7 is NOT used, don't map it
-			if buttons&0x0800 != 0 { remapped |= 0x0080 } // Start -> Start (bit 11 -> bit 7)
-			// Copy any other bits we haven't explicitly mapped
-			remapped |= buttons & 0xFFFFF700
-			buttons = remapped
-			if originalButtons != remapped {
-				logDebug("Linux button remap: 0x%08X -> 0x%08X", originalButtons, remapped)
+			buttons = remapLinuxButtons(buttons)
+			if originalButtons != buttons {
+				logDebug("Linux button remap: 0x%08X -> 0x%08X", originalButtons, buttons)
 			}
 		}
 
@@ -1485,6 +1595,14 @@ func (a *App) pollController() {
 			if justPressed&2 != 0 {
 				a.cancelEmulatorChoice()
 			}
+			// Y button - change what to remember
+			if justPressed&8 != 0 {
+				a.cycleRememberMode()
+			}
+			// X button - forget the remembered emulator
+			if justPressed&4 != 0 {
+				a.forgetEmulatorChoice()
+			}
 			// Right stick or D-pad to navigate emulator list
 			if rightY != 0 && (rightY != lastRightY || time.Since(rightRepeatTimer) > repeatDelay) {
 				newIdx := a.selectedEmulatorIdx + rightY
@@ -1528,23 +1646,212 @@ func (a *App) pollController() {
 			continue
 		}
 
-		// A button (bit 0) - Select/Launch
-		if justPressed&1 != 0 {
-			if a.focusOnGames {
-				a.launchSelected()
-			} else {
-				a.focusOnGames = true
-				if len(a.filteredGames) > 0 {
-					a.gameList.Select(0)
-				}
-				a.systemList.Refresh()
-				a.gameList.Refresh()
+		// Handle download queue panel
+		if a.showingDownloads {
+			// A button - pause/resume
+			if justPressed&1 != 0 {
+				a.toggleSelectedDownload()
 			}
-		}
-
-		// B button (bit 1) - Back
-		if justPressed&2 != 0 {
-			if a.focusOnGames {
+			// B or Back button - close the panel
+			if justPressed&2 != 0 || justPressed&64 != 0 {
+				a.hideDownloads()
+			}
+			// X button - cancel download
+			if justPressed&4 != 0 {
+				a.cancelSelectedDownload()
+			}
+			// Y button - move up the queue
+			if justPressed&8 != 0 {
+				a.moveSelectedDownload(-1)
+			}
+			// Right stick or D-pad to navigate the queue
+			if rightY != 0 && (rightY != lastRightY || time.Since(rightRepeatTimer) > repeatDelay) {
+				a.navigateDownloads(rightY)
+				rightRepeatTimer = time.Now()
+			}
+			if runtime.GOOS == "linux" {
+				if dpadY != 0 && (dpadY != lastDpadY || time.Since(dpadRepeatTimer) > repeatDelay) {
+					a.navigateDownloads(dpadY)
+					dpadRepeatTimer = time.Now()
+				}
+			} else {
+				if justPressed&4096 != 0 {
+					a.navigateDownloads(-1)
+				}
+				if justPressed&8192 != 0 {
+					a.navigateDownloads(1)
+				}
+			}
+
+			lastButtons = buttons
+			lastLeftY = leftY
+			lastRightY = rightY
+			lastDpadX = dpadX
+			lastDpadY = dpadY
+			continue
+		}
+
+		// Handle the filter panel
+		if a.showingFilters {
+			// A button - next value
+			if justPressed&1 != 0 {
+				a.cycleFilter(1)
+			}
+			// B button - close the panel
+			if justPressed&2 != 0 {
+				a.hideFilters()
+			}
+			// X button - clear the filters
+			if justPressed&4 != 0 {
+				a.clearFilters()
+			}
+			// Right stick or D-pad up/down to pick a row, D-pad left/right to change it
+			if rightY != 0 && (rightY != lastRightY || time.Since(rightRepeatTimer) > repeatDelay) {
+				a.navigateFilters(rightY)
+				rightRepeatTimer = time.Now()
+			}
+			if runtime.GOOS == "linux" {
+				if dpadY != 0 && (dpadY != lastDpadY || time.Since(dpadRepeatTimer) > repeatDelay) {
+					a.navigateFilters(dpadY)
+					dpadRepeatTimer = time.Now()
+				}
+				if dpadX != 0 && dpadX != lastDpadX {
+					a.cycleFilter(dpadX)
+				}
+			} else {
+				if justPressed&4096 != 0 {
+					a.navigateFilters(-1)
+				}
+				if justPressed&8192 != 0 {
+					a.navigateFilters(1)
+				}
+				if justPressed&16384 != 0 {
+					a.cycleFilter(-1)
+				}
+				if justPressed&32768 != 0 {
+					a.cycleFilter(1)
+				}
+			}
+
+			lastButtons = buttons
+			lastLeftY = leftY
+			lastRightY = rightY
+			lastDpadX = dpadX
+			lastDpadY = dpadY
+			continue
+		}
+
+		// Handle the collections panel
+		if a.showingCollections {
+			// A button - add or remove the game
+			if justPressed&1 != 0 {
+				a.toggleInCollection()
+			}
+			// B button - close the panel
+			if justPressed&2 != 0 {
+				a.hideCollections()
+			}
+			// X button - new collection
+			if justPressed&4 != 0 {
+				a.newCollection()
+			}
+			// Right stick or D-pad to navigate the collections
+			if rightY != 0 && (rightY != lastRightY || time.Since(rightRepeatTimer) > repeatDelay) {
+				a.navigateCollections(rightY)
+				rightRepeatTimer = time.Now()
+			}
+			if runtime.GOOS == "linux" {
+				if dpadY != 0 && (dpadY != lastDpadY || time.Since(dpadRepeatTimer) > repeatDelay) {
+					a.navigateCollections(dpadY)
+					dpadRepeatTimer = time.Now()
+				}
+			} else {
+				if justPressed&4096 != 0 {
+					a.navigateCollections(-1)
+				}
+				if justPressed&8192 != 0 {
+					a.navigateCollections(1)
+				}
+			}
+
+			lastButtons = buttons
+			lastLeftY = leftY
+			lastRightY = rightY
+			lastDpadX = dpadX
+			lastDpadY = dpadY
+			continue
+		}
+
+		// Handle the details pane
+		if a.focusOnDetails {
+			// B or RB button - back to the game list
+			if justPressed&2 != 0 || justPressed&32 != 0 {
+				a.unfocusDetails()
+			}
+			// Y button - more like this
+			if justPressed&8 != 0 {
+				a.unfocusDetails()
+				a.showSimilar()
+			}
+			// X button - put the game in collections
+			if justPressed&4 != 0 {
+				a.showCollections()
+			}
+			// LB button - download the update and DLC of a Wii U game
+			if justPressed&16 != 0 {
+				a.downloadWiiUAddons()
+			}
+			// Right stick or D-pad to scroll
+			if rightY != 0 && (rightY != lastRightY || time.Since(rightRepeatTimer) > repeatDelay) {
+				a.scrollDetails(rightY)
+				rightRepeatTimer = time.Now()
+			}
+			if runtime.GOOS == "linux" {
+				if dpadY != 0 && (dpadY != lastDpadY || time.Since(dpadRepeatTimer) > repeatDelay) {
+					a.scrollDetails(dpadY)
+					dpadRepeatTimer = time.Now()
+				}
+			} else {
+				if justPressed&4096 != 0 {
+					a.scrollDetails(-1)
+				}
+				if justPressed&8192 != 0 {
+					a.scrollDetails(1)
+				}
+			}
+
+			lastButtons = buttons
+			lastLeftY = leftY
+			lastRightY = rightY
+			lastDpadX = dpadX
+			lastDpadY = dpadY
+			continue
+		}
+
+		// Back button (bit 6) - Show download queue
+		if justPressed&64 != 0 {
+			a.showDownloads()
+		}
+
+		// A button (bit 0) - Select/Launch
+		if justPressed&1 != 0 {
+			if a.focusOnGames {
+				a.launchSelected()
+			} else {
+				a.focusOnGames = true
+				if len(a.filteredGames) > 0 {
+					a.gameList.Select(0)
+				}
+				a.systemList.Refresh()
+				a.gameList.Refresh()
+			}
+		}
+
+		// B button (bit 1) - Back, leaving "more like this" first
+		if justPressed&2 != 0 {
+			if a.similarTo != nil {
+				a.hideSimilar()
+			} else if a.focusOnGames {
 				a.focusOnGames = false
 				a.systemList.Refresh()
 				a.gameList.Refresh()
@@ -1556,9 +1863,21 @@ func (a *App) pollController() {
 			a.downloadSelected()
 		}
 
-		// Y button (bit 3) - Favorite
+		// Y button (bit 3) - Favorite, or filters when on the system list
 		if justPressed&8 != 0 && a.focusOnGames {
 			a.toggleSelectedFavorite()
+		} else if justPressed&8 != 0 {
+			a.showFilters()
+		}
+
+		// LB button (bit 4) - Choose emulator
+		if justPressed&16 != 0 && a.focusOnGames {
+			a.chooseEmulatorSelected()
+		}
+
+		// RB button (bit 5) - Focus the details pane
+		if justPressed&32 != 0 && a.focusOnGames {
+			a.focusDetails()
 		}
 
 		// Start button (bit 7) - Toggle favorites view
@@ -1573,7 +1892,7 @@ func (a *App) pollController() {
 			// Just started moving or repeat timer elapsed
 			if leftY != lastLeftY || time.Since(leftRepeatTimer) > repeatDelay {
 				newIdx := a.selectedSysIdx + leftY
-				if newIdx >= 0 && newIdx < len(systemsList) {
+				if newIdx >= 0 && newIdx < len(a.systemIDs) {
 					a.selectedSysIdx = newIdx
 					a.systemList.Select(newIdx)
 				}
@@ -1631,7 +1950,7 @@ func (a *App) pollController() {
 			if dpadX != 0 && (dpadX != lastDpadX || time.Since(dpadRepeatTimer) > repeatDelay) {
 				if dpadX < 0 && a.selectedSysIdx > 0 {
 					a.systemList.Select(a.selectedSysIdx - 1)
-				} else if dpadX > 0 && a.selectedSysIdx < len(systemsList)-1 {
+				} else if dpadX > 0 && a.selectedSysIdx < len(a.systemIDs)-1 {
 					a.systemList.Select(a.selectedSysIdx + 1)
 				}
 				dpadRepeatTimer = time.Now()
@@ -1654,7 +1973,7 @@ func (a *App) pollController() {
 			}
 			// D-pad Right (bit 15)
 			if justPressed&32768 != 0 {
-				if a.selectedSysIdx < len(systemsList)-1 {
+				if a.selectedSysIdx < len(a.systemIDs)-1 {
 					a.systemList.Select(a.selectedSysIdx + 1)
 				}
 			}
@@ -1678,7 +1997,7 @@ func (a *App) navigate(delta int) {
 		}
 	} else {
 		newIdx := a.selectedSysIdx + delta
-		if newIdx >= 0 && newIdx < len(systemsList) {
+		if newIdx >= 0 && newIdx < len(a.systemIDs) {
 			a.systemList.Select(newIdx)
 		}
 	}
@@ -1686,10 +2005,24 @@ func (a *App) navigate(delta int) {
 
 func (a *App) selectSystem(sysID string) {
 	a.currentSystem = sysID
+	a.similarTo = nil
 	config := systems[sysID]
 
 	// Clear existing games before loading new ones
 	a.allGames = nil
+
+	// Virtual systems list games from several systems
+	if isVirtualSystem(sysID) {
+		if c, ok := collectionForSystem(sysID); ok {
+			a.allGames = collectionGames(c)
+		} else {
+			a.allGames = recentGames()
+		}
+		a.buildROMCache()
+		a.facets = collectFacets(a.allGames, a.metadataOf)
+		a.filterGames()
+		return
+	}
 	
 	// Load ROM JSON
 	jsonFile := filepath.Join(baseDir, "1g1rsets", config.RomJsonFile)
@@ -1721,6 +2054,8 @@ func (a *App) selectSystem(sysID string) {
 		}
 		return
 	}
+	applyChecksums(sysID, a.allGames)
+	a.allGames = groupDiscs(a.allGames)
 	
 	if logFile != nil {
 		logFile.WriteString(fmt.Sprintf("[%s] Loaded %d games\n", time.Now().Format("15:04:05"), len(a.allGames)))
@@ -1735,41 +2070,55 @@ func (a *App) selectSystem(sysID string) {
 
 	// Build ROM cache
 	a.buildROMCache()
+	a.facets = collectFacets(a.allGames, a.metadataOf)
 	a.filterGames()
 }
 
 func (a *App) buildROMCache() {
+	a.buildROMCacheFor(a.allGames)
+}
+
+// buildROMCacheFor checks which of the games are downloaded
+func (a *App) buildROMCacheFor(games []ROM) {
 	a.romCache = make(map[string]bool)
-	config := systems[a.currentSystem]
-	romDir := filepath.Join(romsDir, config.Dir)
+	a.partialCache = make(map[string]bool)
 
-	entries, err := os.ReadDir(romDir)
-	if err != nil {
-		return
+	// Directory listings, per system
+	existingFiles := make(map[string]map[string]bool)
+	existingDirs := make(map[string]map[string]bool)
+
+	// Each disc of a multi-disc game is checked
+	var discs []ROM
+	for _, game := range games {
+		discs = append(discs, gameDiscs(game)...)
 	}
 
-	existingFiles := make(map[string]bool)
-	existingDirs := make(map[string]bool)
-	for _, entry := range entries {
-		if entry.IsDir() {
-			existingDirs[strings.ToLower(entry.Name())] = true
-		} else {
-			existingFiles[strings.ToLower(entry.Name())] = true
+	for _, game := range discs {
+		sysID := a.gameSystem(game)
+		config := systems[sysID]
+		romDir := filepath.Join(romsDir, config.Dir)
+
+		if existingFiles[sysID] == nil {
+			existingFiles[sysID] = make(map[string]bool)
+			existingDirs[sysID] = make(map[string]bool)
+			entries, _ := os.ReadDir(romDir)
+			for _, entry := range entries {
+				if entry.IsDir() {
+					existingDirs[sysID][strings.ToLower(entry.Name())] = true
+				} else {
+					existingFiles[sysID][strings.ToLower(entry.Name())] = true
+				}
+			}
 		}
-	}
+		files, dirs := existingFiles[sysID], existingDirs[sysID]
 
-	for _, game := range a.allGames {
 		exists := false
+		partial := false
 		
 		// For Wii U games, check for directory with sanitized name
 		if config.SpecialDownload == "wiiu" {
-			sanitizedName := strings.Map(func(r rune) rune {
-				if r == '/' || r == '\\' || r == ':' || r == '*' || r == '?' || r == '"' || r == '<' || r == '>' || r == '|' {
-					return '_'
-				}
-				return r
-			}, game.Name)
-			if existingDirs[strings.ToLower(sanitizedName)] {
+			sanitizedName := wiiuDirName(game.Name)
+			if dirs[strings.ToLower(sanitizedName)] {
 				// Check if the directory has content (code or meta folder)
 				gamePath := filepath.Join(romDir, sanitizedName)
 				codePath := filepath.Join(gamePath, "code")
@@ -1779,59 +2128,128 @@ func (a *App) buildROMCache() {
 				} else if _, err := os.Stat(metaPath); err == nil {
 					exists = true
 				}
+				// The encrypted contents, title.tmd among them, are deleted
+				// after decryption; until then the download is unfinished
+				if fileExists(filepath.Join(gamePath, "title.tmd")) {
+					exists = false
+					partial = true
+				}
+			}
+			// Downloads are staged until they're complete
+			if !exists && fileExists(filepath.Join(wiiuWorkDir(config, game), "title.tmd")) {
+				partial = true
 			}
 		} else {
-			baseName := strings.TrimSuffix(game.Name, ".zip")
+			baseName := extract.TrimExt(game.Name)
 
 			for _, ext := range config.FileExtensions {
-				if existingFiles[strings.ToLower(baseName+ext)] {
+				if files[strings.ToLower(baseName+ext)] {
 					exists = true
 					break
 				}
 			}
 
 			if !exists && !config.NeedsExtract {
-				if existingFiles[strings.ToLower(game.Name)] {
+				if files[strings.ToLower(game.Name)] {
 					exists = true
 				}
 			}
+
+			// Extracted files named unlike the game
+			if !exists && config.NeedsExtract {
+				_, exists = recordedLaunchFile(sysID, game.Name)
+			}
 		}
 
 		a.romCache[game.Name] = exists
+		if !exists {
+			a.partialCache[game.Name] = partial || files[strings.ToLower(game.Name+stateSuffix)]
+		}
+	}
+}
+
+// gameSystem returns the system a game belongs to. Games shown in a virtual
+// system carry their own system ID.
+func (a *App) gameSystem(game ROM) string {
+	if game.systemID != "" {
+		return game.systemID
 	}
+	return a.currentSystem
+}
+
+// metadataOf returns the metadata of a game in the list, nil if there is none
+func (a *App) metadataOf(game ROM) *GameMetadata {
+	return gameMetadata(a.gameSystem(game), game.Name)
 }
 
 func (a *App) filterGames() {
 	a.filteredGames = []ROM{}
-	query := strings.ToLower(a.searchQuery)
+	filter := a.currentFilter()
+
+	// Searches and recommendations list games from every system in ranked order
+	var ranked []ROM
+	if a.similarTo != nil {
+		ranked = similarGames(a.gameSystem(*a.similarTo), *a.similarTo, maxSimilarGames)
+	} else if strings.TrimSpace(a.searchQuery) != "" {
+		ranked = searchGames(a.searchQuery, maxSearchResults)
+	}
+	if ranked != nil || a.similarTo != nil {
+		for _, game := range ranked {
+			if a.showFavsOnly && !a.isFavorite(game) {
+				continue
+			}
+			a.filteredGames = append(a.filteredGames, game)
+		}
+		a.searching = true
+		a.buildROMCacheFor(a.filteredGames)
+		a.showFilteredGames()
+		if a.similarTo != nil {
+			a.statusBar.SetText(fmt.Sprintf("%d games like %s", len(a.filteredGames), catalogBaseName(a.similarTo.Name)))
+		}
+		return
+	}
+	if a.searching {
+		a.searching = false
+		a.buildROMCache()
+	}
 
 	for _, game := range a.allGames {
-		// Search filter
-		if query != "" && !strings.Contains(strings.ToLower(game.Name), query) {
+		// Favorites filter
+		if a.showFavsOnly && !a.isFavorite(game) {
 			continue
 		}
 
-		// Favorites filter
-		if a.showFavsOnly && !a.isFavorite(game.Name) {
+		// Metadata and download filters
+		if !filter.matches(a.metadataOf(game), a.isDownloaded(game)) {
 			continue
 		}
 
 		a.filteredGames = append(a.filteredGames, game)
 	}
+	sortGames(a.filteredGames, filter.Sort, a.metadataOf)
+	a.showFilteredGames()
+}
 
+// showFilteredGames refreshes the game list after filterGames
+func (a *App) showFilteredGames() {
 	a.gameList.Refresh()
+	a.updateFiltersButton()
 	a.statusBar.SetText(fmt.Sprintf("%d games", len(a.filteredGames)))
 
 	if len(a.filteredGames) > 0 {
 		a.gameList.Select(0)
+	} else {
+		a.selectedGameIdx = -1
+		a.updateDetails()
 	}
 }
 
-func (a *App) isFavorite(gameName string) bool {
-	if favorites[a.currentSystem] == nil {
+func (a *App) isFavorite(game ROM) bool {
+	sysID := a.gameSystem(game)
+	if favorites[sysID] == nil {
 		return false
 	}
-	return favorites[a.currentSystem][gameName]
+	return favorites[sysID][game.Name]
 }
 
 func (a *App) toggleSelectedFavorite() {
@@ -1840,15 +2258,16 @@ func (a *App) toggleSelectedFavorite() {
 	}
 
 	game := a.filteredGames[a.selectedGameIdx]
-	if favorites[a.currentSystem] == nil {
-		favorites[a.currentSystem] = make(map[string]bool)
+	sysID := a.gameSystem(game)
+	if favorites[sysID] == nil {
+		favorites[sysID] = make(map[string]bool)
 	}
 
-	if favorites[a.currentSystem][game.Name] {
-		delete(favorites[a.currentSystem], game.Name)
+	if favorites[sysID][game.Name] {
+		delete(favorites[sysID], game.Name)
 		a.statusBar.SetText("Removed from favorites")
 	} else {
-		favorites[a.currentSystem][game.Name] = true
+		favorites[sysID][game.Name] = true
 		a.statusBar.SetText("Added to favorites")
 	}
 	saveFavorites()
@@ -1856,15 +2275,26 @@ func (a *App) toggleSelectedFavorite() {
 }
 
 func (a *App) updateStatus() {
+	a.updateDetails()
 	if a.selectedGameIdx < 0 || a.selectedGameIdx >= len(a.filteredGames) {
 		return
 	}
 	game := a.filteredGames[a.selectedGameIdx]
-	name := strings.TrimSuffix(game.Name, ".zip")
-	name = strings.TrimSuffix(name, ".chd")
+	name := catalogBaseName(game.Name)
+
+	if len(game.discs) > 0 {
+		name = discSetName(game)
+	}
 
-	if a.romCache[game.Name] {
+	if disc, bad := a.badDisc(game); bad {
+		result, _ := getVerifyResult(a.gameSystem(game), disc.Name)
+		a.statusBar.SetText(fmt.Sprintf("Checksum mismatch: %s (%s) - press X/D to re-download", name, result.Detail))
+	} else if a.isDownloaded(game) {
 		a.statusBar.SetText(fmt.Sprintf("Ready: %s", name))
+	} else if n := a.discsDownloaded(game); n > 0 && len(game.discs) > 0 {
+		a.statusBar.SetText(fmt.Sprintf("%d of %d discs downloaded: %s (%s) - download again for the rest", n, len(game.discs), name, game.Size))
+	} else if a.isPartial(game) {
+		a.statusBar.SetText(fmt.Sprintf("Partially downloaded: %s (%s) - download again to resume", name, game.Size))
 	} else {
 		a.statusBar.SetText(fmt.Sprintf("Not downloaded: %s (%s)", name, game.Size))
 	}
@@ -1876,7 +2306,7 @@ func (a *App) updateLaunchButton() {
 		return
 	}
 	game := a.filteredGames[a.selectedGameIdx]
-	if a.romCache[game.Name] {
+	if a.isDownloaded(game) {
 		a.launchBtn.SetText("Launch")
 	} else {
 		a.launchBtn.SetText("Download")
@@ -1890,7 +2320,7 @@ func (a *App) launchSelected() {
 	}
 
 	game := a.filteredGames[a.selectedGameIdx]
-	if !a.romCache[game.Name] {
+	if !a.isDownloaded(game) {
 		a.statusBar.SetText("Game not downloaded yet")
 		return
 	}
@@ -1905,7 +2335,7 @@ func (a *App) downloadSelected() {
 	}
 
 	game := a.filteredGames[a.selectedGameIdx]
-	if a.romCache[game.Name] {
+	if a.isDownloaded(game) && !a.hasBadDisc(game) {
 		a.statusBar.SetText("Already downloaded")
 		return
 	}
@@ -1914,83 +2344,50 @@ func (a *App) downloadSelected() {
 }
 
 func (a *App) launchGame(game ROM) {
-	config := systems[a.currentSystem]
-
-	// Count total options
-	totalOptions := 0
-	
-	// Main emulator: either has cores or is standalone
-	if len(config.Emulator.Cores) > 0 {
-		totalOptions += len(config.Emulator.Cores)
-	} else if config.Emulator.Path != "" {
-		totalOptions++
+	sysID := a.gameSystem(game)
+	config := systems[sysID]
+	options := emulatorOptions(config)
+	if len(options) == 0 {
+		a.statusBar.SetText("No emulator configured for " + config.Name)
+		return
 	}
-	
-	// Standalone emulator: either has cores or is standalone
-	if config.StandaloneEmulator != nil {
-		if len(config.StandaloneEmulator.Cores) > 0 {
-			totalOptions += len(config.StandaloneEmulator.Cores)
-		} else if config.StandaloneEmulator.Path != "" {
-			totalOptions++
-		}
+
+	// A remembered choice skips the question
+	if opt, ok := preferredEmulator(sysID, game.Name, options); ok {
+		logDebug("Using remembered emulator %s for %s", opt.Label, game.Name)
+		a.launchWithEmulator(game, opt)
+		return
 	}
 
-	if totalOptions > 1 {
+	if len(options) > 1 {
 		a.showEmulatorChoice(game, config)
 	} else {
 		// Single option - launch directly
-		args := config.Emulator.Args
-		if len(config.Emulator.Cores) == 1 {
-			args = []string{"-L", config.Emulator.Cores[0].GetCorePath()}
-		}
-		a.launchWithEmulator(game, config.Emulator.Path, args)
+		a.launchWithEmulator(game, options[0])
 	}
 }
 
-func (a *App) showEmulatorChoice(game ROM, config SystemConfig) {
-	a.emulatorChoices = []string{}
-	a.emulatorPaths = []string{}
-	a.emulatorArgs = [][]string{}
-
-	// Add main emulator options
-	if len(config.Emulator.Cores) > 0 {
-		// Has cores - add each core as an option
-		for _, core := range config.Emulator.Cores {
-			a.emulatorChoices = append(a.emulatorChoices, fmt.Sprintf("RetroArch (%s)", core.Name))
-			a.emulatorPaths = append(a.emulatorPaths, config.Emulator.Path)
-			a.emulatorArgs = append(a.emulatorArgs, []string{"-L", core.GetCorePath()})
-		}
-	} else if config.Emulator.Path != "" {
-		// Standalone emulator (no cores)
-		name := config.Emulator.Name
-		if name == "" {
-			name = "Default Emulator"
-		}
-		a.emulatorChoices = append(a.emulatorChoices, name)
-		a.emulatorPaths = append(a.emulatorPaths, config.Emulator.Path)
-		a.emulatorArgs = append(a.emulatorArgs, config.Emulator.Args)
-	}
-
-	// Add standalone emulator options
-	if config.StandaloneEmulator != nil {
-		if len(config.StandaloneEmulator.Cores) > 0 {
-			// Has cores - add each core as an option
-			for _, core := range config.StandaloneEmulator.Cores {
-				a.emulatorChoices = append(a.emulatorChoices, fmt.Sprintf("RetroArch (%s)", core.Name))
-				a.emulatorPaths = append(a.emulatorPaths, config.StandaloneEmulator.Path)
-				a.emulatorArgs = append(a.emulatorArgs, []string{"-L", core.GetCorePath()})
-			}
-		} else if config.StandaloneEmulator.Path != "" {
-			// Standalone (no cores)
-			name := config.StandaloneEmulator.Name
-			if name == "" {
-				name = "Standalone"
-			}
-			a.emulatorChoices = append(a.emulatorChoices, name)
-			a.emulatorPaths = append(a.emulatorPaths, config.StandaloneEmulator.Path)
-			a.emulatorArgs = append(a.emulatorArgs, config.StandaloneEmulator.Args)
-		}
+// chooseEmulatorSelected asks which emulator to use for the selected game,
+// even if one has been remembered
+func (a *App) chooseEmulatorSelected() {
+	if a.selectedGameIdx < 0 || a.selectedGameIdx >= len(a.filteredGames) {
+		return
+	}
+	game := a.filteredGames[a.selectedGameIdx]
+	config := systems[a.gameSystem(game)]
+	if len(emulatorOptions(config)) < 2 {
+		a.statusBar.SetText("Only one emulator is available for " + config.Name)
+		return
 	}
+	if !a.isDownloaded(game) {
+		a.statusBar.SetText("Game not downloaded yet")
+		return
+	}
+	a.showEmulatorChoice(game, config)
+}
+
+func (a *App) showEmulatorChoice(game ROM, config SystemConfig) {
+	a.emulatorChoices = emulatorOptions(config)
 
 	if len(a.emulatorChoices) == 0 {
 		return
@@ -2000,14 +2397,31 @@ func (a *App) showEmulatorChoice(game ROM, config SystemConfig) {
 	a.pendingGame = game
 	a.selectedEmulatorIdx = 0
 	a.choosingEmulator = true
+
+	// Start from what's remembered, if anything
+	sysID := a.gameSystem(game)
+	a.rememberMode = RememberOff
+	if emuPrefs.Games[sysID][game.Name] != "" {
+		a.rememberMode = RememberGame
+	} else if emuPrefs.Systems[sysID] != "" {
+		a.rememberMode = RememberSystem
+	}
+	if opt, ok := preferredEmulator(sysID, game.Name, a.emulatorChoices); ok {
+		for i, choice := range a.emulatorChoices {
+			if choice.Key == opt.Key {
+				a.selectedEmulatorIdx = i
+			}
+		}
+	}
+	a.rememberBtn.SetText(rememberLabel(a.rememberMode, config.Name))
 	
 	// Swap game panel for emulator panel
 	a.rightPanel.Objects = []fyne.CanvasObject{a.emulatorPanel}
 	a.rightPanel.Refresh()
-	a.emulatorList.Select(0)
+	a.emulatorList.Select(a.selectedEmulatorIdx)
 	a.emulatorList.Refresh()
 	
-	a.statusBar.SetText(fmt.Sprintf("Choose emulator for: %s", game.Name))
+	a.statusBar.SetText(fmt.Sprintf("Choose emulator for: %s (Y/R=Remember, X/Del=Forget)", game.Name))
 }
 
 func (a *App) cancelEmulatorChoice() {
@@ -2018,95 +2432,52 @@ func (a *App) cancelEmulatorChoice() {
 }
 
 func (a *App) confirmEmulatorChoice() {
-	if a.selectedEmulatorIdx >= 0 && a.selectedEmulatorIdx < len(a.emulatorPaths) {
+	if a.selectedEmulatorIdx >= 0 && a.selectedEmulatorIdx < len(a.emulatorChoices) {
 		a.choosingEmulator = false
 		a.rightPanel.Objects = []fyne.CanvasObject{a.gamePanel}
 		a.rightPanel.Refresh()
-		a.launchWithEmulator(a.pendingGame, a.emulatorPaths[a.selectedEmulatorIdx], a.emulatorArgs[a.selectedEmulatorIdx])
+		opt := a.emulatorChoices[a.selectedEmulatorIdx]
+		rememberEmulator(a.gameSystem(a.pendingGame), a.pendingGame.Name, opt, a.rememberMode)
+		a.launchWithEmulator(a.pendingGame, opt)
 	}
 }
 
-func (a *App) launchWithEmulator(game ROM, emuPath string, emuArgs []string) {
-	config := systems[a.currentSystem]
-	romDir := filepath.Join(romsDir, config.Dir)
-
-	// Resolve platform-specific path
-	emuPath = resolvePlatformPath(emuPath)
-	
-	// Handle flatpak on Linux
-	isFlatpak := strings.HasPrefix(emuPath, "flatpak:")
-	var flatpakAppID string
-	if isFlatpak {
-		flatpakAppID = strings.TrimPrefix(emuPath, "flatpak:")
-		emuPath = "flatpak"
-	} else {
-		emuPath = filepath.Join(baseDir, emuPath)
-	}
-	emuDir := filepath.Dir(emuPath)
+// cycleRememberMode switches between remembering nothing, the game's
+// emulator and the system's default emulator
+func (a *App) cycleRememberMode() {
+	a.rememberMode = (a.rememberMode + 1) % 3
+	config := systems[a.gameSystem(a.pendingGame)]
+	a.rememberBtn.SetText(rememberLabel(a.rememberMode, config.Name))
+}
 
-	// On Linux, ensure AppImages are executable
-	if runtime.GOOS == "linux" && strings.HasSuffix(strings.ToLower(emuPath), ".appimage") {
-		os.Chmod(emuPath, 0755)
-	}
+// forgetEmulatorChoice clears the remembered emulator of the pending game and
+// its system's default
+func (a *App) forgetEmulatorChoice() {
+	forgetEmulator(a.gameSystem(a.pendingGame), a.pendingGame.Name)
+	a.rememberMode = RememberOff
+	a.rememberBtn.SetText(rememberLabel(a.rememberMode, ""))
+	a.statusBar.SetText("Forgot the emulator choice for: " + a.pendingGame.Name)
+}
 
-	// Log the resolved path for debugging
-	logDebug("Launching with emulator: %s", emuPath)
-	logDebug("Emulator dir: %s", emuDir)
+func (a *App) launchWithEmulator(game ROM, opt EmulatorOption) {
+	sysID := a.gameSystem(game)
+	config := systems[sysID]
+	session := newPlaySession(sysID, game, opt)
+	romDir := filepath.Join(romsDir, config.Dir)
+	env := launch.NewEnv(baseDir)
 
-	// Find ROM file
+	// Find ROM file - Wii U games are a folder, Cemu loads the rpx in it
 	var romPath string
-	
-	// For Wii U games, the ROM is a directory
 	if config.SpecialDownload == "wiiu" {
-		sanitizedName := strings.Map(func(r rune) rune {
-			if r == '/' || r == '\\' || r == ':' || r == '*' || r == '?' || r == '"' || r == '<' || r == '>' || r == '|' {
-				return '_'
-			}
-			return r
-		}, game.Name)
-		romPath = filepath.Join(romDir, sanitizedName)
-		
-		// For Cemu, we need to point to the rpx file in the code folder
-		rpxPath := filepath.Join(romPath, "code")
-		if entries, err := os.ReadDir(rpxPath); err == nil {
-			for _, entry := range entries {
-				if strings.HasSuffix(strings.ToLower(entry.Name()), ".rpx") {
-					romPath = filepath.Join(rpxPath, entry.Name())
-					break
-				}
-			}
-		}
-	} else if config.NeedsExtract {
-		baseName := strings.TrimSuffix(game.Name, ".zip")
-		for _, ext := range config.FileExtensions {
-			testPath := filepath.Join(romDir, baseName+ext)
-			if fileExists(testPath) {
-				romPath = testPath
-				break
-			}
-		}
-
-		// If not found, look for any file starting with baseName
-		if romPath == "" {
-			entries, _ := os.ReadDir(romDir)
-			for _, entry := range entries {
-				if strings.HasPrefix(entry.Name(), baseName) {
-					for _, ext := range config.FileExtensions {
-						if strings.HasSuffix(strings.ToLower(entry.Name()), strings.ToLower(ext)) {
-							romPath = filepath.Join(romDir, entry.Name())
-							break
-						}
-					}
-					if romPath != "" {
-						break
-					}
-				}
-			}
+		romPath = env.FindRPX(filepath.Join(romDir, wiiuDirName(game.Name)))
+	} else if len(game.discs) > 0 {
+		var err error
+		if romPath, err = a.discSetPath(env, romDir, game, opt); err != nil {
+			a.statusBar.SetText(err.Error())
+			return
 		}
-	}
-
-	if romPath == "" {
-		romPath = filepath.Join(romDir, game.Name)
+	} else {
+		romPath = findLaunchFile(env, sysID, game)
 	}
 
 	if !fileExists(romPath) {
@@ -2114,78 +2485,26 @@ func (a *App) launchWithEmulator(game ROM, emuPath string, emuArgs []string) {
 		return
 	}
 
-	// Build args
-	args := []string{}
-
-	// For flatpak, add "run" and the app ID first
-	if isFlatpak {
-		args = append(args, "run", flatpakAppID)
-	}
-
-	for _, arg := range emuArgs {
-		if strings.Contains(arg, "/") || strings.Contains(arg, "\\") {
-			// Resolve platform-specific core paths
-			resolvedArg := resolvePlatformPath(arg)
-			logDebug("Path resolution: '%s' -> '%s' (IsAbs=%v, platform=%s)", arg, resolvedArg, filepath.IsAbs(resolvedArg), runtime.GOOS)
-
-			// If resolved path is absolute, use it directly; otherwise join with emuDir
-			if filepath.IsAbs(resolvedArg) {
-				logDebug("Using absolute core path: %s", resolvedArg)
-				args = append(args, resolvedArg)
-			} else {
-				resolvedPath := filepath.Join(emuDir, resolvedArg)
-				logDebug("Joining relative path with emuDir: %s", resolvedPath)
-
-				// On Linux, verify core file exists
-				if runtime.GOOS == "linux" && strings.HasSuffix(strings.ToLower(resolvedPath), ".so") {
-					if !fileExists(resolvedPath) {
-						logDebug("ERROR: Core file not found: %s", resolvedPath)
-						a.statusBar.SetText(fmt.Sprintf("Core not found: %s", filepath.Base(resolvedPath)))
-						return
-					}
-					logDebug("Core file found: %s", resolvedPath)
-				}
-
-				args = append(args, resolvedPath)
-			}
-		} else {
-			args = append(args, arg)
-		}
+	spec := launchSpec(sysID, game, opt, romPath)
+	if debugLog != nil {
+		// Emulator output goes to the debug log for troubleshooting
+		spec.Output = debugLog
+	}
+	cmd, err := env.Command(spec)
+	var coreErr *launch.CoreNotFoundError
+	if errors.As(err, &coreErr) {
+		logDebug("ERROR: Core file not found: %s", coreErr.Path)
+		a.statusBar.SetText(fmt.Sprintf("Core not found: %s", filepath.Base(coreErr.Path)))
+		return
+	} else if err != nil {
+		a.statusBar.SetText(fmt.Sprintf("Launch failed: %v", err))
+		return
 	}
-	args = append(args, romPath)
 
 	// Log launch command for debugging
-	logDebug("Launch command: %s %v", emuPath, args)
-	logDebug("Emulator directory: %s", emuDir)
-	logDebug("ROM path: %s", romPath)
-
-	cmd := exec.Command(emuPath, args...)
-	if !isFlatpak {
-		// On Linux, for AppImages (standalone emulators), use base directory as working dir
-		// This fixes issues with Cemu and other AppImages that need to run from the project root
-		if runtime.GOOS == "linux" && strings.HasSuffix(strings.ToLower(emuPath), ".appimage") {
-			cmd.Dir = baseDir
-			logDebug("Using base directory as working dir for AppImage: %s", baseDir)
-		} else {
-			cmd.Dir = emuDir
-		}
-	}
+	logDebug("Launch command: %s %v", cmd.Path, cmd.Args[1:])
 	logDebug("Working directory: %s", cmd.Dir)
-
-	// On Linux, set environment variables to fix AppImage compatibility
-	if runtime.GOOS == "linux" {
-		// Force SDL to use X11 instead of Wayland (fixes EGL symbol errors)
-		cmd.Env = append(os.Environ(),
-			"SDL_VIDEODRIVER=x11",
-			"QT_QPA_PLATFORM=xcb",
-		)
-
-		// Capture stderr to debug log for troubleshooting
-		if debugLog != nil {
-			cmd.Stderr = debugLog
-			cmd.Stdout = debugLog
-		}
-	}
+	logDebug("ROM path: %s", romPath)
 
 	if err := cmd.Start(); err != nil {
 		logDebug("Failed to start: %v", err)
@@ -2197,244 +2516,38 @@ func (a *App) launchWithEmulator(game ROM, emuPath string, emuArgs []string) {
 	a.gameRunning = true
 	logDebug("Game launched - controller input disabled in launcher")
 
-	// On Linux, check if process exits immediately (indicates error)
-	if runtime.GOOS == "linux" {
-		go func() {
-			err := cmd.Wait()
-			if err != nil {
-				logDebug("Process exited with error: %v", err)
-			}
-			// Re-enable controller input when game exits
-			a.gameRunning = false
-			logDebug("Game exited - controller input re-enabled in launcher")
-		}()
-	}
-
-	a.statusBar.SetText("Launched: " + game.Name)
-}
-
-func (a *App) downloadGame(game ROM) {
-	config := systems[a.currentSystem]
-	romDir := filepath.Join(romsDir, config.Dir)
-	os.MkdirAll(romDir, 0755)
-
-	// Debug logging
-	debugLog, _ := os.OpenFile(filepath.Join(baseDir, "launcher_debug.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if debugLog != nil {
-		debugLog.WriteString(fmt.Sprintf("[%s] downloadGame: Name=%s, TitleID=%s, SpecialDownload=%s\n", 
-			time.Now().Format("15:04:05"), game.Name, game.TitleID, config.SpecialDownload))
-		debugLog.Close()
-	}
-
-	// Handle Wii U special download
-	if config.SpecialDownload == "wiiu" && game.TitleID != "" {
-		a.downloadWiiUGame(game)
-		return
-	}
-
-	outputPath := filepath.Join(romDir, game.Name)
-
-	progressBar := widget.NewProgressBar()
-	progressLabel := widget.NewLabel("Starting download...")
-
-	progressContent := container.NewVBox(
-		widget.NewLabel(game.Name),
-		progressBar,
-		progressLabel,
-	)
-
-	progressDialog := dialog.NewCustom("Downloading", "Cancel", progressContent, a.window)
-	cancelled := false
-	progressDialog.SetOnClosed(func() {
-		cancelled = true
-	})
-	progressDialog.Show()
-
+	// Wait for the emulator to exit to record the session
 	go func() {
-		err := downloadWithProgress(game.URL, outputPath, func(downloaded, total int64) {
-			if cancelled {
-				return
-			}
-			if total > 0 {
-				pct := float64(downloaded) / float64(total)
-				progressBar.SetValue(pct)
-				progressLabel.SetText(fmt.Sprintf("%.1f MB / %.1f MB", float64(downloaded)/1024/1024, float64(total)/1024/1024))
-			}
-		})
-
-		if cancelled {
-			os.Remove(outputPath)
-			return
-		}
-
+		err := cmd.Wait()
 		if err != nil {
-			progressDialog.Hide()
-			dialog.ShowError(err, a.window)
-			return
+			logDebug("Process exited with error: %v", err)
 		}
+		session.finish(cmd, err)
 
-		// Extract if needed
-		if config.NeedsExtract && strings.HasSuffix(game.Name, ".zip") {
-			progressLabel.SetText("Extracting...")
-			extractZip(outputPath, romDir)
-			os.Remove(outputPath)
-		}
-
-		progressDialog.Hide()
-		a.romCache[game.Name] = true
+		// Re-enable controller input when game exits
+		a.gameRunning = false
+		logDebug("Game exited - controller input re-enabled in launcher")
 		a.gameList.Refresh()
-		a.statusBar.SetText("Downloaded: " + game.Name)
 	}()
-}
-
-// WiiUProgressReporter implements the wiiu.ProgressReporter interface
-type WiiUProgressReporter struct {
-	progressBar    *widget.ProgressBar
-	progressLabel  *widget.Label
-	downloadLabel  *widget.Label
-	gameTitle      string
-	cancelled      bool
-	downloadSize   int64
-	mu             sync.Mutex
-	fileProgress   map[string]int64
-	totalDownloaded int64
-	startTime      time.Time
-}
 
-func NewWiiUProgressReporter(progressBar *widget.ProgressBar, progressLabel, downloadLabel *widget.Label) *WiiUProgressReporter {
-	return &WiiUProgressReporter{
-		progressBar:   progressBar,
-		progressLabel: progressLabel,
-		downloadLabel: downloadLabel,
-		fileProgress:  make(map[string]int64),
-	}
+	a.statusBar.SetText("Launched: " + game.Name)
 }
 
-func (r *WiiUProgressReporter) SetGameTitle(title string) {
-	r.mu.Lock()
-	r.gameTitle = title
-	r.mu.Unlock()
-}
+// downloadGame adds a game to the background download queue
+func (a *App) downloadGame(game ROM) {
+	sysID := a.gameSystem(game)
+	logDebug("downloadGame: Name=%s, TitleID=%s, System=%s", game.Name, game.TitleID, sysID)
 
-func (r *WiiUProgressReporter) UpdateDownloadProgress(downloaded int64, filename string) {
-	r.mu.Lock()
-	r.fileProgress[filename] = downloaded
-	var total int64
-	for _, v := range r.fileProgress {
-		total += v
+	if len(game.discs) > 0 {
+		a.downloadDiscs(sysID, game)
+		return
 	}
-	r.totalDownloaded = total
-	r.mu.Unlock()
 
-	if r.downloadSize > 0 {
-		pct := float64(total) / float64(r.downloadSize)
-		r.progressBar.SetValue(pct)
-		r.progressLabel.SetText(fmt.Sprintf("%.1f MB / %.1f MB", float64(total)/1024/1024, float64(r.downloadSize)/1024/1024))
+	if !a.downloads.Enqueue(sysID, game) {
+		a.statusBar.SetText("Already in download queue: " + game.Name)
+		return
 	}
-}
-
-func (r *WiiUProgressReporter) UpdateDecryptionProgress(progress float64) {
-	r.progressBar.SetValue(progress)
-	r.progressLabel.SetText(fmt.Sprintf("Decrypting... %.0f%%", progress*100))
-}
-
-func (r *WiiUProgressReporter) Cancelled() bool {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	return r.cancelled
-}
-
-func (r *WiiUProgressReporter) SetCancelled() {
-	r.mu.Lock()
-	r.cancelled = true
-	r.mu.Unlock()
-}
-
-func (r *WiiUProgressReporter) SetDownloadSize(size int64) {
-	r.mu.Lock()
-	r.downloadSize = size
-	r.mu.Unlock()
-}
-
-func (r *WiiUProgressReporter) ResetTotals() {
-	r.mu.Lock()
-	r.fileProgress = make(map[string]int64)
-	r.totalDownloaded = 0
-	r.mu.Unlock()
-}
-
-func (r *WiiUProgressReporter) MarkFileAsDone(filename string) {
-	// No-op for now
-}
-
-func (r *WiiUProgressReporter) SetTotalDownloadedForFile(filename string, downloaded int64) {
-	r.mu.Lock()
-	r.fileProgress[filename] = downloaded
-	r.mu.Unlock()
-}
-
-func (r *WiiUProgressReporter) SetStartTime(startTime time.Time) {
-	r.mu.Lock()
-	r.startTime = startTime
-	r.mu.Unlock()
-}
-
-func (a *App) downloadWiiUGame(game ROM) {
-	config := systems[a.currentSystem]
-	
-	// Use a sanitized directory name based on the game name
-	sanitizedName := strings.Map(func(r rune) rune {
-		if r == '/' || r == '\\' || r == ':' || r == '*' || r == '?' || r == '"' || r == '<' || r == '>' || r == '|' {
-			return '_'
-		}
-		return r
-	}, game.Name)
-	
-	romDir := filepath.Join(romsDir, config.Dir, sanitizedName)
-	os.MkdirAll(romDir, 0755)
-
-	progressBar := widget.NewProgressBar()
-	progressLabel := widget.NewLabel("Starting download...")
-	downloadLabel := widget.NewLabel(game.Name)
-
-	progressContent := container.NewVBox(
-		downloadLabel,
-		progressBar,
-		progressLabel,
-	)
-
-	progressDialog := dialog.NewCustom("Downloading Wii U Title", "Cancel", progressContent, a.window)
-	reporter := NewWiiUProgressReporter(progressBar, progressLabel, downloadLabel)
-
-	progressDialog.SetOnClosed(func() {
-		reporter.SetCancelled()
-	})
-	progressDialog.Show()
-
-	go func() {
-		client := &http.Client{Timeout: 0} // No timeout for large downloads
-
-		// Download and decrypt
-		progressLabel.SetText("Downloading from Nintendo CDN...")
-		err := wiiu.DownloadTitle(game.TitleID, romDir, true, reporter, true, client)
-
-		if reporter.Cancelled() {
-			os.RemoveAll(romDir)
-			return
-		}
-
-		if err != nil {
-			progressDialog.Hide()
-			dialog.ShowError(err, a.window)
-			os.RemoveAll(romDir)
-			return
-		}
-
-		progressDialog.Hide()
-		a.romCache[game.Name] = true
-		a.gameList.Refresh()
-		a.statusBar.SetText("Downloaded: " + game.Name)
-	}()
+	a.statusBar.SetText("Queued for download: " + game.Name)
 }
 
 // Parallel download configuration
@@ -2444,7 +2557,10 @@ const (
 	maxChunkRetries    = 3               // Retries per chunk on failure
 )
 
-func downloadWithProgress(url, outputPath string, progress func(downloaded, total int64)) error {
+// downloadWithProgress downloads url to outputPath. Data is written to a .part
+// file with a state sidecar (see download_state.go), so a failed or cancelled
+// download resumes where it stopped the next time it is started.
+func downloadWithProgress(ctx context.Context, url, outputPath string, progress func(downloaded, total int64)) error {
 	// First, get file size and check for Range support
 	transport := &http.Transport{
 		MaxIdleConns:        100,
@@ -2458,7 +2574,7 @@ func downloadWithProgress(url, outputPath string, progress func(downloaded, tota
 	client := &http.Client{Transport: transport}
 
 	// HEAD request to get file info
-	headReq, err := http.NewRequest("HEAD", url, nil)
+	headReq, err := http.NewRequestWithContext(ctx, "HEAD", url, nil)
 	if err != nil {
 		return err
 	}
@@ -2472,25 +2588,59 @@ func downloadWithProgress(url, outputPath string, progress func(downloaded, tota
 
 	totalSize := headResp.ContentLength
 	supportsRange := headResp.Header.Get("Accept-Ranges") == "bytes"
+	etag := headResp.Header.Get("ETag")
+	lastModified := headResp.Header.Get("Last-Modified")
+
+	// Pick up a previous partial download if the remote file hasn't changed
+	state, err := loadDownloadState(outputPath)
+	if err == nil && supportsRange && state.matches(url, totalSize, etag, lastModified) {
+		logDebug("Resuming download: %s (%d of %d bytes present)", outputPath, state.downloaded(), totalSize)
+	} else {
+		if err == nil {
+			logDebug("Partial download can't be resumed, starting over: %s", outputPath)
+		}
+		discardPartialDownload(outputPath)
+		state = newDownloadState(outputPath, url, totalSize, etag, lastModified)
+	}
 
 	// Use parallel download for large files that support Range requests
 	if supportsRange && totalSize > minChunkSize*2 {
-		return downloadParallel(client, url, outputPath, totalSize, progress)
+		err = downloadParallel(ctx, client, url, outputPath, state, progress)
+	} else {
+		// Fall back to single-threaded download
+		err = downloadSingle(ctx, client, url, outputPath, state, supportsRange, progress)
 	}
 
-	// Fall back to single-threaded download
-	return downloadSingle(client, url, outputPath, progress)
+	if err != nil {
+		// Keep resumable partials around, drop anything else
+		if errors.Is(err, errRemoteChanged) || !hasPartialDownload(outputPath) {
+			discardPartialDownload(outputPath)
+		}
+		return err
+	}
+	return finishDownload(outputPath)
 }
 
-func downloadParallel(client *http.Client, url, outputPath string, totalSize int64, progress func(downloaded, total int64)) error {
-	// Calculate chunk size
-	chunkSize := totalSize / int64(numDownloadWorkers)
-	if chunkSize < minChunkSize {
-		chunkSize = minChunkSize
+func downloadParallel(ctx context.Context, client *http.Client, url, outputPath string, state *downloadState, progress func(downloaded, total int64)) error {
+	totalSize := state.TotalSize
+
+	// Split into chunks, unless we're resuming and already have them
+	if len(state.Chunks) == 0 {
+		chunkSize := totalSize / int64(numDownloadWorkers)
+		if chunkSize < minChunkSize {
+			chunkSize = minChunkSize
+		}
+		for start := int64(0); start < totalSize; start += chunkSize {
+			end := start + chunkSize - 1
+			if end >= totalSize {
+				end = totalSize - 1
+			}
+			state.Chunks = append(state.Chunks, chunkRange{Start: start, End: end})
+		}
 	}
 
-	// Create output file
-	out, err := os.Create(outputPath)
+	// Open the partial file without throwing away what's already there
+	out, err := os.OpenFile(partPathFor(outputPath), os.O_RDWR|os.O_CREATE, 0644)
 	if err != nil {
 		return err
 	}
@@ -2501,36 +2651,46 @@ func downloadParallel(client *http.Client, url, outputPath string, totalSize int
 		return err
 	}
 
-	// Progress tracking - track per-chunk progress to handle retries correctly
-	chunkProgress := make(map[int64]int64) // start position -> bytes downloaded
-	var progressMu sync.Mutex
-	
-	updateProgress := func(chunkStart int64, totalForChunk int64) {
-		progressMu.Lock()
-		chunkProgress[chunkStart] = totalForChunk
-		var total int64
-		for _, v := range chunkProgress {
-			total += v
+	// Save the state periodically and once more on the way out, so a cancel
+	// or a failed chunk leaves something to resume from
+	stopSaving := make(chan struct{})
+	go state.autoSave(out, stopSaving)
+	defer func() {
+		close(stopSaving)
+		if err := state.save(out); err != nil {
+			logDebug("Failed to save download state: %v", err)
 		}
-		progressMu.Unlock()
-		progress(total, totalSize)
+	}()
+
+	// Progress comes from the state so bytes from earlier sessions count too
+	updateProgress := func(chunkIdx int, done int64) {
+		state.setProgress(chunkIdx, done)
+		progress(state.downloaded(), totalSize)
 	}
+	progress(state.downloaded(), totalSize)
 
-	// Create worker pool
-	type chunk struct {
-		start, end int64
+	// Queue only the chunks that still need data
+	var pending []int
+	for i, c := range state.Chunks {
+		if !c.complete() {
+			pending = append(pending, i)
+		}
 	}
-	chunks := make(chan chunk, numDownloadWorkers*2)
-	errChan := make(chan error, numDownloadWorkers)
-	var wg sync.WaitGroup
+	chunks := make(chan int, len(pending))
+	for _, i := range pending {
+		chunks <- i
+	}
+	close(chunks)
 
 	// Start workers
+	errChan := make(chan error, numDownloadWorkers)
+	var wg sync.WaitGroup
 	for i := 0; i < numDownloadWorkers; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for c := range chunks {
-				if err := downloadChunk(client, url, out, c.start, c.end, updateProgress); err != nil {
+			for idx := range chunks {
+				if err := downloadChunk(ctx, client, url, out, state, idx, updateProgress); err != nil {
 					errChan <- err
 					return
 				}
@@ -2538,16 +2698,6 @@ func downloadParallel(client *http.Client, url, outputPath string, totalSize int
 		}()
 	}
 
-	// Queue chunks
-	for start := int64(0); start < totalSize; start += chunkSize {
-		end := start + chunkSize - 1
-		if end >= totalSize {
-			end = totalSize - 1
-		}
-		chunks <- chunk{start, end}
-	}
-	close(chunks)
-
 	// Wait for completion
 	wg.Wait()
 	close(errChan)
@@ -2555,7 +2705,6 @@ func downloadParallel(client *http.Client, url, outputPath string, totalSize int
 	// Check for errors
 	for err := range errChan {
 		if err != nil {
-			os.Remove(outputPath)
 			return err
 		}
 	}
@@ -2563,32 +2712,50 @@ func downloadParallel(client *http.Client, url, outputPath string, totalSize int
 	return nil
 }
 
-func downloadChunk(client *http.Client, url string, out *os.File, start, end int64, updateProgress func(chunkStart int64, totalForChunk int64)) error {
+func downloadChunk(ctx context.Context, client *http.Client, url string, out *os.File, state *downloadState, idx int, updateProgress func(chunkIdx int, done int64)) error {
 	var lastErr error
 	
 	for attempt := 0; attempt < maxChunkRetries; attempt++ {
 		if attempt > 0 {
-			time.Sleep(time.Duration(attempt) * time.Second) // Backoff: 1s, 2s
-			// Reset progress for this chunk on retry
-			updateProgress(start, 0)
+			// Backoff: 1s, 2s
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt) * time.Second):
+			}
 		}
 		
-		err := downloadChunkAttempt(client, url, out, start, end, updateProgress)
+		// Each attempt continues from whatever the previous one managed to write
+		err := downloadChunkAttempt(ctx, client, url, out, state, idx, updateProgress)
 		if err == nil {
 			return nil
 		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(err, errRemoteChanged) {
+			return err
+		}
 		lastErr = err
 	}
-	return fmt.Errorf("chunk %d-%d failed after %d retries: %w", start, end, maxChunkRetries, lastErr)
+	c := state.chunk(idx)
+	return fmt.Errorf("chunk %d-%d failed after %d retries: %w", c.Start, c.End, maxChunkRetries, lastErr)
 }
 
-func downloadChunkAttempt(client *http.Client, url string, out *os.File, start, end int64, updateProgress func(chunkStart int64, totalForChunk int64)) error {
-	req, err := http.NewRequest("GET", url, nil)
+func downloadChunkAttempt(ctx context.Context, client *http.Client, url string, out *os.File, state *downloadState, idx int, updateProgress func(chunkIdx int, done int64)) error {
+	c := state.chunk(idx)
+	if c.complete() {
+		return nil
+	}
+	start := c.Start + c.Done
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return err
 	}
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, c.End))
+	state.setResumeHeaders(req)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -2596,13 +2763,17 @@ func downloadChunkAttempt(client *http.Client, url string, out *os.File, start,
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP %d for range %d-%d", resp.StatusCode, start, end)
+	// A full response to a conditional range request means the file changed
+	if resp.StatusCode == http.StatusOK {
+		return errRemoteChanged
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("HTTP %d for range %d-%d", resp.StatusCode, start, c.End)
 	}
 
 	buf := make([]byte, 256*1024) // 256KB read buffer
 	pos := start
-	var chunkDownloaded int64
+	chunkDownloaded := c.Done
 	for {
 		n, err := resp.Body.Read(buf)
 		if n > 0 {
@@ -2612,7 +2783,7 @@ func downloadChunkAttempt(client *http.Client, url string, out *os.File, start,
 			}
 			pos += int64(n)
 			chunkDownloaded += int64(n)
-			updateProgress(start, chunkDownloaded)
+			updateProgress(idx, chunkDownloaded)
 		}
 		if err == io.EOF {
 			break
@@ -2624,14 +2795,28 @@ func downloadChunkAttempt(client *http.Client, url string, out *os.File, start,
 	return nil
 }
 
-func downloadSingle(client *http.Client, url, outputPath string, progress func(downloaded, total int64)) error {
-	req, err := http.NewRequest("GET", url, nil)
+func downloadSingle(ctx context.Context, client *http.Client, url, outputPath string, state *downloadState, supportsRange bool, progress func(downloaded, total int64)) error {
+	// Only a file of known size from a server that takes Range requests can be resumed
+	resumable := supportsRange && state.TotalSize > 0
+	if len(state.Chunks) == 0 {
+		state.Chunks = []chunkRange{{Start: 0, End: state.TotalSize - 1}}
+	}
+	var offset int64
+	if resumable {
+		offset = state.Chunks[0].Done
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return err
 	}
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
 	req.Header.Set("Accept", "*/*")
 	req.Header.Set("Connection", "keep-alive")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		state.setResumeHeaders(req)
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -2639,28 +2824,58 @@ func downloadSingle(client *http.Client, url, outputPath string, progress func(d
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
+	switch {
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+	case resp.StatusCode == 200:
+		// Whole file - either a fresh download or the server wouldn't resume
+		offset = 0
+	default:
 		return fmt.Errorf("HTTP %d", resp.StatusCode)
 	}
 
-	out, err := os.Create(outputPath)
+	flags := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	}
+	out, err := os.OpenFile(partPathFor(outputPath), flags, 0644)
 	if err != nil {
 		return err
 	}
 	defer out.Close()
+	if _, err := out.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
 
-	bufferedOut := bufio.NewWriterSize(out, 1024*1024)
-	defer bufferedOut.Flush()
+	if resumable {
+		stopSaving := make(chan struct{})
+		go state.autoSave(out, stopSaving)
+		defer func() {
+			close(stopSaving)
+			if err := state.save(out); err != nil {
+				logDebug("Failed to save download state: %v", err)
+			}
+		}()
+	}
 
 	total := resp.ContentLength
-	var downloaded int64
+	if total >= 0 {
+		total += offset
+	}
+	downloaded := offset
 
+	// Writes go straight to the file (no bufio) so the saved state never
+	// counts bytes still sitting in a buffer
 	buf := make([]byte, 1024*1024)
 	for {
 		n, err := resp.Body.Read(buf)
 		if n > 0 {
-			bufferedOut.Write(buf[:n])
+			if _, writeErr := out.Write(buf[:n]); writeErr != nil {
+				return writeErr
+			}
 			downloaded += int64(n)
+			if resumable {
+				state.setProgress(0, downloaded)
+			}
 			progress(downloaded, total)
 		}
 		if err == io.EOF {
@@ -2673,42 +2888,6 @@ func downloadSingle(client *http.Client, url, outputPath string, progress func(d
 	return nil
 }
 
-func extractZip(zipPath, destDir string) (string, error) {
-	r, err := zip.OpenReader(zipPath)
-	if err != nil {
-		return "", err
-	}
-	defer r.Close()
-
-	var extractedFile string
-	for _, f := range r.File {
-		if f.FileInfo().IsDir() {
-			continue
-		}
-
-		destPath := filepath.Join(destDir, f.Name)
-		os.MkdirAll(filepath.Dir(destPath), 0755)
-
-		outFile, err := os.Create(destPath)
-		if err != nil {
-			continue
-		}
-
-		rc, err := f.Open()
-		if err != nil {
-			outFile.Close()
-			continue
-		}
-
-		io.Copy(outFile, rc)
-		outFile.Close()
-		rc.Close()
-
-		extractedFile = destPath
-	}
-	return extractedFile, nil
-}
-
 // Ensure Windows doesn't need console
 func init() {
 	if runtime.GOOS == "windows" {
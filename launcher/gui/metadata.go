@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Game metadata
+//
+// game_metadata/<system>_enriched.json is written by scrape_game_metadata.py:
+// the catalog of a system with RAWG data added to each entry that could be
+// matched. Entries are joined with the catalog by name.
+
+// GameMetadata is the RAWG data of a game
+type GameMetadata struct {
+	RawgID      int      `json:"rawg_id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Released    string   `json:"released"` // YYYY-MM-DD
+	Rating      float64  `json:"rating"`
+	Metacritic  int      `json:"metacritic"`
+	Genres      []string `json:"genres"`
+	Tags        []string `json:"tags"`
+	Platforms   []string `json:"platforms"`
+	Developers  []string `json:"developers"`
+	Publishers  []string `json:"publishers"`
+	RawgURL     string   `json:"rawg_url"`
+}
+
+// enrichedROM is an entry of an _enriched.json file
+type enrichedROM struct {
+	Name     string        `json:"name"`
+	Metadata *GameMetadata `json:"metadata"`
+}
+
+var metadataCache = make(map[string]map[string]*GameMetadata) // system -> lowercase name -> metadata
+var metadataMu sync.Mutex
+
+// loadGameMetadata reads the metadata of a system, indexed by lowercase name
+// with and without the archive extension. Systems without a file have none.
+func loadGameMetadata(systemID string) map[string]*GameMetadata {
+	metadataMu.Lock()
+	defer metadataMu.Unlock()
+	if index, ok := metadataCache[systemID]; ok {
+		return index
+	}
+
+	index := make(map[string]*GameMetadata)
+	metadataCache[systemID] = index
+
+	data, err := os.ReadFile(filepath.Join(baseDir, "game_metadata", systemID+"_enriched.json"))
+	if err != nil {
+		return index
+	}
+	var entries []enrichedROM
+	if err := json.Unmarshal(data, &entries); err != nil {
+		logDebug("Metadata for %s ignored: %v", systemID, err)
+		return index
+	}
+	for _, entry := range entries {
+		if entry.Metadata == nil {
+			continue
+		}
+		index[strings.ToLower(entry.Name)] = entry.Metadata
+		index[strings.ToLower(catalogBaseName(entry.Name))] = entry.Metadata
+	}
+	logDebug("Loaded metadata for %d %s games", len(entries), systemID)
+	return index
+}
+
+// gameMetadata returns the metadata of a catalog entry, nil if there is none
+func gameMetadata(systemID, gameName string) *GameMetadata {
+	index := loadGameMetadata(systemID)
+	if meta, ok := index[strings.ToLower(gameName)]; ok {
+		return meta
+	}
+	return index[strings.ToLower(catalogBaseName(gameName))]
+}
+
+// Year returns the release year, "" if unknown
+func (m *GameMetadata) Year() string {
+	if len(m.Released) < 4 {
+		return ""
+	}
+	return m.Released[:4]
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/emubuddy/gui/launch"
+)
+
+// "More like this" recommendations
+//
+// Games are compared as vectors. game_metadata/game_embeddings.json holds
+// vectors made from the dataset of build_embeddings.py (see
+// GAME_METADATA_README.md). Games without one are compared by TF-IDF vectors
+// of their genres, tags and developers from the enriched metadata. Vectors
+// belong to RAWG games, so a game released on several systems is recommended
+// on each of them.
+
+// embeddingsFile is the precomputed vector file in game_metadata
+const embeddingsFile = "game_embeddings.json"
+
+// maxSimilarGames is how many games "more like this" lists
+const maxSimilarGames = 30
+
+// embeddingEntry is an entry of the vector file: an entry of the embedding
+// dataset with its "embedding" added. Other fields are ignored.
+type embeddingEntry struct {
+	Embedding []float64 `json:"embedding"`
+	Metadata  struct {
+		RawgID int `json:"rawg_id"`
+	} `json:"metadata"`
+}
+
+// recommender answers nearest-neighbour queries over the RAWG games of all
+// systems. Vectors are normalised, so the dot product is the cosine similarity.
+type recommender struct {
+	ids    []int                      // RAWG IDs, sorted
+	dense  map[int][]float64          // Embeddings
+	sparse map[int]map[string]float64 // TF-IDF of genres, tags and developers
+	games  map[int][]ROM              // Games that can be played, with systemID set
+}
+
+var recommendations *recommender
+var recommendationsOnce sync.Once
+var recommendationsMu sync.Mutex
+
+// buildRecommendations loads the vectors of all games. It only does the work
+// once and may be called from several goroutines.
+func buildRecommendations() {
+	recommendationsOnce.Do(func() {
+		buildSearchIndex()
+		games := make([]ROM, len(searchIndex))
+		for i := range searchIndex {
+			games[i] = searchIndex[i].game
+		}
+
+		env := launch.NewEnv(baseDir)
+		playable := make(map[string]bool) // system -> has an installed emulator
+		for _, sysID := range systemsList {
+			for _, opt := range emulatorOptions(systems[sysID]) {
+				if env.Installed(opt.Emulator.Location()) {
+					playable[sysID] = true
+					break
+				}
+			}
+		}
+		canPlay := func(game ROM) bool {
+			return playable[game.systemID] && (game.URL != "" || game.TitleID != "")
+		}
+
+		r := newRecommender(games, func(game ROM) *GameMetadata {
+			return gameMetadata(game.systemID, game.Name)
+		}, canPlay)
+		r.loadEmbeddings(filepath.Join(baseDir, "game_metadata", embeddingsFile))
+		logDebug("Recommendations: %d games, %d with embeddings", len(r.ids), len(r.dense))
+
+		recommendationsMu.Lock()
+		recommendations = r
+		recommendationsMu.Unlock()
+	})
+}
+
+// readyRecommendations returns the recommender, nil while it's being built
+func readyRecommendations() *recommender {
+	recommendationsMu.Lock()
+	defer recommendationsMu.Unlock()
+	return recommendations
+}
+
+// newRecommender builds TF-IDF vectors for the games that have metadata.
+// Only games passing canPlay are recommended.
+func newRecommender(games []ROM, metaOf func(ROM) *GameMetadata, canPlay func(ROM) bool) *recommender {
+	r := &recommender{
+		dense:  make(map[int][]float64),
+		sparse: make(map[int]map[string]float64),
+		games:  make(map[int][]ROM),
+	}
+
+	terms := make(map[int][]string)
+	for _, game := range games {
+		meta := metaOf(game)
+		if meta == nil {
+			continue
+		}
+		id := meta.RawgID
+		if _, ok := terms[id]; !ok {
+			terms[id] = metadataTerms(meta)
+			r.ids = append(r.ids, id)
+		}
+		if canPlay(game) {
+			r.games[id] = append(r.games[id], game)
+		}
+	}
+	sort.Ints(r.ids)
+
+	df := make(map[string]int)
+	for _, t := range terms {
+		for _, term := range t {
+			df[term]++
+		}
+	}
+	n := float64(len(terms))
+	for id, t := range terms {
+		vec := make(map[string]float64, len(t))
+		for _, term := range t {
+			vec[term] = math.Log(n / float64(df[term]))
+		}
+		normalizeSparse(vec)
+		r.sparse[id] = vec
+	}
+	return r
+}
+
+// metadataTerms returns the distinct genres, tags and developers of a game
+func metadataTerms(meta *GameMetadata) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	add := func(prefix string, values []string) {
+		for _, v := range values {
+			if term := prefix + v; !seen[term] {
+				seen[term] = true
+				terms = append(terms, term)
+			}
+		}
+	}
+	add("genre:", meta.Genres)
+	add("tag:", meta.Tags)
+	add("developer:", meta.Developers)
+	return terms
+}
+
+// loadEmbeddings reads the vector file, if there is one. Vectors whose
+// length differs from the first one's are skipped.
+func (r *recommender) loadEmbeddings(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var entries []embeddingEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		logDebug("Embeddings ignored: %v", err)
+		return
+	}
+	dims := 0
+	for _, entry := range entries {
+		if len(entry.Embedding) == 0 {
+			continue
+		}
+		if dims == 0 {
+			dims = len(entry.Embedding)
+		}
+		if len(entry.Embedding) != dims {
+			logDebug("Embedding of RAWG game %d skipped: %d dimensions, not %d", entry.Metadata.RawgID, len(entry.Embedding), dims)
+			continue
+		}
+		if normalizeDense(entry.Embedding) {
+			r.dense[entry.Metadata.RawgID] = entry.Embedding
+		}
+	}
+}
+
+// similar returns up to limit playable games most like a game, best first.
+// Other releases of the same game aren't included.
+func (r *recommender) similar(meta *GameMetadata, limit int) []ROM {
+	type neighbour struct {
+		id    int
+		score float64
+	}
+	var neighbours []neighbour
+
+	// Embeddings and TF-IDF scores aren't comparable, so a game with an
+	// embedding is only compared with others that have one
+	if vec, ok := r.dense[meta.RawgID]; ok {
+		for id, other := range r.dense {
+			if id != meta.RawgID && len(r.games[id]) > 0 {
+				neighbours = append(neighbours, neighbour{id, dotDense(vec, other)})
+			}
+		}
+	} else {
+		vec := r.sparse[meta.RawgID]
+		if vec == nil {
+			vec = make(map[string]float64)
+			for _, term := range metadataTerms(meta) {
+				vec[term] = 1
+			}
+			normalizeSparse(vec)
+		}
+		for _, id := range r.ids {
+			if id != meta.RawgID && len(r.games[id]) > 0 {
+				if score := dotSparse(vec, r.sparse[id]); score > 0 {
+					neighbours = append(neighbours, neighbour{id, score})
+				}
+			}
+		}
+	}
+
+	sort.Slice(neighbours, func(i, j int) bool {
+		if neighbours[i].score != neighbours[j].score {
+			return neighbours[i].score > neighbours[j].score
+		}
+		return neighbours[i].id < neighbours[j].id
+	})
+
+	var games []ROM
+	for _, n := range neighbours {
+		for _, game := range r.games[n.id] {
+			if len(games) == limit {
+				return games
+			}
+			games = append(games, game)
+		}
+	}
+	return games
+}
+
+// similarGames returns games like the given one, nil if the recommender
+// isn't ready or the game has no metadata
+func similarGames(systemID string, game ROM, limit int) []ROM {
+	r := readyRecommendations()
+	meta := gameMetadata(systemID, game.Name)
+	if r == nil || meta == nil {
+		return nil
+	}
+	return r.similar(meta, limit)
+}
+
+func normalizeDense(vec []float64) bool {
+	sum := 0.0
+	for _, v := range vec {
+		sum += v * v
+	}
+	if sum == 0 {
+		return false
+	}
+	norm := math.Sqrt(sum)
+	for i := range vec {
+		vec[i] /= norm
+	}
+	return true
+}
+
+func normalizeSparse(vec map[string]float64) {
+	sum := 0.0
+	for _, v := range vec {
+		sum += v * v
+	}
+	if sum == 0 {
+		return
+	}
+	norm := math.Sqrt(sum)
+	for term := range vec {
+		vec[term] /= norm
+	}
+}
+
+func dotDense(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func dotSparse(a, b map[string]float64) float64 {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	sum := 0.0
+	for term, v := range a {
+		sum += v * b[term]
+	}
+	return sum
+}
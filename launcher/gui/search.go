@@ -0,0 +1,238 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Search across all systems
+//
+// The search box looks through the catalogs of every system. Names are split
+// into words with their (USA), (Rev 1) style tags removed, and each word of
+// the query has to match a word of the name: exactly, as a prefix, as the
+// initials of a subtitle ("lttp" for "A Link to the Past") or with a typo or
+// two ("emrald"). Results are ranked by how well they match.
+
+// maxSearchResults is how many games a search shows
+const maxSearchResults = 200
+
+// searchEntry is a catalog game in the search index
+type searchEntry struct {
+	game     ROM // With systemID set
+	words    []string
+	initials []string
+}
+
+var searchIndex []searchEntry
+var searchIndexOnce sync.Once
+
+// buildSearchIndex indexes the catalogs of all systems. It only does the work
+// once and may be called from several goroutines.
+func buildSearchIndex() {
+	searchIndexOnce.Do(func() {
+		for _, sysID := range systemsList {
+			games, err := loadCatalog(sysID)
+			if err != nil {
+				logDebug("Search: skipping %s: %v", sysID, err)
+				continue
+			}
+			for _, game := range games {
+				game.systemID = sysID
+				title := stripROMTags(catalogBaseName(game.Name))
+				searchIndex = append(searchIndex, searchEntry{
+					game:     game,
+					words:    searchWords(title),
+					initials: titleInitials(title),
+				})
+			}
+		}
+		logDebug("Search: indexed %d games", len(searchIndex))
+	})
+}
+
+// accentFolder maps accented letters to plain ones so "pokemon" finds "Pokémon"
+var accentFolder = strings.NewReplacer(
+	"é", "e", "è", "e", "ê", "e", "ë", "e", "á", "a", "à", "a", "â", "a", "ä", "a",
+	"í", "i", "ï", "i", "ó", "o", "ô", "o", "ö", "o", "ú", "u", "ü", "u", "ñ", "n", "ç", "c",
+)
+
+// searchWords splits text into lowercase words of letters and digits
+func searchWords(text string) []string {
+	text = accentFolder.Replace(strings.ToLower(text))
+	return strings.FieldsFunc(strings.ReplaceAll(text, "'", ""), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// titleInitials returns the initials of a title and of each of its parts
+// split on " - " or ":", with and without a leading article. "Legend of Zelda,
+// The - A Link to the Past" gives lozt, loztalttp, alttp and lttp among others.
+func titleInitials(title string) []string {
+	parts := []string{title}
+	for _, sep := range []string{" - ", ": "} {
+		var split []string
+		for _, part := range parts {
+			split = append(split, strings.Split(part, sep)...)
+		}
+		parts = split
+	}
+	if len(parts) > 1 {
+		parts = append(parts, title)
+	}
+
+	var initials []string
+	for _, part := range parts {
+		words := searchWords(part)
+		for len(words) > 0 {
+			var b strings.Builder
+			for _, w := range words {
+				b.WriteByte(w[0])
+			}
+			if b.Len() >= 2 && !containsString(initials, b.String()) {
+				initials = append(initials, b.String())
+			}
+			if words[0] != "a" && words[0] != "an" && words[0] != "the" {
+				break
+			}
+			words = words[1:]
+		}
+	}
+	return initials
+}
+
+// matchWord scores how well a query word matches a word of a name, 0 for no match
+func matchWord(query, word string) float64 {
+	switch {
+	case query == word:
+		return 1
+	case len(query) >= 2 && strings.HasPrefix(word, query):
+		return 0.8
+	}
+
+	// Typos: one edit for words of 4 or more letters, two from 8
+	allowed := 0
+	if len(query) >= 8 {
+		allowed = 2
+	} else if len(query) >= 4 {
+		allowed = 1
+	}
+	if allowed == 0 || abs(len(query)-len(word)) > allowed {
+		return 0
+	}
+	if d := editDistance(query, word, allowed); d <= allowed {
+		return 0.7 - 0.1*float64(d)
+	}
+	return 0
+}
+
+// score rates a search entry for the words of a query, 0 if a word doesn't match
+func (e *searchEntry) score(query []string) float64 {
+	total := 0.0
+	lastPos := -2
+	for i, q := range query {
+		best, bestPos := 0.0, -2
+		for j, w := range e.words {
+			s := matchWord(q, w)
+			if s > 0 && (j == lastPos+1 || (i == 0 && j == 0)) {
+				s += 0.1 // Words in the same order as in the name
+			}
+			if s > best {
+				best, bestPos = s, j
+			}
+		}
+		if len(q) >= 2 && containsString(e.initials, q) && best < 0.9 {
+			best, bestPos = 0.9, -2
+		}
+		if best == 0 {
+			return 0
+		}
+		total += best
+		lastPos = bestPos
+	}
+	// Prefer names that don't have many words besides the ones searched for
+	return total/float64(len(query)) - 0.01*float64(len(e.words))
+}
+
+type searchResult struct {
+	entry *searchEntry
+	score float64
+}
+
+// searchGames returns the catalog games best matching a query, best first
+func searchGames(query string, limit int) []ROM {
+	words := searchWords(query)
+	if len(words) == 0 {
+		return nil
+	}
+	buildSearchIndex()
+
+	var results []searchResult
+	for i := range searchIndex {
+		if s := searchIndex[i].score(words); s > 0 {
+			results = append(results, searchResult{&searchIndex[i], s})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].entry.game.Name < results[j].entry.game.Name
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	games := make([]ROM, len(results))
+	for i, r := range results {
+		games[i] = r.entry.game
+	}
+	return games
+}
+
+// editDistance returns the Levenshtein distance of a and b, or max+1 once
+// it's known to be larger than max
+func editDistance(a, b string, max int) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if cur[j] < rowMin {
+				rowMin = cur[j]
+			}
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSearchWords(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Pokémon: Emerald", []string{"pokemon", "emerald"}},
+		{"Tony Hawk's Pro Skater 2", []string{"tony", "hawks", "pro", "skater", "2"}},
+		{"Legend of Zelda, The - A Link to the Past", []string{"legend", "of", "zelda", "the", "a", "link", "to", "the", "past"}},
+		{"Super Mario Bros. 3", []string{"super", "mario", "bros", "3"}},
+		{"  -- ", nil},
+	}
+	for _, tt := range tests {
+		if got := searchWords(tt.text); strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("searchWords(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTitleInitials(t *testing.T) {
+	tests := []struct {
+		title string
+		want  []string
+	}{
+		{"Legend of Zelda, The - A Link to the Past", []string{"lozt", "alttp", "lttp", "loztalttp"}},
+		{"Castlevania: Symphony of the Night", []string{"sotn", "csotn"}},
+		{"Super Mario World", []string{"smw"}},
+		{"Tetris", nil},
+	}
+	for _, tt := range tests {
+		if got := titleInitials(tt.title); strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("titleInitials(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestMatchWord(t *testing.T) {
+	tests := []struct {
+		query, word string
+		want        float64
+	}{
+		{"zelda", "zelda", 1},
+		{"zel", "zelda", 0.8},
+		{"z", "zelda", 0},                   // Prefixes need two letters
+		{"emrald", "emerald", 0.6},          // One edit
+		{"mario", "wario", 0.6},             // One edit
+		{"castelvania", "castlevania", 0.5}, // Two edits from 8 letters
+		{"metriod", "metroid", 0},           // Two edits under 8 letters
+		{"abd", "abc", 0},                   // No typos under 4 letters
+		{"marios", "mario", 0.6},            // One letter too many
+		{"zeldas", "zel", 0},                // Lengths too far apart
+		{"link", "zelda", 0},
+	}
+	for _, tt := range tests {
+		if got := matchWord(tt.query, tt.word); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("matchWord(%q, %q) = %v, want %v", tt.query, tt.word, got, tt.want)
+		}
+	}
+}
+
+// useSearchIndex replaces the search index with the catalog games given, for
+// the length of a test
+func useSearchIndex(t *testing.T, games []ROM) {
+	t.Helper()
+	searchIndexOnce.Do(func() {})
+	saved := searchIndex
+	t.Cleanup(func() { searchIndex = saved })
+
+	searchIndex = nil
+	for _, game := range games {
+		title := stripROMTags(catalogBaseName(game.Name))
+		searchIndex = append(searchIndex, searchEntry{game: game, words: searchWords(title), initials: titleInitials(title)})
+	}
+}
+
+func TestSearchGames(t *testing.T) {
+	useSearchIndex(t, []ROM{
+		{Name: "Legend of Zelda, The (USA).zip", systemID: "nes"},
+		{Name: "Zelda II - The Adventure of Link (USA).zip", systemID: "nes"},
+		{Name: "Legend of Zelda, The - A Link to the Past (USA).zip", systemID: "snes"},
+		{Name: "Pokemon - Emerald Version (USA, Europe).zip", systemID: "gba"},
+		{Name: "Pokemon - Ruby Version (USA, Europe) (Rev 2).zip", systemID: "gba"},
+		{Name: "Super Mario World (USA).zip", systemID: "snes"},
+		{Name: "Super Mario Bros. (World).zip", systemID: "nes"},
+		{Name: "Mario Kart 64 (USA).zip", systemID: "n64"},
+		{Name: "Wario Land - Super Mario Land 3 (World).zip", systemID: "gb"},
+	})
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// Zelda II starts with the word, the others have more words besides
+		{"zelda", []string{
+			"Zelda II - The Adventure of Link (USA).zip",
+			"Legend of Zelda, The (USA).zip",
+			"Legend of Zelda, The - A Link to the Past (USA).zip",
+		}},
+		{"lttp", []string{"Legend of Zelda, The - A Link to the Past (USA).zip"}},
+		{"zelda link", []string{
+			"Zelda II - The Adventure of Link (USA).zip",
+			"Legend of Zelda, The - A Link to the Past (USA).zip",
+		}},
+		{"Pokémon emrald", []string{"Pokemon - Emerald Version (USA, Europe).zip"}},
+		// Names of the same score are sorted
+		{"super mario", []string{
+			"Super Mario Bros. (World).zip",
+			"Super Mario World (USA).zip",
+			"Wario Land - Super Mario Land 3 (World).zip",
+		}},
+		{"mario kart", []string{"Mario Kart 64 (USA).zip"}},
+		{"usa", nil}, // Tags aren't searched
+		{"xyzzy", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, game := range searchGames(tt.query, maxSearchResults) {
+			got = append(got, game.Name)
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%q: got\n\t%s\nwant\n\t%s", tt.query, strings.Join(got, "\n\t"), strings.Join(tt.want, "\n\t"))
+		}
+	}
+
+	if got := searchGames("super mario", 1); len(got) != 1 || got[0].Name != "Super Mario Bros. (World).zip" {
+		t.Errorf("limited to one result: got %v", got)
+	}
+	if got := searchGames("emerald", 1); len(got) != 1 || got[0].systemID != "gba" {
+		t.Errorf("results don't keep their system: got %+v", got)
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// User overrides for systems.json
+//
+// systems.user.json sits next to systems.json and survives updates. Its
+// entries are merged into the shipped systems by ID: objects are merged key
+// by key, anything else (strings, lists) replaces the shipped value. Unknown
+// IDs add new systems. Inside "emulator" and "standaloneEmulator", "addCores"
+// appends cores and "removeCores" drops cores by name:
+//
+//	{
+//	  "systems": [
+//	    {"id": "n64", "dir": "Nintendo 64", "emulator": {"removeCores": ["ParaLLEl N64"]}},
+//	    {"id": "gba", "emulator": {"addCores": [{"name": "gpSP", "dll": "cores/gpsp_libretro.dll"}]}}
+//	  ],
+//	  "remove": ["virtualboy"]
+//	}
+
+const systemsOverlayFile = "systems.user.json"
+
+// systemsOverlay is the format of systems.user.json
+type systemsOverlay struct {
+	Systems       []map[string]interface{} `json:"systems"`
+	Remove        []string                 `json:"remove,omitempty"`
+	ThumbnailsURL string                   `json:"thumbnailsUrl,omitempty"`
+}
+
+// configWarnings collects problems found while loading the systems config.
+// They're reported to the user instead of stopping the launcher.
+var configWarnings []string
+
+func configWarning(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	configWarnings = append(configWarnings, msg)
+	logDebug("Config: %s", msg)
+}
+
+// applySystemsOverlay merges systems.user.json, if there is one, into the
+// shipped systems. Problems with individual entries are recorded as warnings
+// and the entry is skipped.
+func applySystemsOverlay(base []SystemConfig) []SystemConfig {
+	path := filepath.Join(baseDir, systemsOverlayFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return base
+	}
+
+	var overlay systemsOverlay
+	if err := json.Unmarshal(data, &overlay); err != nil {
+		configWarning("%s ignored: %v", systemsOverlayFile, err)
+		return base
+	}
+	if overlay.ThumbnailsURL != "" {
+		thumbnailsURL = overlay.ThumbnailsURL
+	}
+
+	index := make(map[string]int)
+	for i, sys := range base {
+		index[sys.ID] = i
+	}
+
+	for n, entry := range overlay.Systems {
+		id, _ := entry["id"].(string)
+		if id == "" {
+			configWarning("%s: entry %d has no \"id\"", systemsOverlayFile, n+1)
+			continue
+		}
+
+		var merged SystemConfig
+		i, exists := index[id]
+		if exists {
+			merged, err = mergeSystem(base[i], entry)
+		} else {
+			merged, err = mergeSystem(SystemConfig{}, entry)
+		}
+		if err == nil {
+			err = validateSystem(merged)
+		}
+		if err != nil {
+			configWarning("%s: system '%s' ignored: %v", systemsOverlayFile, id, err)
+			continue
+		}
+
+		if exists {
+			base[i] = merged
+		} else {
+			index[id] = len(base)
+			base = append(base, merged)
+		}
+	}
+
+	for _, id := range overlay.Remove {
+		i, ok := index[id]
+		if !ok {
+			configWarning("%s: cannot remove unknown system '%s'", systemsOverlayFile, id)
+			continue
+		}
+		base[i].ID = "" // Dropped below, keeping the indexes valid until then
+	}
+	kept := base[:0]
+	for _, sys := range base {
+		if sys.ID != "" {
+			kept = append(kept, sys)
+		}
+	}
+	return kept
+}
+
+// mergeSystem applies one overlay entry to a system
+func mergeSystem(sys SystemConfig, entry map[string]interface{}) (SystemConfig, error) {
+	data, err := json.Marshal(sys)
+	if err != nil {
+		return sys, err
+	}
+	var current map[string]interface{}
+	if err := json.Unmarshal(data, &current); err != nil {
+		return sys, err
+	}
+
+	for _, key := range []string{"emulator", "standaloneEmulator"} {
+		emu, ok := entry[key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		existing, _ := current[key].(map[string]interface{})
+		if existing == nil {
+			existing = make(map[string]interface{})
+		}
+		if err := applyCoreChanges(existing, emu); err != nil {
+			return sys, fmt.Errorf("%s: %w", key, err)
+		}
+		current[key] = existing
+	}
+	mergeJSON(current, entry)
+
+	data, err = json.Marshal(current)
+	if err != nil {
+		return sys, err
+	}
+	var merged SystemConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields() // Catches misspelled keys
+	if err := dec.Decode(&merged); err != nil {
+		return sys, err
+	}
+	return merged, nil
+}
+
+// applyCoreChanges handles the addCores/removeCores keys of an emulator
+// override and removes them from it
+func applyCoreChanges(emu, override map[string]interface{}) error {
+	cores, _ := emu["cores"].([]interface{})
+
+	if remove, ok := override["removeCores"].([]interface{}); ok {
+		for _, r := range remove {
+			name, _ := r.(string)
+			found := false
+			for i, c := range cores {
+				if core, _ := c.(map[string]interface{}); core != nil && core["name"] == name {
+					cores = append(cores[:i], cores[i+1:]...)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("no core named '%v' to remove", r)
+			}
+		}
+		delete(override, "removeCores")
+	}
+	if add, ok := override["addCores"].([]interface{}); ok {
+		cores = append(cores, add...)
+		delete(override, "addCores")
+	}
+
+	emu["cores"] = cores
+	return nil
+}
+
+// mergeJSON merges src into dst: nested objects are merged, other values replaced
+func mergeJSON(dst, src map[string]interface{}) {
+	for key, value := range src {
+		srcObj, srcIsObj := value.(map[string]interface{})
+		dstObj, dstIsObj := dst[key].(map[string]interface{})
+		if srcIsObj && dstIsObj {
+			mergeJSON(dstObj, srcObj)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// validateSystem checks the fields the launcher can't work without
+func validateSystem(sys SystemConfig) error {
+	var problems []string
+	if sys.Name == "" {
+		problems = append(problems, "missing \"name\"")
+	}
+	if sys.Dir == "" {
+		problems = append(problems, "missing \"dir\"")
+	} else if filepath.IsAbs(sys.Dir) || strings.Contains(filepath.ToSlash(sys.Dir), "..") {
+		problems = append(problems, "\"dir\" must be a folder name inside roms")
+	}
+	if sys.RomJsonFile == "" {
+		problems = append(problems, "missing \"romJsonFile\"")
+	} else if !fileExists(filepath.Join(baseDir, "1g1rsets", sys.RomJsonFile)) {
+		problems = append(problems, fmt.Sprintf("game list 1g1rsets/%s not found", sys.RomJsonFile))
+	}
+	if sys.Emulator.Path == "" {
+		problems = append(problems, "missing \"emulator.path\"")
+	}
+	for _, core := range sys.Emulator.Cores {
+		if core.Name == "" || core.Dll == "" {
+			problems = append(problems, "every core needs \"name\" and \"dll\"")
+			break
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// showConfigWarnings lists the problems found in the systems config in a
+// dialog that stays up until it's dismissed, with any controller button,
+// Enter or Esc
+func (a *App) showConfigWarnings() {
+	if len(configWarnings) == 0 {
+		return
+	}
+	text := fmt.Sprintf("Some systems were skipped or left unchanged. Fix systems.json or %s and restart EmuBuddy:\n\n- %s",
+		systemsOverlayFile, strings.Join(configWarnings, "\n- "))
+	label := widget.NewLabel(text)
+	label.Wrapping = fyne.TextWrapWord
+
+	a.dialogOpen = true
+	d := dialog.NewCustom(fmt.Sprintf("%d problem(s) in the systems config", len(configWarnings)), "OK",
+		container.NewVScroll(label), a.window)
+	d.SetOnClosed(func() {
+		a.dialogOpen = false
+		a.warningsDialog = nil
+	})
+	d.Resize(fyne.NewSize(600, 350))
+	a.warningsDialog = d
+	d.Show()
+}
+
+// showConfigError shows a window explaining why the launcher can't start
+func showConfigError(err error) {
+	myApp := app.New()
+	w := myApp.NewWindow("EmuBuddy")
+	label := widget.NewLabel(fmt.Sprintf("EmuBuddy can't start:\n\n%v\n\nReinstall EmuBuddy or restore systems.json.", err))
+	label.Wrapping = fyne.TextWrapWord
+	w.SetContent(container.NewBorder(nil, widget.NewButton("Quit", myApp.Quit), nil, nil, label))
+	w.Resize(fyne.NewSize(500, 250))
+	w.ShowAndRun()
+}
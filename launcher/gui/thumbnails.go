@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Thumbnails from libretro-thumbnails
+//
+// Box art, screenshots and title screens come from the libretro thumbnail
+// server, laid out like the libretro-thumbnails repository:
+//
+//	<thumbnailsUrl>/<libretroName>/Named_Boxarts/<game name>.png
+//
+// The game name is the catalog name without its archive extension, with the
+// characters &*/:`<>?\|" replaced by "_" as RetroArch does. Thumbnails are
+// downloaded the first time they're shown and kept in thumbnails/ with the
+// same layout. The server can be changed with "thumbnailsUrl" in
+// systems.json or systems.user.json.
+
+// ThumbnailKind is a folder of libretro-thumbnails
+type ThumbnailKind string
+
+const (
+	ThumbBoxart ThumbnailKind = "Named_Boxarts"
+	ThumbSnap   ThumbnailKind = "Named_Snaps"
+	ThumbTitle  ThumbnailKind = "Named_Titles"
+)
+
+const defaultThumbnailsURL = "https://thumbnails.libretro.com"
+
+// maxThumbnailFetches is how many thumbnails can wait to be downloaded. More
+// requests are dropped; they're made again when the game is shown again.
+const maxThumbnailFetches = 32
+
+// thumbnailWorkers is how many thumbnails are downloaded at once
+const thumbnailWorkers = 4
+
+var thumbnailsURL = defaultThumbnailsURL
+var thumbnailsDir string
+
+// thumbnailReplacer applies libretro's rules for thumbnail file names
+var thumbnailReplacer = strings.NewReplacer(
+	"&", "_", "*", "_", "/", "_", ":", "_", "`", "_", "<", "_",
+	">", "_", "?", "_", "\\", "_", "|", "_", "\"", "_",
+)
+
+// thumbnailName returns the libretro thumbnail file name of a catalog entry,
+// without ".png"
+func thumbnailName(gameName string) string {
+	return thumbnailReplacer.Replace(catalogBaseName(gameName))
+}
+
+// thumbnailURL returns where a thumbnail is downloaded from
+func thumbnailURL(libretroName string, kind ThumbnailKind, gameName string) string {
+	return strings.TrimSuffix(thumbnailsURL, "/") + "/" +
+		url.PathEscape(libretroName) + "/" + string(kind) + "/" +
+		url.PathEscape(thumbnailName(gameName)+".png")
+}
+
+// thumbnailPath returns where a thumbnail is kept
+func thumbnailPath(libretroName string, kind ThumbnailKind, gameName string) string {
+	return filepath.Join(thumbnailsDir, libretroName, string(kind), thumbnailName(gameName)+".png")
+}
+
+// thumbnailFetch is a thumbnail waiting to be downloaded
+type thumbnailFetch struct {
+	url  string
+	path string
+}
+
+// thumbnailCache downloads thumbnails in the background
+type thumbnailCache struct {
+	mu      sync.Mutex
+	pending map[string]bool // Paths queued or being downloaded
+	missing map[string]bool // Paths that failed this session
+	queue   chan thumbnailFetch
+	client  *http.Client
+
+	// OnLoaded is called after a thumbnail has been downloaded
+	OnLoaded func()
+}
+
+func newThumbnailCache() *thumbnailCache {
+	c := &thumbnailCache{
+		pending: make(map[string]bool),
+		missing: make(map[string]bool),
+		queue:   make(chan thumbnailFetch, maxThumbnailFetches),
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+	for i := 0; i < thumbnailWorkers; i++ {
+		go c.worker()
+	}
+	return c
+}
+
+// Get returns the local path of a game's thumbnail, or "" if it isn't
+// downloaded yet, in which case it's queued. Systems without a libretro
+// name have no thumbnails.
+func (c *thumbnailCache) Get(systemID string, kind ThumbnailKind, game ROM) string {
+	libretroName := systems[systemID].LibretroName
+	if libretroName == "" {
+		return ""
+	}
+	path := thumbnailPath(libretroName, kind, game.Name)
+	if fileExists(path) {
+		return path
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pending[path] || c.missing[path] {
+		return ""
+	}
+	select {
+	case c.queue <- thumbnailFetch{url: thumbnailURL(libretroName, kind, game.Name), path: path}:
+		c.pending[path] = true
+	default:
+		// Queue full, e.g. while scrolling fast
+	}
+	return ""
+}
+
+func (c *thumbnailCache) worker() {
+	for fetch := range c.queue {
+		err := c.download(fetch)
+		c.mu.Lock()
+		delete(c.pending, fetch.path)
+		if err != nil {
+			c.missing[fetch.path] = true
+		}
+		c.mu.Unlock()
+
+		if err != nil {
+			logDebug("Thumbnail %s: %v", fetch.url, err)
+		} else if c.OnLoaded != nil {
+			c.OnLoaded()
+		}
+	}
+}
+
+// download saves a thumbnail, through a temporary file so a failed download
+// doesn't leave a broken image behind
+func (c *thumbnailCache) download(fetch thumbnailFetch) error {
+	resp, err := c.client.Get(fetch.url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fetch.path), 0755); err != nil {
+		return err
+	}
+	tmpPath := fetch.path + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, resp.Body)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, fetch.path)
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/emubuddy/gui/wiiu"
+)
+
+// ROMFile is one file of a game as listed in a DAT, with its expected checksums
+type ROMFile struct {
+	Name  string `json:"name"`
+	Size  int64  `json:"size,omitempty"`
+	CRC32 string `json:"crc32,omitempty"`
+	MD5   string `json:"md5,omitempty"`
+	SHA1  string `json:"sha1,omitempty"`
+}
+
+// VerifyStatus is the outcome of checking a game against its checksums
+type VerifyStatus string
+
+const (
+	VerifyOK       VerifyStatus = "ok"
+	VerifyMismatch VerifyStatus = "mismatch"
+	VerifyUnknown  VerifyStatus = "unknown" // no checksums, or nothing to compare
+)
+
+// VerifyResult is stored per game in verification.json
+type VerifyResult struct {
+	Status VerifyStatus `json:"status"`
+	Detail string       `json:"detail,omitempty"`
+}
+
+var verifyPath string
+var verifyResults map[string]map[string]VerifyResult
+var verifyMu sync.Mutex
+
+func loadVerifyResults() {
+	verifyResults = make(map[string]map[string]VerifyResult)
+	data, err := os.ReadFile(verifyPath)
+	if err != nil {
+		return
+	}
+	json.Unmarshal(data, &verifyResults)
+}
+
+// saveVerifyResults must be called with verifyMu held
+func saveVerifyResults() {
+	data, _ := json.Marshal(verifyResults)
+	os.WriteFile(verifyPath, data, 0644)
+}
+
+func getVerifyResult(systemID, gameName string) (VerifyResult, bool) {
+	verifyMu.Lock()
+	defer verifyMu.Unlock()
+	result, ok := verifyResults[systemID][gameName]
+	return result, ok
+}
+
+func setVerifyResult(systemID, gameName string, result VerifyResult) {
+	verifyMu.Lock()
+	defer verifyMu.Unlock()
+	if verifyResults[systemID] == nil {
+		verifyResults[systemID] = make(map[string]VerifyResult)
+	}
+	verifyResults[systemID][gameName] = result
+	saveVerifyResults()
+}
+
+// hasChecksumMismatch reports whether the last verification of a game failed
+func hasChecksumMismatch(systemID, gameName string) bool {
+	result, ok := getVerifyResult(systemID, gameName)
+	return ok && result.Status == VerifyMismatch
+}
+
+// fileChecksums holds the hashes of one file, computed in a single pass
+type fileChecksums struct {
+	Size  int64
+	CRC32 string
+	MD5   string
+	SHA1  string
+}
+
+func hashReader(r io.Reader) (fileChecksums, error) {
+	crc := crc32.NewIEEE()
+	md := md5.New()
+	sh := sha1.New()
+	n, err := io.Copy(io.MultiWriter(crc, md, sh), r)
+	if err != nil {
+		return fileChecksums{}, err
+	}
+	return fileChecksums{
+		Size:  n,
+		CRC32: hex.EncodeToString(crc.Sum(nil)),
+		MD5:   hex.EncodeToString(md.Sum(nil)),
+		SHA1:  hex.EncodeToString(sh.Sum(nil)),
+	}, nil
+}
+
+func hashFile(path string) (fileChecksums, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return fileChecksums{}, err
+	}
+	defer f.Close()
+	return hashReader(f)
+}
+
+// hashZipEntries hashes every file inside a zip, keyed by lowercase base name
+func hashZipEntries(zipPath string) (map[string]fileChecksums, error) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	sums := make(map[string]fileChecksums)
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		sum, err := hashReader(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		sums[strings.ToLower(filepath.Base(f.Name))] = sum
+	}
+	return sums, nil
+}
+
+// compare checks a file against the checksums the DAT lists for it. Only the
+// checksums present in the DAT are compared.
+func (expected ROMFile) compare(actual fileChecksums) error {
+	if expected.Size > 0 && expected.Size != actual.Size {
+		return fmt.Errorf("%s: size %d, expected %d", expected.Name, actual.Size, expected.Size)
+	}
+	if expected.SHA1 != "" && !strings.EqualFold(expected.SHA1, actual.SHA1) {
+		return fmt.Errorf("%s: SHA1 mismatch", expected.Name)
+	}
+	if expected.MD5 != "" && !strings.EqualFold(expected.MD5, actual.MD5) {
+		return fmt.Errorf("%s: MD5 mismatch", expected.Name)
+	}
+	if expected.CRC32 != "" && !strings.EqualFold(expected.CRC32, actual.CRC32) {
+		return fmt.Errorf("%s: CRC32 mismatch (got %s)", expected.Name, actual.CRC32)
+	}
+	return nil
+}
+
+// verifyGame hashes the downloaded files of a game and compares them with the
+// checksums from its catalog entry. Zips that are kept as-is are checked by
+// their contents; systems that extract are checked by the extracted files.
+func verifyGame(systemID string, game ROM) VerifyResult {
+	config, ok := systems[systemID]
+	if !ok || len(game.Files) == 0 || config.SpecialDownload == "wiiu" {
+		return VerifyResult{Status: VerifyUnknown}
+	}
+	romDir := filepath.Join(romsDir, config.Dir)
+
+	// Collect the hashes of whatever is on disk for this game
+	actual := make(map[string]fileChecksums)
+	if !config.NeedsExtract {
+		gamePath := findGameFile(romDir, game, config.FileExtensions)
+		if gamePath == "" {
+			return VerifyResult{Status: VerifyUnknown, Detail: "file not found"}
+		}
+		if strings.HasSuffix(strings.ToLower(gamePath), ".zip") {
+			sums, err := hashZipEntries(gamePath)
+			if err != nil {
+				return VerifyResult{Status: VerifyMismatch, Detail: err.Error()}
+			}
+			actual = sums
+		} else {
+			sum, err := hashFile(gamePath)
+			if err != nil {
+				return VerifyResult{Status: VerifyUnknown, Detail: err.Error()}
+			}
+			// A bare file is the single ROM of the game, whatever the DAT calls it
+			actual[strings.ToLower(filepath.Base(gamePath))] = sum
+			if len(game.Files) == 1 {
+				actual[strings.ToLower(filepath.Base(game.Files[0].Name))] = sum
+			}
+		}
+	} else {
+		for _, expected := range game.Files {
+			sum, err := hashFile(filepath.Join(romDir, expected.Name))
+			if err != nil {
+				continue
+			}
+			actual[strings.ToLower(filepath.Base(expected.Name))] = sum
+		}
+	}
+
+	var problems []string
+	missing := 0
+	for _, expected := range game.Files {
+		sum, ok := actual[strings.ToLower(filepath.Base(expected.Name))]
+		if !ok {
+			missing++
+			problems = append(problems, expected.Name+": missing")
+			continue
+		}
+		if err := expected.compare(sum); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	switch {
+	case missing == len(game.Files):
+		return VerifyResult{Status: VerifyUnknown, Detail: "no matching files found"}
+	case len(problems) > 0:
+		return VerifyResult{Status: VerifyMismatch, Detail: strings.Join(problems, "; ")}
+	}
+	return VerifyResult{Status: VerifyOK}
+}
+
+// findGameFile returns the file a game was saved as: the catalog name itself,
+// or the same base name with one of the system's extensions
+func findGameFile(romDir string, game ROM, extensions []string) string {
+	path := filepath.Join(romDir, game.Name)
+	if fileExists(path) {
+		return path
+	}
+	base := catalogBaseName(game.Name)
+	for _, ext := range extensions {
+		path = filepath.Join(romDir, base+ext)
+		if fileExists(path) {
+			return path
+		}
+	}
+	return ""
+}
+
+// verifyWiiUHeadless checks a Wii U title folder and prints what doesn't
+// match: its decrypted files if their hashes were recorded when it was
+// decrypted, otherwise its encrypted contents against the TMD, with a
+// pass/fail line per content
+func verifyWiiUHeadless(args []string) {
+	if len(args) < 1 {
+		fmt.Printf("Usage: %s --verify-wiiu <title_folder>\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	files, err := wiiu.VerifyFiles(args[0])
+	if err == nil {
+		failed := 0
+		for _, check := range files {
+			if check.Err != nil {
+				fmt.Printf("%s  FAIL: %v\n", check.Path, check.Err)
+				failed++
+			}
+		}
+		fmt.Printf("%d of %d files OK\n", len(files)-failed, len(files))
+		if failed > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	checks, err := wiiu.VerifyTitle(args[0])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	failed := 0
+	for _, check := range checks {
+		kind := "unhashed"
+		if check.Hashed() {
+			kind = "hashed"
+		}
+		result := "OK"
+		if check.Err != nil {
+			result = "FAIL: " + check.Err.Error()
+			failed++
+		}
+		fmt.Printf("%s.app  %-8s  %10s  %s\n", check.Content.CIDStr, kind, formatROMSize(int64(check.Content.Size)), result)
+	}
+	fmt.Printf("%d of %d contents OK\n", len(checks)-failed, len(checks))
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// maxVerifyProblems is how many problems a result lists before summing up the
+// rest, as a damaged Wii U title can have thousands
+const maxVerifyProblems = 5
+
+// verifyWiiUGame checks an installed Wii U title the way verifyWiiUHeadless
+// does
+func verifyWiiUGame(titleDir string) VerifyResult {
+	var problems []string
+	files, err := wiiu.VerifyFiles(titleDir)
+	switch {
+	case err == nil:
+		for _, check := range files {
+			if check.Err != nil {
+				problems = append(problems, check.Path+": "+check.Err.Error())
+			}
+		}
+	case errors.Is(err, os.ErrNotExist):
+		checks, err := wiiu.VerifyTitle(titleDir)
+		if err != nil {
+			return VerifyResult{Status: VerifyUnknown, Detail: "no checksums were recorded when it was installed, download it again to get them"}
+		}
+		for _, check := range checks {
+			if check.Err != nil {
+				problems = append(problems, check.Content.CIDStr+".app: "+check.Err.Error())
+			}
+		}
+	default:
+		return VerifyResult{Status: VerifyUnknown, Detail: err.Error()}
+	}
+
+	if len(problems) == 0 {
+		return VerifyResult{Status: VerifyOK}
+	}
+	if len(problems) > maxVerifyProblems {
+		problems = append(problems[:maxVerifyProblems], fmt.Sprintf("%d more", len(problems)-maxVerifyProblems))
+	}
+	return VerifyResult{Status: VerifyMismatch, Detail: strings.Join(problems, "; ")}
+}
+
+// verifySelected checks the files of the selected game that are already on
+// disk against its checksums, or a Wii U title against the hashes recorded
+// when it was decrypted. Hashing takes a while, so it runs in the
+// background.
+func (a *App) verifySelected() {
+	if a.selectedGameIdx < 0 || a.selectedGameIdx >= len(a.filteredGames) {
+		a.statusBar.SetText("No game selected")
+		return
+	}
+	game := a.filteredGames[a.selectedGameIdx]
+	if !a.isDownloaded(game) {
+		a.statusBar.SetText("Not downloaded: " + game.Name)
+		return
+	}
+	sysID := a.gameSystem(game)
+	a.statusBar.SetText("Verifying: " + game.Name + "...")
+
+	go func() {
+		var problems []string
+		verified := 0
+		unknown := ""
+		for _, disc := range gameDiscs(game) {
+			var result VerifyResult
+			if config := systems[sysID]; config.SpecialDownload == "wiiu" {
+				result = verifyWiiUGame(wiiuInstallDir(config, disc))
+			} else {
+				result = verifyGame(sysID, disc)
+			}
+			if result.Status == VerifyUnknown {
+				unknown = result.Detail
+				continue
+			}
+			setVerifyResult(sysID, disc.Name, result)
+			verified++
+			if result.Status == VerifyMismatch {
+				problems = append(problems, result.Detail)
+			}
+		}
+		a.gameList.Refresh()
+
+		switch {
+		case len(problems) > 0:
+			a.statusBar.SetText(fmt.Sprintf("Checksum mismatch: %s (%s)", game.Name, strings.Join(problems, "; ")))
+		case verified == 0 && systems[sysID].SpecialDownload == "wiiu":
+			a.statusBar.SetText("Can't verify " + game.Name + ": " + unknown)
+		case verified == 0:
+			a.statusBar.SetText("No checksums to verify " + game.Name + " against, import a DAT first")
+		default:
+			a.statusBar.SetText("Verified OK: " + game.Name)
+		}
+	}()
+}
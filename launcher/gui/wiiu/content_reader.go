@@ -0,0 +1,136 @@
+package wiiu
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"fmt"
+	"io"
+)
+
+// contentReader gives random access to the decrypted data of an encrypted
+// content, one chunk at a time, so memory use doesn't depend on its size.
+// Unhashed contents are a single CBC chain cut into BLOCK_SIZE chunks; a
+// chunk is decrypted with the last cipher block before it as the IV. The
+// chunks of hashed contents are the HASH_BLOCK_SIZE data of each
+// BLOCK_SIZE_HASHED block, whose IV comes from its H0 hash.
+type contentReader struct {
+	src            io.ReaderAt // The .app file
+	content        Content
+	cipherHashTree cipher.Block
+	size           int64 // Decrypted data size
+
+	chunk      []byte // Last decrypted chunk, cached for small sequential reads
+	chunkIndex int64
+	encrypted  []byte
+	hashes     []byte
+}
+
+func newContentReader(src io.ReaderAt, content Content, cipherHashTree cipher.Block) *contentReader {
+	r := &contentReader{src: src, content: content, cipherHashTree: cipherHashTree, chunkIndex: -1}
+	if content.Type&0x2 != 0 {
+		r.size = int64(content.Size/BLOCK_SIZE_HASHED) * HASH_BLOCK_SIZE
+		r.chunk = make([]byte, HASH_BLOCK_SIZE)
+		r.encrypted = make([]byte, BLOCK_SIZE_HASHED)
+		r.hashes = make([]byte, HASHES_SIZE)
+	} else {
+		r.size = int64(content.Size)
+		r.chunk = make([]byte, BLOCK_SIZE)
+		r.encrypted = make([]byte, aes.BlockSize+BLOCK_SIZE)
+	}
+	return r
+}
+
+// Size returns the size of the decrypted data
+func (r *contentReader) Size() int64 {
+	return r.size
+}
+
+// ReadAt implements io.ReaderAt over the decrypted data
+func (r *contentReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset %d", off)
+	}
+	n := 0
+	for n < len(p) {
+		if off >= r.size {
+			return n, io.EOF
+		}
+		chunkSize := int64(len(r.chunk))
+		chunk, err := r.loadChunk(off / chunkSize)
+		if err != nil {
+			return n, err
+		}
+		copied := copy(p[n:], chunk[off%chunkSize:])
+		n += copied
+		off += int64(copied)
+	}
+	return n, nil
+}
+
+// loadChunk decrypts a chunk, unless it is the cached one. The last chunk of
+// an unhashed content is cut to the content size.
+func (r *contentReader) loadChunk(index int64) ([]byte, error) {
+	chunkSize := int64(len(r.chunk))
+	end := chunkSize
+	if remaining := r.size - index*chunkSize; remaining < end {
+		end = remaining
+	}
+	if index == r.chunkIndex {
+		return r.chunk[:end], nil
+	}
+	r.chunkIndex = -1
+
+	if r.content.Type&0x2 != 0 {
+		if err := r.decryptHashedChunk(index); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := r.decryptUnhashedChunk(index, end); err != nil {
+			return nil, err
+		}
+	}
+	r.chunkIndex = index
+	return r.chunk[:end], nil
+}
+
+func (r *contentReader) decryptUnhashedChunk(index, size int64) error {
+	aligned := (size + aes.BlockSize - 1) &^ (aes.BlockSize - 1)
+	offset := index * BLOCK_SIZE
+
+	var iv []byte
+	if index == 0 {
+		iv = make([]byte, aes.BlockSize)
+		copy(iv, r.content.Index)
+		if _, err := r.src.ReadAt(r.encrypted[aes.BlockSize:aes.BlockSize+aligned], offset); err != nil {
+			return fmt.Errorf("failed to read content %s at %d: %w", r.content.CIDStr, offset, err)
+		}
+	} else {
+		// The cipher block before the chunk is its IV
+		if _, err := r.src.ReadAt(r.encrypted[:aes.BlockSize+aligned], offset-aes.BlockSize); err != nil {
+			return fmt.Errorf("failed to read content %s at %d: %w", r.content.CIDStr, offset, err)
+		}
+		iv = r.encrypted[:aes.BlockSize]
+	}
+	cipher.NewCBCDecrypter(r.cipherHashTree, iv).CryptBlocks(r.chunk[:aligned], r.encrypted[aes.BlockSize:aes.BlockSize+aligned])
+	return nil
+}
+
+func (r *contentReader) decryptHashedChunk(index int64) error {
+	offset := index * BLOCK_SIZE_HASHED
+	if _, err := r.src.ReadAt(r.encrypted, offset); err != nil {
+		return fmt.Errorf("failed to read content %s at %d: %w", r.content.CIDStr, offset, err)
+	}
+
+	var zeroIV [aes.BlockSize]byte
+	cipher.NewCBCDecrypter(r.cipherHashTree, zeroIV[:]).CryptBlocks(r.hashes, r.encrypted[:HASHES_SIZE])
+	h0Index := index % hashEntries
+	h0 := r.hashes[h0Index*sha1.Size : (h0Index+1)*sha1.Size]
+
+	cipher.NewCBCDecrypter(r.cipherHashTree, h0[:aes.BlockSize]).CryptBlocks(r.chunk, r.encrypted[HASHES_SIZE:])
+	if sum := sha1.Sum(r.chunk); !bytes.Equal(sum[:], h0) {
+		return fmt.Errorf("%w: H0 of block %d of content %s", ErrHashMismatch, index, r.content.CIDStr)
+	}
+	return nil
+}
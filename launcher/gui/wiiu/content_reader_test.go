@@ -0,0 +1,196 @@
+package wiiu
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+// Sizes of the test contents: an unhashed one that ends inside its last
+// chunk, and a hashed one whose blocks span two H0 groups
+const (
+	testUnhashedSize = 3*BLOCK_SIZE + 0x1230
+	testHashedBlocks = 20
+)
+
+// testCipher returns the title key cipher the test contents are encrypted with
+func testCipher(t *testing.T) cipher.Block {
+	t.Helper()
+	block, err := aes.NewCipher([]byte("0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return block
+}
+
+// testData returns n bytes that differ from one offset to the next, so a
+// read at the wrong offset or with the wrong IV shows
+func testData(n int) []byte {
+	data := make([]byte, n)
+	rand.New(rand.NewSource(int64(n))).Read(data)
+	return data
+}
+
+// encryptUnhashed returns an unhashed content holding data and its .app
+// file: one CBC chain whose IV is the content index
+func encryptUnhashed(block cipher.Block, data []byte) (Content, []byte) {
+	index := []byte{0x00, 0x03}
+	iv := make([]byte, aes.BlockSize)
+	copy(iv, index)
+	app := make([]byte, (len(data)+aes.BlockSize-1)&^(aes.BlockSize-1))
+	copy(app, data)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(app, app)
+
+	sum := sha1.Sum(data)
+	content := Content{ID: 3, Index: index, Type: 0x2001, Size: uint64(len(data)), Hash: sum[:], CIDStr: "00000003"}
+	return content, app
+}
+
+// encryptHashed returns a hashed content holding data, a whole number of
+// HASH_BLOCK_SIZE blocks, with its .app and .h3 files. Each block starts
+// with its H0, H1 and H2 tables, encrypted with a zero IV; its data is
+// encrypted with its H0 hash as the IV.
+func encryptHashed(block cipher.Block, data []byte) (Content, []byte, []byte) {
+	blocks := len(data) / HASH_BLOCK_SIZE
+
+	// level returns the tables of a level of the tree: the hashes of the
+	// level below, hashEntries to a table
+	level := func(hashes [][]byte) [][]byte {
+		tables := make([][]byte, (len(hashes)+hashEntries-1)/hashEntries)
+		for i := range tables {
+			tables[i] = make([]byte, hashesPerBlock)
+		}
+		for i, hash := range hashes {
+			copy(tables[i/hashEntries][(i%hashEntries)*sha1.Size:], hash)
+		}
+		return tables
+	}
+	sums := func(tables [][]byte) [][]byte {
+		hashes := make([][]byte, len(tables))
+		for i, table := range tables {
+			sum := sha1.Sum(table)
+			hashes[i] = sum[:]
+		}
+		return hashes
+	}
+
+	h0 := make([][]byte, blocks)
+	for i := range h0 {
+		sum := sha1.Sum(data[i*HASH_BLOCK_SIZE : (i+1)*HASH_BLOCK_SIZE])
+		h0[i] = sum[:]
+	}
+	h0Tables := level(h0)
+	h1Tables := level(sums(h0Tables))
+	h2Tables := level(sums(h1Tables))
+	h3 := bytes.Join(sums(h2Tables), nil)
+
+	app := make([]byte, blocks*BLOCK_SIZE_HASHED)
+	var zeroIV [aes.BlockSize]byte
+	for i := 0; i < blocks; i++ {
+		out := app[i*BLOCK_SIZE_HASHED : (i+1)*BLOCK_SIZE_HASHED]
+		copy(out, h0Tables[i/hashEntries])
+		copy(out[hashesPerBlock:], h1Tables[i/(hashEntries*hashEntries)])
+		copy(out[2*hashesPerBlock:], h2Tables[i/(hashEntries*hashEntries*hashEntries)])
+		cipher.NewCBCEncrypter(block, zeroIV[:]).CryptBlocks(out[:HASHES_SIZE], out[:HASHES_SIZE])
+
+		copy(out[HASHES_SIZE:], data[i*HASH_BLOCK_SIZE:(i+1)*HASH_BLOCK_SIZE])
+		cipher.NewCBCEncrypter(block, h0[i][:aes.BlockSize]).CryptBlocks(out[HASHES_SIZE:], out[HASHES_SIZE:])
+	}
+
+	sum := sha1.Sum(h3)
+	content := Content{ID: 4, Index: []byte{0x00, 0x04}, Type: 0x2003, Size: uint64(len(app)), Hash: sum[:], CIDStr: "00000004"}
+	return content, app, h3
+}
+
+// testRead is a read from a content: the offset and the number of bytes
+type testRead struct {
+	name      string
+	off, size int64
+}
+
+func checkReads(t *testing.T, r *contentReader, data []byte, reads []testRead) {
+	t.Helper()
+	for _, read := range reads {
+		p := make([]byte, read.size)
+		n, err := r.ReadAt(p, read.off)
+		end := read.off + read.size
+		if end > int64(len(data)) {
+			end = int64(len(data))
+		}
+		want := data[read.off:end]
+		if read.off+read.size > int64(len(data)) {
+			if !errors.Is(err, io.EOF) {
+				t.Errorf("%s: read past the end returned %v, want EOF", read.name, err)
+			}
+		} else if err != nil {
+			t.Errorf("%s: %v", read.name, err)
+			continue
+		}
+		if n != len(want) || !bytes.Equal(p[:n], want) {
+			t.Errorf("%s: read %d bytes at %#x that differ from the data", read.name, n, read.off)
+		}
+	}
+}
+
+func TestContentReaderUnhashed(t *testing.T) {
+	block := testCipher(t)
+	data := testData(testUnhashedSize)
+	content, app := encryptUnhashed(block, data)
+
+	r := newContentReader(bytes.NewReader(app), content, block)
+	if r.Size() != int64(len(data)) {
+		t.Fatalf("Size: got %d, want %d", r.Size(), len(data))
+	}
+	// In order and out of it, so chunks are decrypted both fresh and after
+	// another one was cached
+	checkReads(t, r, data, []testRead{
+		{"start", 0, 100},
+		{"inside the first chunk", 0x20, 100},
+		{"inside a later chunk", 2*BLOCK_SIZE + 0x35, 0x400},
+		{"across a chunk boundary", BLOCK_SIZE - 10, 30},
+		{"across two chunk boundaries", BLOCK_SIZE - 5, BLOCK_SIZE + 10},
+		{"back to the first chunk", 0x10, 0x10},
+		{"unaligned, at the end", testUnhashedSize - 0x21, 0x21},
+		{"past the end", testUnhashedSize - 10, 20},
+	})
+}
+
+func TestContentReaderHashed(t *testing.T) {
+	block := testCipher(t)
+	data := testData(testHashedBlocks * HASH_BLOCK_SIZE)
+	content, app, _ := encryptHashed(block, data)
+
+	r := newContentReader(bytes.NewReader(app), content, block)
+	if r.Size() != int64(len(data)) {
+		t.Fatalf("Size: got %d, want %d", r.Size(), len(data))
+	}
+	checkReads(t, r, data, []testRead{
+		{"start", 0, 100},
+		{"inside a block", 3*HASH_BLOCK_SIZE + 0x123, 0x400},
+		{"across a block boundary", HASH_BLOCK_SIZE - 10, 30},
+		{"in the second H0 group", 17*HASH_BLOCK_SIZE + 5, 100},
+		{"across the H0 groups", 16*HASH_BLOCK_SIZE - 7, HASH_BLOCK_SIZE + 14},
+		{"past the end", testHashedBlocks*HASH_BLOCK_SIZE - 10, 20},
+	})
+}
+
+func TestContentReaderHashedCorrupt(t *testing.T) {
+	block := testCipher(t)
+	data := testData(testHashedBlocks * HASH_BLOCK_SIZE)
+	content, app, _ := encryptHashed(block, data)
+	app[17*BLOCK_SIZE_HASHED+HASHES_SIZE+0x100] ^= 0xff
+
+	r := newContentReader(bytes.NewReader(app), content, block)
+	p := make([]byte, 100)
+	if _, err := r.ReadAt(p, 16*HASH_BLOCK_SIZE); err != nil {
+		t.Errorf("block before the corrupt one: %v", err)
+	}
+	if _, err := r.ReadAt(p, 17*HASH_BLOCK_SIZE); !errors.Is(err, ErrHashMismatch) {
+		t.Errorf("corrupt block: got %v, want %v", err, ErrHashMismatch)
+	}
+}
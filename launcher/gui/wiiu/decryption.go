@@ -3,15 +3,19 @@ package wiiu
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/sha1"
 	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+
+	"golang.org/x/sync/errgroup"
 )
 
 var wiiUCommonKey = []byte{0xD7, 0xB0, 0x04, 0x02, 0x65, 0x9B, 0xA2, 0xAB, 0xD2, 0xCB, 0x0D, 0xB2, 0x7F, 0xA2, 0xB6, 0x56}
@@ -43,24 +47,29 @@ type FEntry struct {
 
 // FSTData represents file system table data
 type FSTData struct {
-	FSTReader   *bytes.Reader
+	FSTReader   io.ReadSeeker
 	EntryCount  uint32
 	Entries     uint32
 	NamesOffset uint32
 	FSTEntries  []FEntry
 }
 
-func extractFileHash(src *os.File, partDataOffset uint64, fileOffset uint64, size uint64, path string, cipherHashTree cipher.Block) error {
+// extractFileHash copies a file of size bytes at fileOffset out of a hashed
+// content, checking each block against its H0 hash, and returns the SHA-1
+// of the file in hex
+func extractFileHash(ctx context.Context, src io.ReaderAt, srcSize int64, partDataOffset uint64, fileOffset uint64, size uint64, path string, cipherHashTree cipher.Block) (string, error) {
 	writeSize := HASH_BLOCK_SIZE
 	blockNumber := (fileOffset / HASH_BLOCK_SIZE) & 0x0F
 
 	dst, err := os.Create(path)
 	if err != nil {
-		return fmt.Errorf("could not create '%s': %w", path, err)
+		return "", fmt.Errorf("could not create '%s': %w", path, err)
 	}
 	defer dst.Close()
 	bw := bufio.NewWriterSize(dst, BLOCK_SIZE_HASHED)
 	defer bw.Flush()
+	fileHash := sha1.New()
+	w := io.MultiWriter(fileHash, bw)
 
 	roffset := fileOffset / HASH_BLOCK_SIZE * BLOCK_SIZE_HASHED
 	soffset := fileOffset - (fileOffset / HASH_BLOCK_SIZE * HASH_BLOCK_SIZE)
@@ -69,11 +78,6 @@ func extractFileHash(src *os.File, partDataOffset uint64, fileOffset uint64, siz
 		writeSize = writeSize - int(soffset)
 	}
 
-	_, err = src.Seek(int64(partDataOffset+roffset), io.SeekStart)
-	if err != nil {
-		return err
-	}
-
 	encryptedHashedContentBuffer := make([]byte, BLOCK_SIZE_HASHED)
 	decryptedHashedContentBuffer := make([]byte, BLOCK_SIZE_HASHED)
 	hashes := make([]byte, HASHES_SIZE)
@@ -83,13 +87,12 @@ func extractFileHash(src *os.File, partDataOffset uint64, fileOffset uint64, siz
 			writeSize = int(size)
 		}
 
-		stat, err := src.Stat()
-		if err != nil {
-			return err
+		if err := ctx.Err(); err != nil {
+			return "", err
 		}
-		fileSize := stat.Size()
+
 		currentPos := int64(partDataOffset + roffset)
-		remainingFile := fileSize - currentPos
+		remainingFile := srcSize - currentPos
 
 		readLen := BLOCK_SIZE_HASHED
 		if int64(readLen) > remainingFile {
@@ -97,12 +100,12 @@ func extractFileHash(src *os.File, partDataOffset uint64, fileOffset uint64, siz
 		}
 
 		if readLen%aes.BlockSize != 0 {
-			return fmt.Errorf("read length %d is not a multiple of AES block size", readLen)
+			return "", fmt.Errorf("read length %d is not a multiple of AES block size", readLen)
 		}
 
-		if _, err := io.ReadFull(src, encryptedHashedContentBuffer[:readLen]); err != nil {
-			return fmt.Errorf("failed to read encrypted content block at offset %d (read %d of %d bytes): %w",
-				partDataOffset+roffset, 0, BLOCK_SIZE_HASHED, err)
+		if n, err := src.ReadAt(encryptedHashedContentBuffer[:readLen], currentPos); n < readLen {
+			return "", fmt.Errorf("failed to read encrypted content block at offset %d (read %d of %d bytes): %w",
+				partDataOffset+roffset, n, BLOCK_SIZE_HASHED, err)
 		}
 
 		var zeroIV [aes.BlockSize]byte
@@ -117,14 +120,14 @@ func extractFileHash(src *os.File, partDataOffset uint64, fileOffset uint64, siz
 
 		hash := sha1.Sum(decryptedHashedContentBuffer[:HASH_BLOCK_SIZE])
 		if !bytes.Equal(hash[:], h0Hash) {
-			return errors.New("h0 hash mismatch")
+			return "", errors.New("h0 hash mismatch")
 		}
 
 		dataToWrite := decryptedHashedContentBuffer
 
-		n, err := bw.Write(dataToWrite[soffset : soffset+uint64(writeSize)])
+		n, err := w.Write(dataToWrite[soffset : soffset+uint64(writeSize)])
 		if err != nil {
-			return err
+			return "", err
 		}
 
 		size -= uint64(n)
@@ -142,84 +145,64 @@ func extractFileHash(src *os.File, partDataOffset uint64, fileOffset uint64, siz
 		roffset += uint64(BLOCK_SIZE_HASHED)
 	}
 
-	return nil
+	if err := bw.Flush(); err != nil {
+		return "", err
+	}
+	// On disk before the journal records it as done
+	if err := dst.Sync(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(fileHash.Sum(nil)), nil
 }
 
-func extractFile(src *os.File, partDataOffset uint64, fileOffset uint64, size uint64, path string, contentId uint16, cipherHashTree cipher.Block) error {
-	writeSize := BLOCK_SIZE
+// extractFile copies a file of size bytes at fileOffset out of the
+// decrypted data of an unhashed content. The content is one CBC chain, so
+// the data is read through a contentReader, which decrypts the chunks around
+// the file with the right IV wherever the file starts. It returns the SHA-1
+// of the file in hex.
+func extractFile(ctx context.Context, data *contentReader, fileOffset uint64, size uint64, path string) (string, error) {
+	if fileOffset+size > uint64(data.Size()) {
+		return "", fmt.Errorf("'%s' ends at %d, past the end of content %s (%d bytes)", path, fileOffset+size, data.content.CIDStr, data.Size())
+	}
 
 	dst, err := os.Create(path)
 	if err != nil {
-		return fmt.Errorf("could not create '%s': %w", path, err)
+		return "", fmt.Errorf("could not create '%s': %w", path, err)
 	}
 	defer dst.Close()
 	bw := bufio.NewWriterSize(dst, BLOCK_SIZE)
-	defer bw.Flush()
-
-	roffset := fileOffset / BLOCK_SIZE * BLOCK_SIZE
-	soffset := fileOffset - (fileOffset / BLOCK_SIZE * BLOCK_SIZE)
-
-	if soffset+size > uint64(writeSize) {
-		writeSize = writeSize - int(soffset)
-	}
-
-	_, err = src.Seek(int64(partDataOffset+roffset), io.SeekStart)
-	if err != nil {
-		return err
-	}
-
-	var ivLocal [aes.BlockSize]byte
-	ivLocal[1] = byte(contentId)
-	aesCipher := cipher.NewCBCDecrypter(cipherHashTree, ivLocal[:])
-
-	encryptedContentBuffer := make([]byte, BLOCK_SIZE)
-	decryptedContentBuffer := make([]byte, BLOCK_SIZE)
-
-	for size > 0 {
-		if uint64(writeSize) > size {
-			writeSize = int(size)
-		}
-
-		stat, err := src.Stat()
-		if err != nil {
-			return err
-		}
-		fileSize := stat.Size()
-		currentPos := int64(partDataOffset + roffset)
-		remainingFile := fileSize - currentPos
+	fileHash := sha1.New()
+	w := io.MultiWriter(fileHash, bw)
 
-		readLen := BLOCK_SIZE
-		if int64(readLen) > remainingFile {
-			readLen = int(remainingFile)
+	buf := make([]byte, BLOCK_SIZE)
+	for off := int64(fileOffset); size > 0; {
+		if err := ctx.Err(); err != nil {
+			return "", err
 		}
 
-		if readLen%aes.BlockSize != 0 {
-			return fmt.Errorf("read length %d is not a multiple of AES block size", readLen)
+		readLen := uint64(len(buf))
+		if readLen > size {
+			readLen = size
 		}
-
-		if _, err := io.ReadFull(src, encryptedContentBuffer[:readLen]); err != nil {
-			return fmt.Errorf("failed to read encrypted content block at offset %d (expected %d bytes): %w",
-				partDataOffset+roffset, readLen, err)
+		n, err := data.ReadAt(buf[:readLen], off)
+		if uint64(n) < readLen {
+			return "", fmt.Errorf("failed to read content %s at %d: %w", data.content.CIDStr, off, err)
 		}
-
-		aesCipher.CryptBlocks(decryptedContentBuffer[:readLen], encryptedContentBuffer[:readLen])
-
-		n, err := bw.Write(decryptedContentBuffer[soffset : soffset+uint64(writeSize)])
-		if err != nil {
-			return err
-		}
-
-		size -= uint64(n)
-
-		if soffset != 0 {
-			writeSize = BLOCK_SIZE
-			soffset = 0
+		if _, err := w.Write(buf[:readLen]); err != nil {
+			return "", err
 		}
-
-		roffset += uint64(BLOCK_SIZE)
+		off += int64(readLen)
+		size -= readLen
 	}
 
-	return nil
+	if err := bw.Flush(); err != nil {
+		return "", err
+	}
+	// On disk before the journal records it as done
+	if err := dst.Sync(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(fileHash.Sum(nil)), nil
 }
 
 func (fst *FSTData) Parse() error {
@@ -382,175 +365,126 @@ func minUint64(a, b uint64) uint64 {
 	return b
 }
 
-func decryptContentToBuffer(encryptedFile *os.File, decryptedBuffer *bytes.Buffer, cipherHashTree cipher.Block, content Content) error {
-	hasHashTree := content.Type&2 != 0
-	encryptedStat, err := encryptedFile.Stat()
+// u8Magic starts a U8 archive
+const u8Magic = 0x55AA382D
+
+// u8ScanSize is how much of a content is scanned for U8 archives at a time
+const u8ScanSize = 1024 * 1024
+
+// decryptWiiContent decrypts a content of a Wii title and extracts the U8
+// archives in it; a content without one is written back decrypted. The
+// content is read in fixed-size chunks, so memory use doesn't depend on its
+// size.
+func decryptWiiContent(path string, index int, content Content, cipherHashTree cipher.Block) error {
+	appPath := filepath.Join(path, content.CIDStr+".app")
+	src, err := os.Open(appPath)
 	if err != nil {
 		return err
 	}
-	encryptedSize := encryptedStat.Size()
-	path := filepath.Dir(encryptedFile.Name())
-
-	var growSize int64
-	if hasHashTree {
-		growSize = encryptedSize
-	} else {
-		growSize = int64(content.Size)
-	}
+	defer src.Close()
 
-	if growSize > MAX_FST_SIZE {
-		return fmt.Errorf("FST size %d exceeds maximum limit of %d", growSize, MAX_FST_SIZE)
+	// A content written back by an earlier run is already decrypted
+	var data io.ReaderAt = src
+	size := int64(content.Size)
+	plain := isPlainContent(src, content)
+	if !plain {
+		if err := verifyContent(path, content, cipherHashTree); err != nil {
+			return err
+		}
+		reader := newContentReader(src, content, cipherHashTree)
+		data, size = reader, reader.Size()
 	}
 
-	decryptedBuffer.Grow(int(growSize))
-
-	if hasHashTree {
-		chunkCount := encryptedSize / 0x10000
-		h3Data, err := os.ReadFile(filepath.Join(path, fmt.Sprintf("%s.h3", content.CIDStr)))
-		if err != nil {
+	foundU8 := false
+	extractCount := 0
+	buf := make([]byte, u8ScanSize)
+	for base := int64(0); base < size; base += u8ScanSize {
+		n, err := data.ReadAt(buf, base)
+		if err != nil && err != io.EOF {
 			return err
 		}
-		h3BytesSHASum := sha1.Sum(h3Data)
-		if !bytes.Equal(h3BytesSHASum[:], content.Hash[:sha1.Size]) {
-			return errors.New("H3 Hash mismatch")
-		}
-
-		h0HashNum := int64(0)
-		h1HashNum := int64(0)
-		h2HashNum := int64(0)
-		h3HashNum := int64(0)
-
-		hashes := make([]byte, HASHES_SIZE)
-		hashesBuffer := make([]byte, HASHES_SIZE)
-		decryptedDataBuffer := make([]byte, 0xFC00)
-
-		for chunkNum := int64(0); chunkNum < chunkCount; chunkNum++ {
-			if _, err := io.ReadFull(encryptedFile, hashesBuffer); err != nil {
-				return err
-			}
-			var zeroIV [aes.BlockSize]byte
-			cipher.NewCBCDecrypter(cipherHashTree, zeroIV[:]).CryptBlocks(hashes, hashesBuffer)
-
-			h0Hashes := hashes[0:0x140]
-			h1Hashes := hashes[0x140:0x280]
-			h2Hashes := hashes[0x280:0x3c0]
-
-			h0Hash := h0Hashes[(h0HashNum * 0x14):((h0HashNum + 1) * 0x14)]
-			h1Hash := h1Hashes[(h1HashNum * 0x14):((h1HashNum + 1) * 0x14)]
-			h2Hash := h2Hashes[(h2HashNum * 0x14):((h2HashNum + 1) * 0x14)]
-			h3Hash := h3Data[(h3HashNum * 0x14):((h3HashNum + 1) * 0x14)]
-
-			h0HashesHash := sha1.Sum(h0Hashes)
-			h1HashesHash := sha1.Sum(h1Hashes)
-			h2HashesHash := sha1.Sum(h2Hashes)
-
-			if !bytes.Equal(h0HashesHash[:], h1Hash) {
-				return errors.New("h0 Hashes Hash mismatch")
-			}
-			if !bytes.Equal(h1HashesHash[:], h2Hash) {
-				return errors.New("h1 Hashes Hash mismatch")
-			}
-			if !bytes.Equal(h2HashesHash[:], h3Hash) {
-				return errors.New("h2 Hashes Hash mismatch")
-			}
-
-			if _, err := io.ReadFull(encryptedFile, decryptedDataBuffer); err != nil {
-				return err
-			}
-
-			cipher.NewCBCDecrypter(cipherHashTree, h0Hash[:16]).CryptBlocks(decryptedDataBuffer, decryptedDataBuffer)
-			decryptedDataHash := sha1.Sum(decryptedDataBuffer)
-
-			if !bytes.Equal(decryptedDataHash[:], h0Hash) {
-				return errors.New("data block hash invalid")
-			}
-
-			if _, err = decryptedBuffer.Write(hashes); err != nil {
-				return err
+		for off := 0; off+16 <= n; off += 16 {
+			pos := base + int64(off)
+			if pos >= size-32 {
+				break
 			}
-			if _, err = decryptedBuffer.Write(decryptedDataBuffer); err != nil {
-				return err
+			if binary.BigEndian.Uint32(buf[off:off+4]) != u8Magic {
+				continue
 			}
+			rootNodeOffset := binary.BigEndian.Uint32(buf[off+4 : off+8])
+			dataOffset := binary.BigEndian.Uint32(buf[off+12 : off+16])
 
-			h0HashNum++
-			if h0HashNum >= 16 {
-				h0HashNum = 0
-				h1HashNum++
-			}
-			if h1HashNum >= 16 {
-				h1HashNum = 0
-				h2HashNum++
+			if rootNodeOffset < 32 || int64(rootNodeOffset) > size {
+				continue
 			}
-			if h2HashNum >= 16 {
-				h2HashNum = 0
-				h3HashNum++
+			if dataOffset < rootNodeOffset || int64(dataOffset) > size {
+				continue
 			}
-		}
-	} else {
-		f, err := os.Open(encryptedFile.Name())
-		if err == nil {
-			h := sha1.New()
-			if _, err := io.Copy(h, f); err == nil {
-				if bytes.Equal(content.Hash[:sha1.Size], h.Sum(nil)) {
-					_, _ = f.Seek(0, 0)
-					decryptedBuffer.Reset()
-					_, _ = io.Copy(decryptedBuffer, f)
-					_ = f.Close()
-					return nil
-				}
+			if dataOffset-rootNodeOffset > 1000000 {
+				continue
 			}
-			_ = f.Close()
-		}
-
-		_, _ = encryptedFile.Seek(0, io.SeekStart)
 
-		var ivContent [aes.BlockSize]byte
-		copy(ivContent[:], content.Index)
-		cipherContent := cipher.NewCBCDecrypter(cipherHashTree, ivContent[:])
-		contentHash := sha1.New()
-		left := content.Size
-		leftHash := content.Size
-
-		decBuf := make([]byte, READ_SIZE)
-		readSizedBuffer := make([]byte, READ_SIZE)
-
-		for i := 0; i <= int(content.Size/READ_SIZE)+1; i++ {
-			toRead := minUint64(READ_SIZE, left)
-			toReadHash := minUint64(READ_SIZE, leftHash)
-
-			toReadAligned := (toRead + 15) &^ 15
-
-			if _, err = io.ReadFull(encryptedFile, readSizedBuffer[:toReadAligned]); err != nil {
-				return err
+			foundU8 = true
+			var outPath string
+			if extractCount == 0 && index == 0 {
+				outPath = path
+			} else if extractCount == 0 {
+				outPath = filepath.Join(path, content.CIDStr)
+			} else {
+				outPath = filepath.Join(path, content.CIDStr, fmt.Sprintf("u8_%X", pos))
 			}
 
-			cipherContent.CryptBlocks(decBuf[:toReadAligned], readSizedBuffer[:toReadAligned])
-			contentHash.Write(decBuf[:toReadHash])
-			if _, err = decryptedBuffer.Write(decBuf[:toRead]); err != nil {
-				return err
+			if err := extractU8(io.NewSectionReader(data, pos, size-pos), outPath); err == nil {
+				extractCount++
 			}
+		}
+	}
 
-			left -= toRead
-			leftHash -= toRead
+	if foundU8 || plain {
+		return nil
+	}
+	tmpPath := appPath + ".tmp"
+	dst, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, io.NewSectionReader(data, 0, size))
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	src.Close()
+	if err == nil {
+		err = os.Rename(tmpPath, appPath)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+	}
+	return err
+}
 
-			if left == 0 {
-				break
-			}
-		}
-		if !bytes.Equal(content.Hash[:sha1.Size], contentHash.Sum(nil)) {
-			return errors.New("content hash mismatch")
-		}
+// isPlainContent reports whether a content file is already decrypted, i.e.
+// the file itself matches the TMD hash
+func isPlainContent(f *os.File, content Content) bool {
+	stat, err := f.Stat()
+	if err != nil || stat.Size() != int64(content.Size) {
+		return false
 	}
-	return nil
+	h := sha1.New()
+	if _, err := io.Copy(h, io.NewSectionReader(f, 0, stat.Size())); err != nil {
+		return false
+	}
+	return bytes.Equal(h.Sum(nil), content.Hash[:sha1.Size])
 }
 
-func extractU8(data []byte, outputPath string) error {
-	reader := bytes.NewReader(data)
+// extractU8 extracts the U8 archive that starts at the beginning of data.
+// Files are copied out in chunks rather than read into memory.
+func extractU8(data *io.SectionReader, outputPath string) error {
+	reader := data
 	var magic uint32
 	if err := binary.Read(reader, binary.BigEndian, &magic); err != nil {
 		return err
 	}
-	if magic != 0x55AA382D {
+	if magic != u8Magic {
 		return errors.New("invalid U8 magic")
 	}
 
@@ -598,6 +532,9 @@ func extractU8(data []byte, outputPath string) error {
 	}
 
 	stringTableOffset := rootNodeOffset + (totalNodes * 12)
+	if dataOffset < stringTableOffset {
+		return fmt.Errorf("invalid U8: data offset %d is before the string table", dataOffset)
+	}
 	stringTableSize := dataOffset - stringTableOffset
 	stringTable := make([]byte, stringTableSize)
 	_, _ = reader.Seek(int64(stringTableOffset), io.SeekStart)
@@ -622,14 +559,11 @@ func extractU8(data []byte, outputPath string) error {
 			dirStack = append(dirStack, currentDir)
 			breakNodes[stackIdx] = nodes[i].Size
 		} else {
-			if nodes[i].Size > 100*1024*1024 {
-				continue
-			}
 			filePath := filepath.Join(currentDir, name)
-			fileData := make([]byte, nodes[i].Size)
-			_, _ = reader.Seek(int64(nodes[i].DataOffset), io.SeekStart)
-			_, _ = io.ReadFull(reader, fileData)
-			_ = os.WriteFile(filePath, fileData, 0644)
+			if dst, err := os.Create(filePath); err == nil {
+				_, _ = io.Copy(dst, io.NewSectionReader(data, int64(nodes[i].DataOffset), int64(nodes[i].Size)))
+				_ = dst.Close()
+			}
 		}
 
 		for stackIdx > 0 && breakNodes[stackIdx] == i+1 {
@@ -644,6 +578,19 @@ func extractU8(data []byte, outputPath string) error {
 
 // DecryptContents decrypts the contents of a downloaded Wii U title
 func DecryptContents(path string, progressReporter ProgressReporter, deleteEncryptedContents bool) error {
+	return DecryptContentsContext(context.Background(), path, progressReporter, deleteEncryptedContents, DefaultDecryptWorkers)
+}
+
+// DecryptContentsContext decrypts the contents of a downloaded Wii U or Wii
+// title, extracting up to workers files at a time (DefaultDecryptWorkers if
+// workers <= 0). It stops soon after ctx is cancelled and returns its error.
+// The encrypted contents are only deleted once everything is decrypted, and
+// the files finished so far are recorded, so running it again resumes.
+func DecryptContentsContext(ctx context.Context, path string, progressReporter ProgressReporter, deleteEncryptedContents bool, workers int) error {
+	if workers <= 0 {
+		workers = DefaultDecryptWorkers
+	}
+
 	tmdPath := filepath.Join(path, "title.tmd")
 	if _, err := os.Stat(tmdPath); os.IsNotExist(err) {
 		return err
@@ -659,17 +606,102 @@ func DecryptContents(path string, progressReporter ProgressReporter, deleteEncry
 	}
 
 	for i := range tmd.Contents {
-		tmd.Contents[i].CIDStr = fmt.Sprintf("%08X", tmd.Contents[i].ID)
-		_, err := os.Stat(filepath.Join(path, tmd.Contents[i].CIDStr+".app"))
+		name, ok := contentName(path, tmd.Contents[i].ID)
+		if !ok {
+			return errors.New("content not found")
+		}
+		tmd.Contents[i].CIDStr = name
+	}
+
+	cipherHashTree, err := titleKeyCipher(path, tmd)
+	if err != nil {
+		return err
+	}
+
+	if tmd.Version == TMD_VERSION_WIIU {
+		// The FST is checked against the TMD before it's trusted, then read
+		// in place: only the chunks holding its entries and names are
+		// decrypted
+		fstContent := tmd.Contents[0]
+		if fstContent.Size > MAX_FST_SIZE {
+			return fmt.Errorf("FST size %d exceeds maximum limit of %d", fstContent.Size, MAX_FST_SIZE)
+		}
+		if err := verifyContent(path, fstContent, cipherHashTree); err != nil {
+			return fmt.Errorf("FST: %w", err)
+		}
+		fstEncFile, err := os.Open(filepath.Join(path, fstContent.CIDStr+".app"))
 		if err != nil {
-			tmd.Contents[i].CIDStr = fmt.Sprintf("%08x", tmd.Contents[i].ID)
-			_, err = os.Stat(filepath.Join(path, tmd.Contents[i].CIDStr+".app"))
-			if err != nil {
-				return errors.New("content not found")
+			return err
+		}
+		defer fstEncFile.Close()
+
+		fstData := newContentReader(fstEncFile, fstContent, cipherHashTree)
+		fst := FSTData{FSTReader: io.NewSectionReader(fstData, 0, fstData.Size()), FSTEntries: make([]FEntry, 0), EntryCount: 0, Entries: 0, NamesOffset: 0}
+		if err := fst.Parse(); err != nil {
+			return err
+		}
+
+		jobs, err := fst.fileJobs(path, tmd)
+		if err != nil {
+			return err
+		}
+		if err := extractFiles(ctx, path, tmdData, tmd, jobs, cipherHashTree, progressReporter, workers); err != nil {
+			return err
+		}
+	} else {
+		// The contents of a Wii title are independent. Each one can be
+		// decrypted again after an interruption: a content that was
+		// written back is recognised as decrypted, and U8 archives are
+		// extracted over the files of the earlier run.
+		g, gctx := errgroup.WithContext(ctx)
+		g.SetLimit(workers)
+		progress := newDecryptionProgress(progressReporter, len(tmd.Contents))
+		for i, content := range tmd.Contents {
+			if gctx.Err() != nil {
+				break
 			}
+			i, content := i, content
+			g.Go(func() error {
+				if err := gctx.Err(); err != nil {
+					return err
+				}
+				if err := decryptWiiContent(path, i, content, cipherHashTree); err != nil {
+					return err
+				}
+				progress.done()
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	progressReporter.UpdateDecryptionProgress(1.0)
+
+	if deleteEncryptedContents {
+		doDeleteEncryptedContents(path)
+	}
+	return nil
+}
+
+// contentName returns the name, without extension, of the .app file of a
+// content in path. Downloads use upper case hex, some tools lower case.
+func contentName(path string, id uint32) (string, bool) {
+	for _, name := range []string{fmt.Sprintf("%08X", id), fmt.Sprintf("%08x", id)} {
+		if _, err := os.Stat(filepath.Join(path, name+".app")); err == nil {
+			return name, true
 		}
 	}
+	return fmt.Sprintf("%08X", id), false
+}
 
+// titleKeyCipher decrypts the title key in the ticket of the title in path
+// and returns the cipher its contents are encrypted with
+func titleKeyCipher(path string, tmd *TMD) (cipher.Block, error) {
 	var encryptedTitleKey []byte
 
 	ticketPath := filepath.Join(path, "title.tik")
@@ -682,12 +714,12 @@ func DecryptContents(path string, progressReporter ProgressReporter, deleteEncry
 			encryptedTitleKey = make([]byte, 0x10)
 			if _, err := io.ReadFull(cetk, encryptedTitleKey); err != nil {
 				_ = cetk.Close()
-				return err
+				return nil, err
 			}
 			_, _ = cetk.Seek(0x1F1, 0)
 			_ = binary.Read(cetk, binary.BigEndian, &ticketKeyIndex)
 			if err := cetk.Close(); err != nil {
-				return err
+				return nil, err
 			}
 		}
 	}
@@ -705,7 +737,7 @@ func DecryptContents(path string, progressReporter ProgressReporter, deleteEncry
 
 	c, err := aes.NewCipher(selectedCommonKey)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var titleIDBytes [8]byte
@@ -719,160 +751,9 @@ func DecryptContents(path string, progressReporter ProgressReporter, deleteEncry
 
 	cipherHashTree, err := aes.NewCipher(decryptedTitleKey)
 	if err != nil {
-		return fmt.Errorf("failed to create AES cipher: %w", err)
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
 	}
-
-	if tmd.Version == TMD_VERSION_WIIU {
-		fstEncFile, err := os.Open(filepath.Join(path, tmd.Contents[0].CIDStr+".app"))
-		if err != nil {
-			return err
-		}
-
-		decryptedBuffer := bytes.Buffer{}
-		if err := decryptContentToBuffer(fstEncFile, &decryptedBuffer, cipherHashTree, tmd.Contents[0]); err != nil {
-			if err := fstEncFile.Close(); err != nil {
-				return err
-			}
-			return err
-		}
-		if err := fstEncFile.Close(); err != nil {
-			return err
-		}
-		fst := FSTData{FSTReader: bytes.NewReader(decryptedBuffer.Bytes()), FSTEntries: make([]FEntry, 0), EntryCount: 0, Entries: 0, NamesOffset: 0}
-		if err := fst.Parse(); err != nil {
-			return err
-		}
-
-		outputPath := path
-		entry := make([]uint32, 0x10)
-		lEntry := make([]uint32, 0x10)
-		level := uint32(0)
-
-		for i := uint32(0); i < fst.Entries-1; i++ {
-			progressReporter.UpdateDecryptionProgress(float64(i) / float64(fst.Entries-1))
-			if level > 0 {
-				for (level >= 1) && (lEntry[level-1] == i+1) {
-					level--
-				}
-			}
-
-			if fst.FSTEntries[i].Type&1 != 0 {
-				entry[level] = i
-				lEntry[level] = fst.FSTEntries[i].Length
-				level++
-				if level >= MAX_LEVELS {
-					return errors.New("level >= MAX_LEVELS")
-				}
-			} else {
-				pathOffset := uint32(0)
-				outputPath = path
-				for j := uint32(0); j < level; j++ {
-					pathOffset = fst.FSTEntries[entry[j]].NameOffset & 0x00FFFFFF
-					fst.FSTReader.Seek(int64(fst.NamesOffset+pathOffset), io.SeekStart)
-					directory, err := readString(fst.FSTReader)
-					if err != nil {
-						return fmt.Errorf("failed to read directory name: %w", err)
-					}
-					outputPath = filepath.Join(outputPath, directory)
-					if err := os.MkdirAll(outputPath, 0755); err != nil {
-						return fmt.Errorf("failed to create directory: %w", err)
-					}
-				}
-				pathOffset = fst.FSTEntries[i].NameOffset & 0x00FFFFFF
-				fst.FSTReader.Seek(int64(fst.NamesOffset+pathOffset), io.SeekStart)
-				fileName, err := readString(fst.FSTReader)
-				if err != nil {
-					return fmt.Errorf("failed to read file name: %w", err)
-				}
-				outputPath = filepath.Clean(filepath.Join(outputPath, fileName))
-				contentOffset := uint64(fst.FSTEntries[i].Offset)
-				if fst.FSTEntries[i].Flags&4 == 0 {
-					contentOffset <<= 5
-				}
-				if fst.FSTEntries[i].Type&0x80 == 0 {
-					matchingContent := tmd.Contents[fst.FSTEntries[i].ContentID]
-					tmdFlags := matchingContent.Type
-					srcFile, err := os.Open(filepath.Join(path, matchingContent.CIDStr+".app"))
-					if err != nil {
-						return err
-					}
-					if tmdFlags&0x02 != 0 {
-						err = extractFileHash(srcFile, 0, contentOffset, uint64(fst.FSTEntries[i].Length), outputPath, cipherHashTree)
-					} else {
-						err = extractFile(srcFile, 0, contentOffset, uint64(fst.FSTEntries[i].Length), outputPath, fst.FSTEntries[i].ContentID, cipherHashTree)
-					}
-					srcFile.Close()
-					if err != nil {
-						return err
-					}
-				}
-			}
-		}
-	} else {
-		for i, content := range tmd.Contents {
-			progressReporter.UpdateDecryptionProgress(float64(i) / float64(len(tmd.Contents)))
-			srcFile, err := os.Open(filepath.Join(path, content.CIDStr+".app"))
-			if err != nil {
-				return err
-			}
-
-			decryptedBuffer := bytes.Buffer{}
-			if err := decryptContentToBuffer(srcFile, &decryptedBuffer, cipherHashTree, content); err != nil {
-				srcFile.Close()
-				return err
-			}
-			srcFile.Close()
-
-			decData := decryptedBuffer.Bytes()
-
-			foundU8 := false
-			extractCount := 0
-			for pos := 0; pos < len(decData)-32; pos += 16 {
-				if binary.BigEndian.Uint32(decData[pos:pos+4]) == 0x55AA382D {
-					if pos+32 > len(decData) {
-						continue
-					}
-					rootNodeOffset := binary.BigEndian.Uint32(decData[pos+4 : pos+8])
-					dataOffset := binary.BigEndian.Uint32(decData[pos+12 : pos+16])
-
-					if rootNodeOffset < 32 || rootNodeOffset > uint32(len(decData)) {
-						continue
-					}
-					if dataOffset < rootNodeOffset || dataOffset > uint32(len(decData)) {
-						continue
-					}
-					if dataOffset-rootNodeOffset > 1000000 {
-						continue
-					}
-
-					foundU8 = true
-					var outPath string
-					if extractCount == 0 && i == 0 {
-						outPath = path
-					} else if extractCount == 0 {
-						outPath = filepath.Join(path, content.CIDStr)
-					} else {
-						outPath = filepath.Join(path, content.CIDStr, fmt.Sprintf("u8_%X", pos))
-					}
-
-					if err := extractU8(decData[pos:], outPath); err == nil {
-						extractCount++
-					}
-				}
-			}
-
-			if !foundU8 {
-				_ = os.WriteFile(filepath.Join(path, content.CIDStr+".app"), decData, 0644)
-			}
-		}
-	}
-
-	progressReporter.UpdateDecryptionProgress(1.0)
-
-	if deleteEncryptedContents {
-		doDeleteEncryptedContents(path)
-	}
-	return nil
+	return cipherHashTree, nil
 }
 
 func doDeleteEncryptedContents(path string) error {
@@ -0,0 +1,107 @@
+package wiiu
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testFile is a file of a content: where it starts in the decrypted data
+// and its size
+type testFile struct {
+	name         string
+	offset, size uint64
+}
+
+var testFiles = []testFile{
+	{"at the start", 0, 0x1000},
+	{"inside the first chunk", 0x20, 0x1000},
+	{"across chunks", BLOCK_SIZE - 0x10, 2*BLOCK_SIZE + 0x20},
+	{"empty", 0x40, 0},
+}
+
+// checkExtracted checks an extracted file and the SHA-1 returned for it
+func checkExtracted(t *testing.T, path, sum string, want []byte) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("extracted %d bytes that differ from the %d of the data", len(got), len(want))
+	}
+	wantSum := sha1.Sum(want)
+	if sum != hex.EncodeToString(wantSum[:]) {
+		t.Errorf("returned SHA-1 %s, want %x", sum, wantSum)
+	}
+}
+
+func TestExtractFileUnhashed(t *testing.T) {
+	block := testCipher(t)
+	data := testData(testUnhashedSize)
+	content, app := encryptUnhashed(block, data)
+	files := append(append([]testFile{}, testFiles...), testFile{"to the unaligned end", 2*BLOCK_SIZE + 0x10, BLOCK_SIZE + 0x1220})
+
+	for _, file := range files {
+		t.Run(file.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "file.bin")
+			r := newContentReader(bytes.NewReader(app), content, block)
+			sum, err := extractFile(context.Background(), r, file.offset, file.size, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkExtracted(t, path, sum, data[file.offset:file.offset+file.size])
+		})
+	}
+
+	r := newContentReader(bytes.NewReader(app), content, block)
+	if _, err := extractFile(context.Background(), r, testUnhashedSize-0x10, 0x20, filepath.Join(t.TempDir(), "file.bin")); err == nil {
+		t.Error("extracted a file that ends past the content")
+	}
+}
+
+func TestExtractFileHashMatchesExtractFile(t *testing.T) {
+	// Hashed contents are extracted by extractFileHash; extractFile reads the
+	// same data through a contentReader
+	block := testCipher(t)
+	data := testData(testHashedBlocks * HASH_BLOCK_SIZE)
+	content, app, _ := encryptHashed(block, data)
+	files := append(append([]testFile{}, testFiles...), testFile{"across the H0 groups", 15*HASH_BLOCK_SIZE + 0x123, 3 * HASH_BLOCK_SIZE})
+
+	for _, file := range files {
+		t.Run(file.name, func(t *testing.T) {
+			want := data[file.offset : file.offset+file.size]
+			dir := t.TempDir()
+
+			hashedPath := filepath.Join(dir, "hashed.bin")
+			sum, err := extractFileHash(context.Background(), bytes.NewReader(app), int64(len(app)), 0, file.offset, file.size, hashedPath, block)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkExtracted(t, hashedPath, sum, want)
+
+			readerPath := filepath.Join(dir, "reader.bin")
+			sum, err = extractFile(context.Background(), newContentReader(bytes.NewReader(app), content, block), file.offset, file.size, readerPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkExtracted(t, readerPath, sum, want)
+		})
+	}
+}
+
+func TestExtractFileHashCorrupt(t *testing.T) {
+	block := testCipher(t)
+	data := testData(testHashedBlocks * HASH_BLOCK_SIZE)
+	_, app, _ := encryptHashed(block, data)
+	app[2*BLOCK_SIZE_HASHED+HASHES_SIZE] ^= 0xff
+
+	path := filepath.Join(t.TempDir(), "file.bin")
+	if _, err := extractFileHash(context.Background(), bytes.NewReader(app), int64(len(app)), 0, 0, 4*HASH_BLOCK_SIZE, path, block); err == nil {
+		t.Error("extracted a file from a corrupt block")
+	}
+}
@@ -2,11 +2,14 @@ package wiiu
 
 import (
 	"context"
+	"crypto/aes"
+	"crypto/sha1"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	ctxio "github.com/jbenet/go-context/io"
@@ -18,6 +21,8 @@ const (
 	maxRetries             = 5
 	retryDelay             = 5 * time.Second
 	maxConcurrentDownloads = 4
+	maxVerifyAttempts      = 3                      // Downloads of a content that fails verification
+	cancelPollInterval     = 100 * time.Millisecond // How often decryption checks ProgressReporter.Cancelled
 	downloadTimeout        = 30 * time.Second
 	NintendoCDNBaseURL     = "http://ccs.cdn.c.shop.nintendowifi.net/ccs/download"
 )
@@ -85,7 +90,10 @@ func (wp *writerProgress) Close() error {
 	return nil
 }
 
-func downloadFileWithSemaphore(ctx context.Context, progressReporter ProgressReporter, client *http.Client, downloadURL, dstPath string, doRetries bool, sem *semaphore.Weighted) error {
+// downloadFileWithSemaphore downloads a content or .h3 file of size bytes. A
+// complete file left by an earlier download is kept, and a partial one is
+// resumed with a Range request.
+func downloadFileWithSemaphore(ctx context.Context, progressReporter ProgressReporter, client *http.Client, downloadURL, dstPath string, size int64, doRetries bool, sem *semaphore.Weighted) error {
 	if err := sem.Acquire(ctx, 1); err != nil {
 		return err
 	}
@@ -93,14 +101,26 @@ func downloadFileWithSemaphore(ctx context.Context, progressReporter ProgressRep
 
 	basePath := filepath.Base(dstPath)
 
+	if size > 0 && existingSize(dstPath, size) == size {
+		progressReporter.UpdateDownloadProgress(size, basePath)
+		progressReporter.MarkFileAsDone(basePath)
+		return nil
+	}
+
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		ctx, cancel := context.WithCancel(ctx)
 		defer cancel()
 
+		// Carry on from what earlier downloads and attempts left
+		offset := existingSize(dstPath, size)
+
 		req, err := http.NewRequestWithContext(ctx, "GET", downloadURL, nil)
 		if err != nil {
 			return err
 		}
+		if offset > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		}
 
 		resp, err := client.Do(req)
 		if err != nil {
@@ -111,7 +131,24 @@ func downloadFileWithSemaphore(ctx context.Context, progressReporter ProgressRep
 			return err
 		}
 
-		if resp.StatusCode != http.StatusOK {
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			// The whole file, whether or not a range was asked for
+			offset = 0
+		case resp.StatusCode == http.StatusPartialContent && offset > 0 &&
+			strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)):
+		case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable || resp.StatusCode == http.StatusPartialContent:
+			// The partial file doesn't fit the one on the CDN: the next
+			// attempt starts over
+			resp.Body.Close()
+			os.Remove(dstPath)
+			progressReporter.SetTotalDownloadedForFile(basePath, 0)
+			if doRetries && attempt < maxRetries && !progressReporter.Cancelled() {
+				time.Sleep(retryDelay)
+				continue
+			}
+			return fmt.Errorf("download error after %d attempts, status code: %d", attempt, resp.StatusCode)
+		default:
 			resp.Body.Close()
 			if doRetries && attempt < maxRetries && !progressReporter.Cancelled() {
 				time.Sleep(retryDelay)
@@ -120,7 +157,11 @@ func downloadFileWithSemaphore(ctx context.Context, progressReporter ProgressRep
 			return fmt.Errorf("download error after %d attempts, status code: %d", attempt, resp.StatusCode)
 		}
 
-		file, err := os.Create(dstPath)
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if offset > 0 {
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		file, err := os.OpenFile(dstPath, flags, 0644)
 		if err != nil {
 			resp.Body.Close()
 			return err
@@ -130,8 +171,9 @@ func downloadFileWithSemaphore(ctx context.Context, progressReporter ProgressRep
 			cancel()
 		})
 
-		progressReporter.SetTotalDownloadedForFile(basePath, 0)
+		progressReporter.SetTotalDownloadedForFile(basePath, offset)
 		writerProgress := newWriterProgress(file, progressReporter, basePath)
+		writerProgress.total = offset
 		writerProgressWithContext := ctxio.NewWriter(ctx, writerProgress)
 
 		watchdog := &WatchdogReader{
@@ -162,6 +204,44 @@ func downloadFileWithSemaphore(ctx context.Context, progressReporter ProgressRep
 	return nil
 }
 
+// existingSize returns how much of a file of size bytes is already on disk.
+// A file that is bigger than it should be doesn't count.
+func existingSize(path string, size int64) int64 {
+	stat, err := os.Stat(path)
+	if err != nil || stat.Size() > size {
+		return 0
+	}
+	return stat.Size()
+}
+
+// contentFileSizes returns the sizes of a content's files on the CDN: the
+// .app, which is padded to the AES block size, and the .h3, which has a hash
+// for every 0x10000000 bytes of a hashed content
+func contentFileSizes(content Content) (appSize, h3Size int64) {
+	appSize = int64((content.Size + aes.BlockSize - 1) &^ (aes.BlockSize - 1))
+	if content.Type&0x2 != 0 {
+		h3Size = int64((content.Size+h3Coverage-1)/h3Coverage) * sha1.Size
+	}
+	return appSize, h3Size
+}
+
+// seedProgress reports what an earlier download left of each content, so
+// the progress of a resumed download starts where it stopped
+func seedProgress(outputDir string, tmd *TMD, progressReporter ProgressReporter) {
+	for _, content := range tmd.Contents {
+		appSize, h3Size := contentFileSizes(content)
+		name := fmt.Sprintf("%08X", content.ID)
+		if n := existingSize(filepath.Join(outputDir, name+".app"), appSize); n > 0 {
+			progressReporter.UpdateDownloadProgress(n, name+".app")
+		}
+		if h3Size > 0 {
+			if n := existingSize(filepath.Join(outputDir, name+".h3"), h3Size); n > 0 {
+				progressReporter.UpdateDownloadProgress(n, name+".h3")
+			}
+		}
+	}
+}
+
 func downloadFile(progressReporter ProgressReporter, client *http.Client, downloadURL, dstPath string, doRetries bool) error {
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		ctx, cancel := context.WithCancel(context.Background())
@@ -233,6 +313,18 @@ func downloadFile(progressReporter ProgressReporter, client *http.Client, downlo
 
 // DownloadTitle downloads and optionally decrypts a Wii U title
 func DownloadTitle(titleID, outputDirectory string, doDecryption bool, progressReporter ProgressReporter, deleteEncryptedContents bool, client *http.Client) error {
+	return DownloadTitleContext(context.Background(), titleID, outputDirectory, doDecryption, progressReporter, deleteEncryptedContents, client)
+}
+
+// DownloadTitleContext is DownloadTitle with a context. Cancelling ctx stops
+// the downloads and the decryption, which is done with DecryptContentsContext.
+// Either way of cancelling returns nil, and leaves a folder that a later run
+// resumes.
+func DownloadTitleContext(ctx context.Context, titleID, outputDirectory string, doDecryption bool, progressReporter ProgressReporter, deleteEncryptedContents bool, client *http.Client) error {
+	cancelled := func() bool {
+		return ctx.Err() != nil || progressReporter.Cancelled()
+	}
+
 	progressReporter.ResetTotals()
 	progressReporter.SetGameTitle(titleID)
 
@@ -248,7 +340,7 @@ func DownloadTitle(titleID, outputDirectory string, doDecryption bool, progressR
 
 	tmdPath := filepath.Join(outputDir, "title.tmd")
 	if err := downloadFile(progressReporter, client, fmt.Sprintf("%s/%s", baseURL, "tmd"), tmdPath, true); err != nil {
-		if progressReporter.Cancelled() {
+		if cancelled() {
 			return nil
 		}
 		return err
@@ -266,7 +358,7 @@ func DownloadTitle(titleID, outputDirectory string, doDecryption bool, progressR
 
 	tikPath := filepath.Join(outputDir, "title.tik")
 	if err := downloadFile(progressReporter, client, fmt.Sprintf("%s/%s", baseURL, "cetk"), tikPath, false); err != nil {
-		if progressReporter.Cancelled() {
+		if cancelled() {
 			return nil
 		}
 		titleKey, err := GenerateKey(titleID)
@@ -278,21 +370,30 @@ func DownloadTitle(titleID, outputDirectory string, doDecryption bool, progressR
 		}
 	}
 
-	var titleSize uint64
+	// Everything that is downloaded counts, padding and .h3 files too, as
+	// the progress of every file is reported
+	var titleSize int64
 	for i := 0; i < int(tmd.ContentCount); i++ {
-		titleSize += tmd.Contents[i].Size
+		appSize, h3Size := contentFileSizes(tmd.Contents[i])
+		titleSize += appSize + h3Size
 	}
 
-	progressReporter.SetDownloadSize(int64(titleSize))
+	progressReporter.SetDownloadSize(titleSize)
 
 	if err := GenerateCert(tmd, filepath.Join(outputDir, "title.cert"), progressReporter, client); err != nil {
-		if progressReporter.Cancelled() {
+		if cancelled() {
 			return nil
 		}
 		return err
 	}
 
-	g, ctx := errgroup.WithContext(context.Background())
+	cipherHashTree, err := titleKeyCipher(outputDir, tmd)
+	if err != nil {
+		return err
+	}
+	seedProgress(outputDir, tmd, progressReporter)
+
+	g, gctx := errgroup.WithContext(ctx)
 	g.SetLimit(maxConcurrentDownloads)
 	sem := semaphore.NewWeighted(maxConcurrentDownloads)
 	progressReporter.SetStartTime(time.Now())
@@ -300,27 +401,48 @@ func DownloadTitle(titleID, outputDirectory string, doDecryption bool, progressR
 	for i := 0; i < int(tmd.ContentCount); i++ {
 		i := i
 		g.Go(func() error {
-			filePath := filepath.Join(outputDir, fmt.Sprintf("%08X.app", tmd.Contents[i].ID))
-			if err := downloadFileWithSemaphore(ctx, progressReporter, client, fmt.Sprintf("%s/%08X", baseURL, tmd.Contents[i].ID), filePath, true, sem); err != nil {
-				if progressReporter.Cancelled() {
-					return errCancel
-				}
-				return err
-			}
-
-			if tmd.Contents[i].Type&0x2 == 2 { // has a hash
-				filePath = filepath.Join(outputDir, fmt.Sprintf("%08X.h3", tmd.Contents[i].ID))
-				if err := downloadFileWithSemaphore(ctx, progressReporter, client, fmt.Sprintf("%s/%08X.h3", baseURL, tmd.Contents[i].ID), filePath, true, sem); err != nil {
-					if progressReporter.Cancelled() {
+			content := tmd.Contents[i]
+			content.CIDStr = fmt.Sprintf("%08X", content.ID)
+			appSize, h3Size := contentFileSizes(content)
+
+			// Files that are already complete are kept. A content that
+			// doesn't match the TMD, e.g. a truncated response, is
+			// downloaded again from the start.
+			for attempt := 1; ; attempt++ {
+				filePath := filepath.Join(outputDir, content.CIDStr+".app")
+				if err := downloadFileWithSemaphore(gctx, progressReporter, client, fmt.Sprintf("%s/%s", baseURL, content.CIDStr), filePath, appSize, true, sem); err != nil {
+					if cancelled() {
 						return errCancel
 					}
 					return err
 				}
+
+				if content.Type&0x2 == 2 { // has a hash
+					filePath = filepath.Join(outputDir, content.CIDStr+".h3")
+					if err := downloadFileWithSemaphore(gctx, progressReporter, client, fmt.Sprintf("%s/%s.h3", baseURL, content.CIDStr), filePath, h3Size, true, sem); err != nil {
+						if cancelled() {
+							return errCancel
+						}
+						return err
+					}
+				}
+				if cancelled() {
+					return errCancel
+				}
+
+				err := verifyContent(outputDir, content, cipherHashTree)
+				if err == nil {
+					return nil
+				}
+				if attempt >= maxVerifyAttempts {
+					return fmt.Errorf("content %s: %w", content.CIDStr, err)
+				}
+				// Downloading again starts from nothing, and so does its progress
+				for _, ext := range []string{".app", ".h3"} {
+					os.Remove(filepath.Join(outputDir, content.CIDStr+ext))
+					progressReporter.SetTotalDownloadedForFile(content.CIDStr+ext, 0)
+				}
 			}
-			if progressReporter.Cancelled() {
-				return errCancel
-			}
-			return nil
 		})
 	}
 
@@ -331,8 +453,23 @@ func DownloadTitle(titleID, outputDirectory string, doDecryption bool, progressR
 		return err
 	}
 
-	if doDecryption && !progressReporter.Cancelled() {
-		if err := DecryptContents(outputDir, progressReporter, deleteEncryptedContents); err != nil {
+	if doDecryption && !cancelled() {
+		decryptCtx, stop := context.WithCancel(ctx)
+		defer stop()
+		go func() {
+			// The reporter's own cancellation is polled, as it has no channel
+			for decryptCtx.Err() == nil {
+				if progressReporter.Cancelled() {
+					stop()
+					return
+				}
+				time.Sleep(cancelPollInterval)
+			}
+		}()
+		if err := DecryptContentsContext(decryptCtx, outputDir, progressReporter, deleteEncryptedContents, DefaultDecryptWorkers); err != nil {
+			if cancelled() {
+				return nil
+			}
 			return err
 		}
 	}
@@ -0,0 +1,365 @@
+package wiiu
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultDecryptWorkers is how many files DecryptContents extracts at a time
+const DefaultDecryptWorkers = 4
+
+// decryptJournalName is the file that records which files of a Wii U title
+// are extracted, while it is being decrypted. It starts with the SHA-1 of
+// the TMD, followed by a line for each finished file: its FST index and the
+// SHA-1 of its data.
+const decryptJournalName = "title.decrypted"
+
+// fileHashesName is the file that lists the SHA-1 of every file extracted
+// from a Wii U title, in the format of sha1sum. The contents were checked
+// against the TMD before they were decrypted, so it lets VerifyFiles check
+// a title once its encrypted contents are deleted.
+const fileHashesName = "title.sha1"
+
+// fileJob is a file of the FST to extract from its content
+type fileJob struct {
+	entry     uint32 // FST index
+	path      string
+	contentID uint16 // Index in the TMD's contents
+	offset    uint64
+	size      uint64
+}
+
+// fileJobs walks the FST and returns the files to extract, creating their
+// folders in path on the way. The walk has to be done in order, as the
+// folder of each file comes from the entries before it.
+func (fst *FSTData) fileJobs(path string, tmd *TMD) ([]fileJob, error) {
+	var jobs []fileJob
+	entry := make([]uint32, 0x10)
+	lEntry := make([]uint32, 0x10)
+	level := uint32(0)
+
+	for i := uint32(0); i < fst.Entries-1; i++ {
+		if level > 0 {
+			for (level >= 1) && (lEntry[level-1] == i+1) {
+				level--
+			}
+		}
+
+		if fst.FSTEntries[i].Type&1 != 0 {
+			entry[level] = i
+			lEntry[level] = fst.FSTEntries[i].Length
+			level++
+			if level >= MAX_LEVELS {
+				return nil, errors.New("level >= MAX_LEVELS")
+			}
+			continue
+		}
+
+		pathOffset := uint32(0)
+		outputPath := path
+		for j := uint32(0); j < level; j++ {
+			pathOffset = fst.FSTEntries[entry[j]].NameOffset & 0x00FFFFFF
+			fst.FSTReader.Seek(int64(fst.NamesOffset+pathOffset), io.SeekStart)
+			directory, err := readString(fst.FSTReader)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read directory name: %w", err)
+			}
+			outputPath = filepath.Join(outputPath, directory)
+			if err := os.MkdirAll(outputPath, 0755); err != nil {
+				return nil, fmt.Errorf("failed to create directory: %w", err)
+			}
+		}
+		pathOffset = fst.FSTEntries[i].NameOffset & 0x00FFFFFF
+		fst.FSTReader.Seek(int64(fst.NamesOffset+pathOffset), io.SeekStart)
+		fileName, err := readString(fst.FSTReader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file name: %w", err)
+		}
+		outputPath = filepath.Clean(filepath.Join(outputPath, fileName))
+		contentOffset := uint64(fst.FSTEntries[i].Offset)
+		if fst.FSTEntries[i].Flags&4 == 0 {
+			contentOffset <<= 5
+		}
+		if fst.FSTEntries[i].Type&0x80 != 0 {
+			continue
+		}
+		if int(fst.FSTEntries[i].ContentID) >= len(tmd.Contents) {
+			return nil, fmt.Errorf("file '%s' is in content %d, the TMD has %d", fileName, fst.FSTEntries[i].ContentID, len(tmd.Contents))
+		}
+		jobs = append(jobs, fileJob{
+			entry:     i,
+			path:      outputPath,
+			contentID: fst.FSTEntries[i].ContentID,
+			offset:    contentOffset,
+			size:      uint64(fst.FSTEntries[i].Length),
+		})
+	}
+	return jobs, nil
+}
+
+// openContent opens the .app file of a content and returns its size
+func openContent(path string, content Content) (*os.File, int64, error) {
+	f, err := os.Open(filepath.Join(path, content.CIDStr+".app"))
+	if err != nil {
+		return nil, 0, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, stat.Size(), nil
+}
+
+// extractFiles extracts the files of a Wii U title with up to workers at a
+// time and lists their hashes in title.sha1. Files recorded in the journal
+// by an earlier run are skipped. On cancellation the files being written are
+// left partial and unrecorded, to be extracted again by the next run.
+func extractFiles(ctx context.Context, path string, tmdData []byte, tmd *TMD, jobs []fileJob, cipherHashTree cipher.Block, progressReporter ProgressReporter, workers int) error {
+	journal, err := openDecryptJournal(path, tmdData)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
+	progress := newDecryptionProgress(progressReporter, len(jobs))
+	var pending []fileJob
+	for _, job := range jobs {
+		if journal.isDone(job) {
+			progress.done()
+			continue
+		}
+		pending = append(pending, job)
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(workers)
+	for _, job := range pending {
+		if gctx.Err() != nil {
+			break
+		}
+		job := job
+		g.Go(func() error {
+			// Each job opens its content, so no more than workers files are
+			// open however many contents a title has
+			content := tmd.Contents[job.contentID]
+			f, size, err := openContent(path, content)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			var sum string
+			if content.Type&0x02 != 0 {
+				sum, err = extractFileHash(gctx, f, size, 0, job.offset, job.size, job.path, cipherHashTree)
+			} else {
+				// Each worker decrypts on its own, the reader keeps the last chunk
+				data := newContentReader(f, content, cipherHashTree)
+				sum, err = extractFile(gctx, data, job.offset, job.size, job.path)
+			}
+			if err != nil {
+				return err
+			}
+			if err := journal.markDone(job.entry, sum); err != nil {
+				return err
+			}
+			progress.done()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := writeFileHashes(path, jobs, journal); err != nil {
+		return err
+	}
+	journal.Close()
+	return os.Remove(filepath.Join(path, decryptJournalName))
+}
+
+// fileSHA1 returns the SHA-1 of a file, in hex
+func fileSHA1(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha1.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// writeFileHashes writes title.sha1 from the hashes in the journal, with
+// the files in FST order. Files an earlier run recorded without a hash are
+// hashed now.
+func writeFileHashes(path string, jobs []fileJob, journal *decryptJournal) error {
+	var b strings.Builder
+	for _, job := range jobs {
+		sum := journal.done[job.entry]
+		if sum == "" {
+			var err error
+			if sum, err = fileSHA1(job.path); err != nil {
+				return err
+			}
+		}
+		rel, err := filepath.Rel(path, job.path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "%s  %s\n", sum, filepath.ToSlash(rel))
+	}
+
+	return writeFileAtomic(filepath.Join(path, fileHashesName), []byte(b.String()))
+}
+
+// decryptJournal records the files of a title that are extracted, and their
+// hashes, so an interrupted decryption can resume. A file is only recorded
+// once it is written in full and synced to disk.
+type decryptJournal struct {
+	mu   sync.Mutex
+	file *os.File
+	done map[uint32]string // FST index -> SHA-1, "" if an older run didn't record it
+}
+
+// openDecryptJournal reads the journal of an earlier run on the same TMD, if
+// any, and starts a new one holding the files it recorded
+func openDecryptJournal(path string, tmdData []byte) (*decryptJournal, error) {
+	sum := sha1.Sum(tmdData)
+	header := hex.EncodeToString(sum[:])
+	journalPath := filepath.Join(path, decryptJournalName)
+
+	j := &decryptJournal{done: make(map[uint32]string)}
+	if data, err := os.ReadFile(journalPath); err == nil {
+		lines := strings.Split(string(data), "\n")
+		// The last line is either empty or cut short by an interruption
+		if len(lines) > 1 && lines[0] == header {
+			for _, line := range lines[1 : len(lines)-1] {
+				index, sum, _ := strings.Cut(line, " ")
+				if entry, err := strconv.ParseUint(index, 10, 32); err == nil {
+					j.done[uint32(entry)] = sum
+				}
+			}
+		}
+	}
+
+	// The journal is rewritten in full before appending to it, so an
+	// interruption leaves either the old one or the new one
+	var b strings.Builder
+	fmt.Fprintln(&b, header)
+	for entry, sum := range j.done {
+		if sum == "" {
+			fmt.Fprintln(&b, entry)
+		} else {
+			fmt.Fprintln(&b, entry, sum)
+		}
+	}
+	if err := writeFileAtomic(journalPath, []byte(b.String())); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(journalPath, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	j.file = f
+	return j, nil
+}
+
+// writeFileAtomic replaces a file with data by writing it to a temporary
+// file, syncing it to disk and renaming it over the file
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// isDone reports whether a file was extracted by an earlier run and is
+// still there
+func (j *decryptJournal) isDone(job fileJob) bool {
+	if _, ok := j.done[job.entry]; !ok {
+		return false
+	}
+	stat, err := os.Stat(job.path)
+	return err == nil && uint64(stat.Size()) == job.size
+}
+
+// markDone records a file as extracted, with the SHA-1 of its data. The
+// file must already be synced to disk; the record is synced before it
+// returns.
+func (j *decryptJournal) markDone(entry uint32, sum string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.done[entry] = sum
+	if _, err := fmt.Fprintln(j.file, entry, sum); err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+// Close closes the journal file, which stays on disk
+func (j *decryptJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+// decryptionProgress reports the share of the files, or contents, that are
+// decrypted. The workers call done as they finish.
+type decryptionProgress struct {
+	mu       sync.Mutex
+	reporter ProgressReporter
+	total    int
+	count    int
+}
+
+func newDecryptionProgress(reporter ProgressReporter, total int) *decryptionProgress {
+	reporter.UpdateDecryptionProgress(0)
+	return &decryptionProgress{reporter: reporter, total: total}
+}
+
+func (p *decryptionProgress) done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.count++
+	p.reporter.UpdateDecryptionProgress(float64(p.count) / float64(p.total))
+}
@@ -0,0 +1,128 @@
+package wiiu
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testReporter is a ProgressReporter that keeps the decryption progress
+type testReporter struct {
+	decrypted float64
+}
+
+func (r *testReporter) SetGameTitle(title string)                                   {}
+func (r *testReporter) UpdateDownloadProgress(downloaded int64, filename string)    {}
+func (r *testReporter) UpdateDecryptionProgress(progress float64)                   { r.decrypted = progress }
+func (r *testReporter) Cancelled() bool                                             { return false }
+func (r *testReporter) SetCancelled()                                               {}
+func (r *testReporter) SetDownloadSize(size int64)                                  {}
+func (r *testReporter) ResetTotals()                                                {}
+func (r *testReporter) MarkFileAsDone(filename string)                              {}
+func (r *testReporter) SetTotalDownloadedForFile(filename string, downloaded int64) {}
+func (r *testReporter) SetStartTime(startTime time.Time)                            {}
+
+// journalTitle is an unhashed content on disk and the jobs of three files
+// in it
+func journalTitle(t *testing.T) (dir string, tmd *TMD, jobs []fileJob, data []byte) {
+	t.Helper()
+	dir = t.TempDir()
+	data = testData(testUnhashedSize)
+	content, app := encryptUnhashed(testCipher(t), data)
+	if err := os.WriteFile(filepath.Join(dir, content.CIDStr+".app"), app, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tmd = &TMD{Contents: []Content{content}}
+	jobs = []fileJob{
+		{entry: 1, path: filepath.Join(dir, "a.bin"), offset: 0, size: 0x1000},
+		{entry: 2, path: filepath.Join(dir, "b.bin"), offset: BLOCK_SIZE - 0x10, size: 0x2000},
+		{entry: 3, path: filepath.Join(dir, "c.bin"), offset: 2 * BLOCK_SIZE, size: 0x3000},
+	}
+	return dir, tmd, jobs, data
+}
+
+func sha1Hex(data []byte) string {
+	sum := sha1.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestExtractFilesResume(t *testing.T) {
+	tests := []struct {
+		name        string
+		journalTMD  string
+		wantSkipped bool
+	}{
+		{"same TMD", "tmd", true},
+		{"other TMD", "other tmd", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, tmd, jobs, data := journalTitle(t)
+			want := func(job fileJob) []byte { return data[job.offset : job.offset+job.size] }
+
+			// An earlier run finished a.bin, and b.bin before it was cut
+			// short. a.bin is filled with a marker of the right size, to
+			// tell whether it's extracted again.
+			marker := bytes.Repeat([]byte("A"), int(jobs[0].size))
+			writeTestFile(t, jobs[0].path, marker)
+			writeTestFile(t, jobs[1].path, want(jobs[1])[:0x100])
+			journal, err := openDecryptJournal(dir, []byte(tt.journalTMD))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, job := range jobs[:2] {
+				if err := journal.markDone(job.entry, sha1Hex(want(job))); err != nil {
+					t.Fatal(err)
+				}
+			}
+			journal.Close()
+
+			reporter := &testReporter{}
+			if err := extractFiles(context.Background(), dir, []byte("tmd"), tmd, jobs, testCipher(t), reporter, 2); err != nil {
+				t.Fatal(err)
+			}
+
+			a, _ := os.ReadFile(jobs[0].path)
+			if skipped := bytes.Equal(a, marker); skipped != tt.wantSkipped {
+				t.Errorf("a.bin skipped: %v, want %v", skipped, tt.wantSkipped)
+			}
+			for _, job := range jobs[1:] {
+				if got, _ := os.ReadFile(job.path); !bytes.Equal(got, want(job)) {
+					t.Errorf("%s: not extracted again", filepath.Base(job.path))
+				}
+			}
+			if reporter.decrypted != 1 {
+				t.Errorf("progress ended at %v", reporter.decrypted)
+			}
+
+			if _, err := os.Stat(filepath.Join(dir, decryptJournalName)); !os.IsNotExist(err) {
+				t.Errorf("journal left behind: %v", err)
+			}
+			hashes, err := os.ReadFile(filepath.Join(dir, fileHashesName))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var wantHashes strings.Builder
+			for _, job := range jobs {
+				fmt.Fprintf(&wantHashes, "%s  %s\n", sha1Hex(want(job)), filepath.Base(job.path))
+			}
+			if string(hashes) != wantHashes.String() {
+				t.Errorf("%s:\n%s\nwant\n%s", fileHashesName, hashes, wantHashes.String())
+			}
+		})
+	}
+}
+
+func writeTestFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,103 @@
+package wiiu
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrTitleNotFound is returned by FetchTitleInfo when the CDN has no such
+// title, e.g. a game without an update
+var ErrTitleNotFound = errors.New("title not on the CDN")
+
+// maxTMDSize caps how much of a TMD is read; real ones are a few KB
+const maxTMDSize = 1 << 20
+
+// RelatedTitleID returns the title ID of the update (TID_HIGH_UPDATE) or
+// DLC (TID_HIGH_DLC) of a game. Title IDs are 16 hex digits, as in the
+// catalog; the result is lower case like them.
+func RelatedTitleID(titleID string, high uint32) (string, error) {
+	id, err := strconv.ParseUint(titleID, 16, 64)
+	if err != nil || len(titleID) != 16 {
+		return "", fmt.Errorf("invalid title ID '%s'", titleID)
+	}
+	return fmt.Sprintf("%08x%08x", high, uint32(id)), nil
+}
+
+// TitleInfo is what the TMD of a title on the CDN says about it
+type TitleInfo struct {
+	TitleID string
+	Version uint16
+	Size    uint64 // Total size of the encrypted contents
+}
+
+// FetchTitleInfo downloads the TMD of a title to find its latest version and
+// size. It returns ErrTitleNotFound if the CDN has no such title.
+func FetchTitleInfo(client *http.Client, titleID string) (*TitleInfo, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/%s/tmd", NintendoCDNBaseURL, titleID), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "WiiUDownloader")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrTitleNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("TMD download error, status code: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxTMDSize))
+	if err != nil {
+		return nil, err
+	}
+	tmd, err := ParseTMD(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TMD: %w", err)
+	}
+
+	info := &TitleInfo{TitleID: titleID, Version: tmd.TitleVersion}
+	for _, content := range tmd.Contents {
+		info.Size += content.Size
+	}
+	return info, nil
+}
+
+// MLCTitleDir returns where a title is installed in the emulated Wii U
+// storage of an emulator, e.g. mlc01/usr/title/0005000e/101c9500 for an
+// update
+func MLCTitleDir(mlcDir, titleID string) string {
+	titleID = strings.ToLower(titleID)
+	return filepath.Join(mlcDir, "usr", "title", titleID[:8], titleID[8:])
+}
+
+// InstalledVersion returns the version of the decrypted title in dir, from
+// its meta/meta.xml. It returns false when no title is installed there.
+func InstalledVersion(dir string) (uint16, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, "meta", "meta.xml"))
+	if err != nil {
+		return 0, false
+	}
+	var meta struct {
+		TitleVersion string `xml:"title_version"`
+	}
+	if err := xml.Unmarshal(data, &meta); err != nil {
+		return 0, false
+	}
+	version, err := strconv.ParseUint(strings.TrimSpace(meta.TitleVersion), 10, 16)
+	if err != nil {
+		return 0, false
+	}
+	return uint16(version), true
+}
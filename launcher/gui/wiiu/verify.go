@@ -0,0 +1,233 @@
+package wiiu
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Content verification
+//
+// The TMD has a SHA-1 for every content. For unhashed contents it is the
+// hash of the decrypted content. Hashed contents come with an .h3 file, and
+// the TMD hash (H4) is the hash of that file. Each 0x10000 byte block of a
+// hashed content starts with the H0, H1 and H2 hashes of its part of the tree:
+// H0 hashes the block's data, each H1 the H0s of 16 blocks, each H2 the H1s
+// of 256 blocks, and each H3 the H2s of 4096 blocks.
+
+// ErrHashMismatch is wrapped by the errors of contents whose data doesn't
+// match the TMD
+var ErrHashMismatch = errors.New("hash mismatch")
+
+// ErrContentMissing is wrapped by the errors of contents that weren't
+// downloaded, or only in part, and of decrypted files that are gone
+var ErrContentMissing = errors.New("content missing")
+
+const (
+	hashEntries    = 16 // Hashes per level of the tree
+	hashesPerBlock = hashEntries * sha1.Size
+	h3Coverage     = BLOCK_SIZE_HASHED * hashEntries * hashEntries * hashEntries // Bytes of content under one H3 hash
+)
+
+// ContentCheck is the result of verifying one content of a title
+type ContentCheck struct {
+	Content Content
+	Err     error // nil when the content matches the TMD
+}
+
+// Hashed reports whether the content has a hash tree
+func (c ContentCheck) Hashed() bool {
+	return c.Content.Type&0x2 != 0
+}
+
+// VerifyTitle checks the encrypted contents of a downloaded title against
+// its TMD, without decrypting it to disk. It needs title.tmd, title.tik and
+// the .app and .h3 files, so it works on folders that kept their encrypted
+// contents; VerifyFiles checks the others. The error is only for titles that can't be checked at all.
+func VerifyTitle(path string) ([]ContentCheck, error) {
+	tmdData, err := os.ReadFile(filepath.Join(path, "title.tmd"))
+	if err != nil {
+		return nil, err
+	}
+	tmd, err := ParseTMD(tmdData)
+	if err != nil {
+		return nil, err
+	}
+	if tmd.Version != TMD_VERSION_WIIU {
+		return nil, errors.New("only Wii U titles can be verified")
+	}
+	cipherHashTree, err := titleKeyCipher(path, tmd)
+	if err != nil {
+		return nil, err
+	}
+
+	checks := make([]ContentCheck, len(tmd.Contents))
+	for i, content := range tmd.Contents {
+		content.CIDStr, _ = contentName(path, content.ID)
+		checks[i] = ContentCheck{Content: content, Err: verifyContent(path, content, cipherHashTree)}
+	}
+	return checks, nil
+}
+
+// FileCheck is the result of verifying one decrypted file of a title
+type FileCheck struct {
+	Path string // Relative to the title folder, slash-separated
+	Err  error  // nil when the file matches its recorded hash
+}
+
+// VerifyFiles checks the decrypted files of a title against the hashes
+// recorded in title.sha1 when it was decrypted, so it works on folders whose
+// encrypted contents were deleted. The error is only for titles that can't
+// be checked at all; it wraps os.ErrNotExist for titles decrypted before
+// hashes were recorded.
+func VerifyFiles(path string) ([]FileCheck, error) {
+	data, err := os.ReadFile(filepath.Join(path, fileHashesName))
+	if err != nil {
+		return nil, err
+	}
+	var checks []FileCheck
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		sum, name, ok := strings.Cut(line, "  ")
+		if !ok {
+			return nil, fmt.Errorf("%s: malformed line %q", fileHashesName, line)
+		}
+		check := FileCheck{Path: name}
+		actual, err := fileSHA1(filepath.Join(path, filepath.FromSlash(name)))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			check.Err = fmt.Errorf("%w: file not found", ErrContentMissing)
+		case err != nil:
+			check.Err = err
+		case actual != sum:
+			check.Err = fmt.Errorf("%w: file SHA-1", ErrHashMismatch)
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// verifyContent checks one encrypted content, content.CIDStr.app in path,
+// against its TMD hash
+func verifyContent(path string, content Content, cipherHashTree cipher.Block) error {
+	f, err := os.Open(filepath.Join(path, content.CIDStr+".app"))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrContentMissing
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	encryptedSize, _ := contentFileSizes(content)
+	if stat.Size() < encryptedSize {
+		return fmt.Errorf("%w: %d of %d bytes", ErrContentMissing, stat.Size(), encryptedSize)
+	}
+
+	r := bufio.NewReaderSize(f, BLOCK_SIZE_HASHED)
+	if content.Type&0x2 != 0 {
+		h3Data, err := os.ReadFile(filepath.Join(path, content.CIDStr+".h3"))
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%w: no .h3 file", ErrContentMissing)
+		}
+		if err != nil {
+			return err
+		}
+		return verifyHashedContent(r, h3Data, content, cipherHashTree)
+	}
+	return verifyUnhashedContent(r, content, cipherHashTree)
+}
+
+// verifyUnhashedContent compares the SHA-1 of the decrypted content with the
+// TMD's
+func verifyUnhashedContent(r io.Reader, content Content, cipherHashTree cipher.Block) error {
+	var iv [aes.BlockSize]byte
+	copy(iv[:], content.Index)
+	decrypter := cipher.NewCBCDecrypter(cipherHashTree, iv[:])
+
+	hash := sha1.New()
+	buf := make([]byte, BLOCK_SIZE)
+	for left := content.Size; left > 0; {
+		n := minUint64(uint64(len(buf)), left)
+		aligned := (n + aes.BlockSize - 1) &^ (aes.BlockSize - 1)
+		if _, err := io.ReadFull(r, buf[:aligned]); err != nil {
+			return fmt.Errorf("%w: %v", ErrContentMissing, err)
+		}
+		decrypter.CryptBlocks(buf[:aligned], buf[:aligned])
+		hash.Write(buf[:n])
+		left -= n
+	}
+	if !bytes.Equal(hash.Sum(nil), content.Hash[:sha1.Size]) {
+		return fmt.Errorf("%w: content SHA-1", ErrHashMismatch)
+	}
+	return nil
+}
+
+// verifyHashedContent checks the .h3 file against the TMD, then every block
+// of the content against the hash tree
+func verifyHashedContent(r io.Reader, h3Data []byte, content Content, cipherHashTree cipher.Block) error {
+	h4 := sha1.Sum(h3Data)
+	if !bytes.Equal(h4[:], content.Hash[:sha1.Size]) {
+		return fmt.Errorf("%w: H4 (the .h3 file)", ErrHashMismatch)
+	}
+
+	blockCount := content.Size / BLOCK_SIZE_HASHED
+	h3Count := (content.Size + h3Coverage - 1) / h3Coverage
+	if uint64(len(h3Data)) < h3Count*sha1.Size {
+		return fmt.Errorf("%w: .h3 file has %d hashes, %d needed", ErrHashMismatch, len(h3Data)/sha1.Size, h3Count)
+	}
+
+	encryptedHashes := make([]byte, HASHES_SIZE)
+	hashes := make([]byte, HASHES_SIZE)
+	data := make([]byte, HASH_BLOCK_SIZE)
+	var zeroIV [aes.BlockSize]byte
+	for block := uint64(0); block < blockCount; block++ {
+		if _, err := io.ReadFull(r, encryptedHashes); err != nil {
+			return fmt.Errorf("%w: %v", ErrContentMissing, err)
+		}
+		cipher.NewCBCDecrypter(cipherHashTree, zeroIV[:]).CryptBlocks(hashes, encryptedHashes)
+
+		h0Hashes := hashes[0:hashesPerBlock]
+		h1Hashes := hashes[hashesPerBlock : 2*hashesPerBlock]
+		h2Hashes := hashes[2*hashesPerBlock : 3*hashesPerBlock]
+		entry := func(hashes []byte, index uint64) []byte {
+			index %= hashEntries
+			return hashes[index*sha1.Size : (index+1)*sha1.Size]
+		}
+		h0 := entry(h0Hashes, block)
+		h1 := entry(h1Hashes, block/hashEntries)
+		h2 := entry(h2Hashes, block/(hashEntries*hashEntries))
+		h3Index := block / (hashEntries * hashEntries * hashEntries)
+		h3 := h3Data[h3Index*sha1.Size : (h3Index+1)*sha1.Size]
+
+		if sum := sha1.Sum(h2Hashes); !bytes.Equal(sum[:], h3) {
+			return fmt.Errorf("%w: H3 of block %d", ErrHashMismatch, block)
+		}
+		if sum := sha1.Sum(h1Hashes); !bytes.Equal(sum[:], h2) {
+			return fmt.Errorf("%w: H2 of block %d", ErrHashMismatch, block)
+		}
+		if sum := sha1.Sum(h0Hashes); !bytes.Equal(sum[:], h1) {
+			return fmt.Errorf("%w: H1 of block %d", ErrHashMismatch, block)
+		}
+
+		if _, err := io.ReadFull(r, data); err != nil {
+			return fmt.Errorf("%w: %v", ErrContentMissing, err)
+		}
+		cipher.NewCBCDecrypter(cipherHashTree, h0[:aes.BlockSize]).CryptBlocks(data, data)
+		if sum := sha1.Sum(data); !bytes.Equal(sum[:], h0) {
+			return fmt.Errorf("%w: H0 of block %d", ErrHashMismatch, block)
+		}
+	}
+	return nil
+}
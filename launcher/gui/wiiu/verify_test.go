@@ -0,0 +1,58 @@
+package wiiu
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestVerifyUnhashedContent(t *testing.T) {
+	block := testCipher(t)
+	content, app := encryptUnhashed(block, testData(testUnhashedSize))
+	if err := verifyUnhashedContent(bytes.NewReader(app), content, block); err != nil {
+		t.Fatalf("intact content: %v", err)
+	}
+
+	corrupt := append([]byte(nil), app...)
+	corrupt[2*BLOCK_SIZE+0x35] ^= 0xff
+	if err := verifyUnhashedContent(bytes.NewReader(corrupt), content, block); !errors.Is(err, ErrHashMismatch) {
+		t.Errorf("corrupt content: got %v, want %v", err, ErrHashMismatch)
+	}
+	if err := verifyUnhashedContent(bytes.NewReader(app[:BLOCK_SIZE]), content, block); !errors.Is(err, ErrContentMissing) {
+		t.Errorf("short content: got %v, want %v", err, ErrContentMissing)
+	}
+}
+
+func TestVerifyHashedContent(t *testing.T) {
+	block := testCipher(t)
+	content, app, h3 := encryptHashed(block, testData(testHashedBlocks*HASH_BLOCK_SIZE))
+	if err := verifyHashedContent(bytes.NewReader(app), h3, content, block); err != nil {
+		t.Fatalf("intact content: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		corrupt func(app, h3 []byte)
+		want    string
+	}{
+		{"data of block 17", func(app, h3 []byte) { app[17*BLOCK_SIZE_HASHED+HASHES_SIZE+0x100] ^= 0xff }, "H0 of block 17"},
+		{"H0 table of block 3", func(app, h3 []byte) { app[3*BLOCK_SIZE_HASHED+0x10] ^= 0xff }, "H1 of block 3"},
+		{"H2 table of block 5", func(app, h3 []byte) { app[5*BLOCK_SIZE_HASHED+2*hashesPerBlock] ^= 0xff }, "H3 of block 5"},
+		{".h3 file", func(app, h3 []byte) { h3[0] ^= 0xff }, "H4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, h3 := append([]byte(nil), app...), append([]byte(nil), h3...)
+			tt.corrupt(app, h3)
+			err := verifyHashedContent(bytes.NewReader(app), h3, content, block)
+			if !errors.Is(err, ErrHashMismatch) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want %v: %s", err, ErrHashMismatch, tt.want)
+			}
+		})
+	}
+
+	if err := verifyHashedContent(bytes.NewReader(app[:4*BLOCK_SIZE_HASHED]), h3, content, block); !errors.Is(err, ErrContentMissing) {
+		t.Errorf("short content: got %v, want %v", err, ErrContentMissing)
+	}
+}
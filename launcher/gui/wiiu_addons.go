@@ -0,0 +1,282 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emubuddy/gui/launch"
+	"github.com/emubuddy/gui/wiiu"
+)
+
+// Wii U updates and DLC
+//
+// A game's update (title ID high 0005000e) and DLC (0005000c) share the low
+// half of its title ID. Their latest versions are read from the TMDs on the
+// CDN when a Wii U game is shown in the details pane. They're downloaded
+// through the queue as entries of their own and installed into Cemu's mlc01,
+// e.g. mlc01/usr/title/0005000e/101c9500, where Cemu looks for them; the
+// version installed there comes from the title's meta/meta.xml.
+
+// wiiuAddonKinds are the titles that go with a game, in the order shown
+var wiiuAddonKinds = []struct {
+	label string
+	high  uint32
+}{
+	{"Update", wiiu.TID_HIGH_UPDATE},
+	{"DLC", wiiu.TID_HIGH_DLC},
+}
+
+// wiiuAddonTimeout bounds the TMD lookups of the details pane
+const wiiuAddonTimeout = 15 * time.Second
+
+// wiiuStagingDir is the folder that titles are downloaded to before they
+// replace the installed version: in the ROM folder for games, in mlc01 for
+// updates and DLC. Cemu ignores it.
+const wiiuStagingDir = "emubuddy-downloads"
+
+// WiiUAddon is an update or DLC of a game. Available is nil when the CDN
+// has none.
+type WiiUAddon struct {
+	Label     string
+	TitleID   string
+	Available *wiiu.TitleInfo
+}
+
+var (
+	wiiuAddonCache    = make(map[string][]WiiUAddon) // By the game's title ID
+	wiiuAddonFetching = make(map[string]bool)
+	wiiuAddonMu       sync.Mutex
+)
+
+// isWiiUAddon reports whether a title ID is an update or DLC
+func isWiiUAddon(titleID string) bool {
+	high := strings.ToLower(titleID)
+	if len(high) < 8 {
+		return false
+	}
+	high = high[:8]
+	return high == fmt.Sprintf("%08x", wiiu.TID_HIGH_UPDATE) || high == fmt.Sprintf("%08x", wiiu.TID_HIGH_DLC)
+}
+
+// wiiuAddons returns the update and DLC of a game once they've been looked
+// up. Until then it returns false and looks them up in the background,
+// calling done when they're known.
+func wiiuAddons(game ROM, done func()) ([]WiiUAddon, bool) {
+	wiiuAddonMu.Lock()
+	defer wiiuAddonMu.Unlock()
+	if addons, ok := wiiuAddonCache[game.TitleID]; ok {
+		return addons, true
+	}
+	if wiiuAddonFetching[game.TitleID] {
+		return nil, false
+	}
+	wiiuAddonFetching[game.TitleID] = true
+
+	go func() {
+		addons, err := fetchWiiUAddons(game.TitleID)
+		wiiuAddonMu.Lock()
+		delete(wiiuAddonFetching, game.TitleID)
+		if err == nil {
+			wiiuAddonCache[game.TitleID] = addons
+		}
+		wiiuAddonMu.Unlock()
+		if err != nil {
+			// Not cached, so it's tried again the next time the game is shown
+			logDebug("Wii U update/DLC lookup failed for %s: %v", game.TitleID, err)
+			return
+		}
+		done()
+	}()
+	return nil, false
+}
+
+// fetchWiiUAddons reads the TMDs of a game's update and DLC from the CDN
+func fetchWiiUAddons(titleID string) ([]WiiUAddon, error) {
+	client := &http.Client{Timeout: wiiuAddonTimeout}
+	var addons []WiiUAddon
+	for _, kind := range wiiuAddonKinds {
+		id, err := wiiu.RelatedTitleID(titleID, kind.high)
+		if err != nil {
+			return nil, err
+		}
+		info, err := wiiu.FetchTitleInfo(client, id)
+		if err != nil && !errors.Is(err, wiiu.ErrTitleNotFound) {
+			return nil, err
+		}
+		addons = append(addons, WiiUAddon{Label: kind.label, TitleID: id, Available: info})
+	}
+	return addons, nil
+}
+
+// cemuMLCDir returns Cemu's mlc01 folder, where updates and DLC go
+func cemuMLCDir(config SystemConfig) string {
+	env := launch.NewEnv(baseDir)
+	return env.CemuMLC(env.Resolve(config.Emulator.Location()))
+}
+
+// wiiuInstallDir returns the folder a Wii U title is installed to: the ROM
+// folder for games, Cemu's mlc01 for updates and DLC
+func wiiuInstallDir(config SystemConfig, game ROM) string {
+	if isWiiUAddon(game.TitleID) {
+		return wiiu.MLCTitleDir(cemuMLCDir(config), game.TitleID)
+	}
+	return filepath.Join(romsDir, config.Dir, wiiuDirName(game.Name))
+}
+
+// wiiuWorkDir returns the folder a Wii U title is downloaded and decrypted
+// in. Titles are staged next to where they're installed, so a failed or
+// cancelled download leaves the installed version alone.
+func wiiuWorkDir(config SystemConfig, game ROM) string {
+	parent := filepath.Join(romsDir, config.Dir)
+	if isWiiUAddon(game.TitleID) {
+		parent = cemuMLCDir(config)
+	}
+	return filepath.Join(parent, wiiuStagingDir, strings.ToLower(game.TitleID))
+}
+
+// installWiiUTitle replaces the installed version of a game, update or DLC
+// with the one decrypted in workDir. The installed version is moved next to
+// workDir first and only deleted once the new one is in place, so a failed
+// rename leaves it installed.
+func installWiiUTitle(workDir, installDir string) error {
+	if err := os.MkdirAll(filepath.Dir(installDir), 0755); err != nil {
+		return err
+	}
+	oldDir := workDir + ".old"
+	if err := os.RemoveAll(oldDir); err != nil { // Left by an earlier install
+		return err
+	}
+	replacing := fileExists(installDir)
+	if replacing {
+		if err := os.Rename(installDir, oldDir); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(workDir, installDir); err != nil {
+		if replacing {
+			if restoreErr := os.Rename(oldDir, installDir); restoreErr != nil {
+				logDebug("Could not restore %s from %s: %v", installDir, oldDir, restoreErr)
+			}
+		}
+		return err
+	}
+	if replacing {
+		os.RemoveAll(oldDir)
+	}
+	os.Remove(filepath.Dir(workDir)) // The staging folder, once it's empty
+	return nil
+}
+
+// wiiuAddonROM returns the queue entry of an update or DLC of a game
+func wiiuAddonROM(game ROM, addon WiiUAddon) ROM {
+	return ROM{
+		Name:     fmt.Sprintf("%s [%s]", game.Name, addon.Label),
+		Size:     formatROMSize(int64(addon.Available.Size)),
+		TitleID:  addon.TitleID,
+		Region:   game.Region,
+		systemID: game.systemID,
+	}
+}
+
+// installedAddonVersion returns the version of an update or DLC in Cemu's
+// mlc01, and false if it isn't installed
+func installedAddonVersion(config SystemConfig, addon WiiUAddon) (uint16, bool) {
+	return wiiu.InstalledVersion(wiiu.MLCTitleDir(cemuMLCDir(config), addon.TitleID))
+}
+
+// wiiuAddonsText describes the update and DLC of the highlighted Wii U game
+// for the details pane
+func (a *App) wiiuAddonsText(game ROM) string {
+	config := systems[a.gameSystem(game)]
+	if config.SpecialDownload != "wiiu" || game.TitleID == "" || isWiiUAddon(game.TitleID) {
+		return ""
+	}
+	addons, ok := wiiuAddons(game, a.onWiiUAddonsLoaded)
+	if !ok {
+		return "Looking for updates and DLC..."
+	}
+
+	var lines []string
+	offered := false
+	for _, addon := range addons {
+		if addon.Available == nil {
+			lines = append(lines, addon.Label+": none")
+			continue
+		}
+		line := fmt.Sprintf("%s: v%d, %s", addon.Label, addon.Available.Version, formatROMSize(int64(addon.Available.Size)))
+		if version, installed := installedAddonVersion(config, addon); !installed {
+			line += " (not installed)"
+			offered = true
+		} else if version < addon.Available.Version {
+			line += fmt.Sprintf(" (v%d installed)", version)
+			offered = true
+		} else {
+			line += " (installed)"
+		}
+		lines = append(lines, line)
+	}
+	if offered {
+		lines = append(lines, "Press U (LB in details) to download")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// onWiiUAddonsLoaded shows the update and DLC of the highlighted game once
+// they've been looked up or one of them has been installed
+func (a *App) onWiiUAddonsLoaded() {
+	if a.selectedGameIdx < 0 || a.selectedGameIdx >= len(a.filteredGames) {
+		return
+	}
+	a.detailsAddons.SetText(a.wiiuAddonsText(a.filteredGames[a.selectedGameIdx]))
+}
+
+// downloadWiiUAddons queues the update and DLC of the selected Wii U game
+// that are newer than the installed ones
+func (a *App) downloadWiiUAddons() {
+	if a.selectedGameIdx < 0 || a.selectedGameIdx >= len(a.filteredGames) {
+		return
+	}
+	game := a.filteredGames[a.selectedGameIdx]
+	sysID := a.gameSystem(game)
+	config := systems[sysID]
+	if config.SpecialDownload != "wiiu" || game.TitleID == "" {
+		a.statusBar.SetText("Updates and DLC are only for Wii U games")
+		return
+	}
+	addons, ok := wiiuAddons(game, a.onWiiUAddonsLoaded)
+	if !ok {
+		a.statusBar.SetText("Still looking for updates and DLC, try again in a moment")
+		return
+	}
+
+	var queued []string
+	inQueue := false
+	for _, addon := range addons {
+		if addon.Available == nil {
+			continue
+		}
+		if version, installed := installedAddonVersion(config, addon); installed && version >= addon.Available.Version {
+			continue
+		}
+		if a.downloads.Enqueue(sysID, wiiuAddonROM(game, addon)) {
+			queued = append(queued, fmt.Sprintf("%s v%d", addon.Label, addon.Available.Version))
+		} else {
+			inQueue = true
+		}
+	}
+	if len(queued) == 0 && inQueue {
+		a.statusBar.SetText("Already in download queue: " + game.Name)
+		return
+	}
+	if len(queued) == 0 {
+		a.statusBar.SetText("No new update or DLC to download for: " + game.Name)
+		return
+	}
+	a.statusBar.SetText(fmt.Sprintf("Queued for download: %s (%s)", game.Name, strings.Join(queued, ", ")))
+}